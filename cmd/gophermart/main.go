@@ -1,53 +1,106 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"os"
+
 	"github.com/gitslim/gophermart/internal/accrual"
+	"github.com/gitslim/gophermart/internal/certs"
 	"github.com/gitslim/gophermart/internal/conf"
+	"github.com/gitslim/gophermart/internal/events"
+	"github.com/gitslim/gophermart/internal/health"
 	"github.com/gitslim/gophermart/internal/logging"
 	"github.com/gitslim/gophermart/internal/logging/sugared"
+	"github.com/gitslim/gophermart/internal/password"
+	"github.com/gitslim/gophermart/internal/ratelimit"
+	"github.com/gitslim/gophermart/internal/rpc"
 	"github.com/gitslim/gophermart/internal/service"
+	"github.com/gitslim/gophermart/internal/service/admin"
 	"github.com/gitslim/gophermart/internal/service/balance"
+	"github.com/gitslim/gophermart/internal/service/merchant"
 	"github.com/gitslim/gophermart/internal/service/order"
+	"github.com/gitslim/gophermart/internal/service/privacy"
+	"github.com/gitslim/gophermart/internal/service/session"
 	"github.com/gitslim/gophermart/internal/service/user"
 	"github.com/gitslim/gophermart/internal/storage"
+	"github.com/gitslim/gophermart/internal/storage/memory"
 	"github.com/gitslim/gophermart/internal/storage/postgres"
 	"github.com/gitslim/gophermart/internal/storage/postgres/migrations"
+	"github.com/gitslim/gophermart/internal/storage/sqlite"
 	"github.com/gitslim/gophermart/internal/web"
 	"github.com/gitslim/gophermart/internal/web/handlers"
 	"github.com/gitslim/gophermart/internal/web/middleware"
+	"github.com/gitslim/gophermart/internal/web/openapi"
 	"github.com/gitslim/gophermart/internal/web/router"
 	"github.com/gitslim/gophermart/internal/workers"
 	"go.uber.org/fx"
 )
 
 func main() {
-	fx.New(CreateApp()).Run()
+	config, err := conf.LoadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	// Подкоманда migrate управляет схемой базы данных без запуска сервера,
+	// поэтому параметры сервера для нее не проверяются
+	if args := flag.Args(); len(args) > 0 {
+		if args[0] != "migrate" {
+			fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s\n", args[0], migrateUsage)
+			os.Exit(2)
+		}
+		if err := runMigrate(config, args[1:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := config.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	fx.New(CreateApp(config)).Run()
 }
 
-func CreateApp() fx.Option {
+func CreateApp(config *conf.Config) fx.Option {
 	return fx.Options(
 		// Конфигурация и логирование
+		fx.Supply(config),
 		fx.Provide(
-			conf.ParseConfig,
 			fx.Annotate(sugared.NewLogger, fx.As(new(logging.Logger))),
 		),
 
-		// Хранилище
+		// Хранилище, выбранное в конфигурации
+		storageOption(config),
+
+		// Хеширование и политика паролей
 		fx.Provide(
-			postgres.NewConnPool,
-			fx.Annotate(postgres.NewPgUserStorage, fx.As(new(storage.UserStorage))),
-			fx.Annotate(postgres.NewPgOrderStorage, fx.As(new(storage.OrderStorage))),
-			fx.Annotate(postgres.NewPgWithdrawalStorage, fx.As(new(storage.WithdrawalStorage))),
+			password.NewHasher,
+			password.NewPolicy,
 		),
 
 		// Клиент системы начислений
 		fx.Provide(accrual.NewClient),
 
+		// Хранилище квот запросов
+		fx.Provide(ratelimit.NewMemoryStore),
+
+		// Рассылка событий пользователям
+		fx.Provide(events.NewBroker),
+
 		// Сервисы
 		fx.Provide(
 			fx.Annotate(user.NewUserService, fx.As(new(service.UserService))),
 			fx.Annotate(order.NewOrderService, fx.As(new(service.OrderService))),
 			fx.Annotate(balance.NewBalanceService, fx.As(new(service.BalanceService))),
+			fx.Annotate(admin.NewAdminService, fx.As(new(service.AdminService))),
+			fx.Annotate(merchant.NewMerchantService, fx.As(new(service.MerchantService))),
+			fx.Annotate(session.NewSessionService, fx.As(new(service.SessionService))),
+			fx.Annotate(privacy.NewPrivacyService, fx.As(new(service.PrivacyService))),
 		),
 
 		// Воркеры
@@ -57,22 +110,118 @@ func CreateApp() fx.Option {
 
 		// Веб-компоненты
 		fx.Provide(
-			middleware.NewGzipMiddleware,
+			openapi.NewSpec,
+			middleware.NewLoggingMiddleware,
+			middleware.NewCompressMiddleware,
+			middleware.NewRateLimitMiddleware,
 			middleware.NewAuthMiddleware,
+			middleware.NewAPIKeyMiddleware,
+			middleware.NewOpenAPIMiddleware,
 			handlers.NewHandler,
+			handlers.NewAdminHandler,
+			handlers.NewMerchantHandler,
+			handlers.NewHealthHandler,
 			router.NewRouter,
 		),
 
-		// Запуск хранилища и миграций
+		// Проверки готовности, проверки хранилища объявлены вместе с ним
+		fx.Provide(
+			fx.Annotate(accrual.NewHealthChecker, fx.ResultTags(`group:"health"`)),
+			fx.Annotate(workers.NewHealthChecker, fx.ResultTags(`group:"health"`)),
+			fx.Annotate(health.NewService, fx.ParamTags(`group:"health"`)),
+		),
+
+		// TLS сертификат, общий для HTTP и gRPC серверов
+		fx.Provide(certs.NewReloader),
+
+		// gRPC API
+		fx.Provide(rpc.NewServer),
+
+		// Запуск воркера обработки заказов. Хуки остановки выполняются в обратном порядке:
+		// воркер дообрабатывает заказы после остановки серверов и до закрытия пула соединений
+		fx.Invoke(workers.RegisterOrderProcessingWorkerHooks),
+
+		// Запуск сервера
+		fx.Invoke(
+			web.RegisterServerHooks,
+			rpc.RegisterServerHooks,
+		),
+
+		// Остановка потоков событий до остановки сервера, хуки остановки выполняются в обратном порядке
+		fx.Invoke(events.RegisterBrokerHooks),
+	)
+}
+
+// storageOption возвращает компоненты хранилища, выбранного в конфигурации
+func storageOption(config *conf.Config) fx.Option {
+	switch config.Storage {
+	case conf.StorageMemory:
+		return memoryStorage()
+	case conf.StorageSQLite:
+		return sqliteStorage()
+	default:
+		return postgresStorage()
+	}
+}
+
+// postgresStorage описывает хранилище PostgreSQL: пул соединений, миграции и проверки готовности
+func postgresStorage() fx.Option {
+	return fx.Options(
+		fx.Provide(
+			postgres.NewConnPool,
+			fx.Annotate(postgres.NewPgUserStorage, fx.As(new(storage.UserStorage))),
+			fx.Annotate(postgres.NewPgOrderStorage, fx.As(new(storage.OrderStorage))),
+			fx.Annotate(postgres.NewPgWithdrawalStorage, fx.As(new(storage.WithdrawalStorage))),
+			fx.Annotate(postgres.NewPgBalanceAdjustmentStorage, fx.As(new(storage.BalanceAdjustmentStorage))),
+			fx.Annotate(postgres.NewPgMerchantStorage, fx.As(new(storage.MerchantStorage))),
+			fx.Annotate(postgres.NewPgSessionStorage, fx.As(new(storage.SessionStorage))),
+		),
+		fx.Provide(
+			fx.Annotate(postgres.NewHealthChecker, fx.ResultTags(`group:"health"`)),
+			fx.Annotate(migrations.NewHealthChecker, fx.ResultTags(`group:"health"`)),
+		),
+
+		// Запуск хранилища и миграций. Хук пула регистрируется первым и останавливается последним
 		fx.Invoke(
 			postgres.RegisterPoolHooks,
 			migrations.RunMigrations,
 		),
+	)
+}
 
-		// Запуск воркера обработки заказов
-		fx.Invoke(workers.RegisterOrderProcessingWorkerHooks),
+// sqliteStorage описывает хранилище SQLite для развертывания на одном сервере
+func sqliteStorage() fx.Option {
+	return fx.Options(
+		fx.Provide(
+			sqlite.NewDB,
+			fx.Annotate(sqlite.NewSQLiteUserStorage, fx.As(new(storage.UserStorage))),
+			fx.Annotate(sqlite.NewSQLiteOrderStorage, fx.As(new(storage.OrderStorage))),
+			fx.Annotate(sqlite.NewSQLiteWithdrawalStorage, fx.As(new(storage.WithdrawalStorage))),
+			fx.Annotate(sqlite.NewSQLiteBalanceAdjustmentStorage, fx.As(new(storage.BalanceAdjustmentStorage))),
+			fx.Annotate(sqlite.NewSQLiteMerchantStorage, fx.As(new(storage.MerchantStorage))),
+			fx.Annotate(sqlite.NewSQLiteSessionStorage, fx.As(new(storage.SessionStorage))),
+		),
+		fx.Provide(
+			fx.Annotate(sqlite.NewHealthChecker, fx.ResultTags(`group:"health"`)),
+		),
 
-		// Запуск сервера
-		fx.Invoke(web.RegisterServerHooks),
+		// Запуск хранилища и миграций. Хук базы регистрируется первым и останавливается последним
+		fx.Invoke(
+			sqlite.RegisterDBHooks,
+			sqlite.RunMigrations,
+		),
+	)
+}
+
+// memoryStorage описывает хранилище в памяти процесса
+func memoryStorage() fx.Option {
+	return fx.Provide(
+		memory.NewDB,
+		fx.Annotate(memory.NewMemUserStorage, fx.As(new(storage.UserStorage))),
+		fx.Annotate(memory.NewMemOrderStorage, fx.As(new(storage.OrderStorage))),
+		fx.Annotate(memory.NewMemWithdrawalStorage, fx.As(new(storage.WithdrawalStorage))),
+		fx.Annotate(memory.NewMemBalanceAdjustmentStorage, fx.As(new(storage.BalanceAdjustmentStorage))),
+		fx.Annotate(memory.NewMemMerchantStorage, fx.As(new(storage.MerchantStorage))),
+		fx.Annotate(memory.NewMemSessionStorage, fx.As(new(storage.SessionStorage))),
 	)
 }
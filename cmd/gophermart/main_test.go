@@ -1,13 +1,49 @@
 package main
 
 import (
+	"io"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/gitslim/gophermart/internal/conf"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/fx"
 )
 
 func TestValidateApp(t *testing.T) {
-	err := fx.ValidateApp(CreateApp())
-	require.NoError(t, err)
+	for _, backend := range []string{conf.StoragePostgres, conf.StorageSQLite, conf.StorageMemory} {
+		t.Run(backend, func(t *testing.T) {
+			err := fx.ValidateApp(CreateApp(&conf.Config{Storage: backend}))
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestMigrateCommand(t *testing.T) {
+	config := &conf.Config{
+		Storage:     conf.StorageSQLite,
+		DatabaseURI: conf.SQLiteScheme + filepath.Join(t.TempDir(), "gophermart.db"),
+	}
+
+	run := func(args ...string) string {
+		var out strings.Builder
+		require.NoError(t, runMigrate(config, args, &out))
+		return out.String()
+	}
+
+	assert.Equal(t, "no migrations applied\n", run("version"))
+	assert.Equal(t, "version 2\n", run("up", "2"))
+	assert.Equal(t, "version 9\n", run("up"))
+	assert.Equal(t, "no change\nversion 9\n", run("up"))
+	assert.Equal(t, "version 8\n", run("down"))
+	assert.Equal(t, "version 3\n", run("goto", "3"))
+	assert.Equal(t, "version 5\n", run("force", "5"))
+	assert.Equal(t, "no migrations applied\n", run("force", "-1"))
+
+	assert.Error(t, runMigrate(config, nil, io.Discard))
+	assert.Error(t, runMigrate(config, []string{"sideways"}, io.Discard))
+	assert.Error(t, runMigrate(config, []string{"down", "0"}, io.Discard))
+	assert.Error(t, runMigrate(config, []string{"goto"}, io.Discard))
 }
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/gitslim/gophermart/internal/conf"
+	"github.com/gitslim/gophermart/internal/storage/postgres/migrations"
+	"github.com/gitslim/gophermart/internal/storage/sqlite"
+	"github.com/golang-migrate/migrate/v4"
+)
+
+// migrateUsage описывает подкоманду migrate
+const migrateUsage = `usage: gophermart [-d database-uri] migrate <command>
+
+commands:
+  up [N]     apply all pending migrations or the next N
+  down [N]   roll back the last N migrations, one by default
+  goto V     migrate up or down to version V
+  version    print the current schema version
+  force V    set version V without running migrations and clear the dirty flag`
+
+// runMigrate выполняет подкоманду migrate для хранилища из конфигурации.
+// Аргументы проверяются до подключения к базе данных
+func runMigrate(config *conf.Config, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	var run func(m *migrate.Migrate) error
+	switch cmd, params := args[0], args[1:]; cmd {
+	case "up":
+		n, err := optionalCount(params)
+		if err != nil {
+			return err
+		}
+		run = func(m *migrate.Migrate) error {
+			if n == 0 {
+				return changeResult(m.Up(), out)
+			}
+			return changeResult(m.Steps(n), out)
+		}
+	case "down":
+		n, err := optionalCount(params)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			n = 1
+		}
+		run = func(m *migrate.Migrate) error {
+			return changeResult(m.Steps(-n), out)
+		}
+	case "goto":
+		v, err := versionParam(params)
+		if err != nil {
+			return err
+		}
+		if v < 0 {
+			return errors.New("version must not be negative")
+		}
+		run = func(m *migrate.Migrate) error {
+			return changeResult(m.Migrate(uint(v)), out)
+		}
+	case "force":
+		// Версия -1 означает, что миграции не применялись
+		v, err := versionParam(params)
+		if err != nil {
+			return err
+		}
+		run = func(m *migrate.Migrate) error {
+			if err := m.Force(v); err != nil {
+				return fmt.Errorf("failed to force version: %w", err)
+			}
+			return nil
+		}
+	case "version":
+		if len(params) != 0 {
+			return errors.New(migrateUsage)
+		}
+		run = func(*migrate.Migrate) error { return nil }
+	default:
+		return fmt.Errorf("unknown migrate command %q\n\n%s", cmd, migrateUsage)
+	}
+
+	m, err := newMigrate(config)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := run(m); err != nil {
+		return err
+	}
+
+	return printVersion(m, out)
+}
+
+// newMigrate создает экземпляр migrate со встроенными миграциями выбранного хранилища
+func newMigrate(config *conf.Config) (*migrate.Migrate, error) {
+	switch config.Storage {
+	case conf.StorageMemory:
+		return nil, errors.New("in-memory storage has no schema to migrate")
+	case conf.StorageSQLite:
+		return sqlite.NewMigrate(config)
+	default:
+		return migrations.New(config)
+	}
+}
+
+// changeResult сообщает, что схема уже в нужном состоянии, вместо ошибки ErrNoChange
+func changeResult(err error, out io.Writer) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		fmt.Fprintln(out, "no change")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to migrate: %w", err)
+	}
+	return nil
+}
+
+// printVersion выводит текущую версию схемы
+func printVersion(m *migrate.Migrate, out io.Writer) error {
+	version, dirty, err := m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		fmt.Fprintln(out, "no migrations applied")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get schema version: %w", err)
+	}
+
+	if dirty {
+		fmt.Fprintf(out, "version %d (dirty)\n", version)
+	} else {
+		fmt.Fprintf(out, "version %d\n", version)
+	}
+	return nil
+}
+
+// optionalCount разбирает необязательное число миграций, 0 означает, что число не указано
+func optionalCount(params []string) (int, error) {
+	switch len(params) {
+	case 0:
+		return 0, nil
+	case 1:
+		n, err := strconv.Atoi(params[0])
+		if err != nil || n < 1 {
+			return 0, fmt.Errorf("invalid number of migrations %q", params[0])
+		}
+		return n, nil
+	default:
+		return 0, errors.New(migrateUsage)
+	}
+}
+
+// versionParam разбирает обязательный номер версии
+func versionParam(params []string) (int, error) {
+	if len(params) != 1 {
+		return 0, errors.New(migrateUsage)
+	}
+
+	v, err := strconv.Atoi(params[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid version %q", params[0])
+	}
+	return v, nil
+}
@@ -60,3 +60,19 @@ func (c *Client) GetOrderAccrual(ctx context.Context, orderNumber string) (respo
 		return nil, resp.StatusCode, nil
 	}
 }
+
+// Ping проверяет, что система начислений принимает соединения, любой HTTP ответ считается успешным
+func (c *Client) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("accrual system is unreachable: %w", err)
+	}
+	resp.Body.Close()
+
+	return nil
+}
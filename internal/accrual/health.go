@@ -0,0 +1,30 @@
+package accrual
+
+import (
+	"context"
+
+	"github.com/gitslim/gophermart/internal/health"
+)
+
+// healthChecker проверяет доступность системы начислений. Без нее заказы не обрабатываются,
+// но остальное API продолжает работать
+type healthChecker struct {
+	client *Client
+}
+
+// NewHealthChecker создает проверку доступности системы начислений
+func NewHealthChecker(client *Client) health.Checker {
+	return &healthChecker{client: client}
+}
+
+func (c *healthChecker) Name() string {
+	return "accrual"
+}
+
+func (c *healthChecker) Critical() bool {
+	return false
+}
+
+func (c *healthChecker) Check(ctx context.Context) (map[string]interface{}, error) {
+	return map[string]interface{}{"address": c.client.baseURL}, c.client.Ping(ctx)
+}
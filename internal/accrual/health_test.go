@@ -0,0 +1,30 @@
+package accrual
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gitslim/gophermart/internal/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthChecker(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
+	checker := NewHealthChecker(NewClient(&conf.Config{AccrualSystemAddress: srv.URL}))
+	assert.False(t, checker.Critical())
+
+	// Любой ответ означает, что система начислений доступна
+	details, err := checker.Check(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, srv.URL, details["address"])
+
+	srv.Close()
+	_, err = checker.Check(context.Background())
+	assert.Error(t, err)
+}
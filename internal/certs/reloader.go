@@ -0,0 +1,104 @@
+// Package certs отдает TLS сертификат сервера и перечитывает его после обновления файлов
+package certs
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/conf"
+	"github.com/gitslim/gophermart/internal/logging"
+)
+
+// certCheckInterval - как часто проверяется изменение файлов сертификата
+const certCheckInterval = 10 * time.Second
+
+// Reloader отдает TLS сертификат и перечитывает его после изменения файлов.
+// Один экземпляр используется HTTP и gRPC серверами
+type Reloader struct {
+	certFile string
+	keyFile  string
+	log      logging.Logger
+
+	mu        sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	lastCheck time.Time
+}
+
+// NewReloader загружает сертификат и ключ из конфигурации, возвращает nil, если TLS не настроен
+func NewReloader(cfg *conf.Config, log logging.Logger) (*Reloader, error) {
+	if !cfg.TLSEnabled() {
+		return nil, nil
+	}
+
+	r := &Reloader{certFile: cfg.TLSCertFile, keyFile: cfg.TLSKeyFile, log: log}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// TLSConfig возвращает серверную конфигурацию TLS с перечитываемым сертификатом
+func (r *Reloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: r.GetCertificate,
+	}
+}
+
+// GetCertificate возвращает текущий сертификат, используется в tls.Config
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.lastCheck) >= certCheckInterval {
+		r.lastCheck = time.Now()
+		if modTime, err := r.latestModTime(); err == nil && modTime.After(r.modTime) {
+			// При ошибке продолжаем отдавать прежний сертификат, например пока файлы записаны не полностью
+			if err := r.load(); err != nil {
+				r.log.Errorf("Failed to reload TLS certificate: %v", err)
+			} else {
+				r.log.Infof("TLS certificate reloaded from %s", r.certFile)
+			}
+		}
+	}
+
+	return r.cert, nil
+}
+
+// load читает сертификат и ключ с диска
+func (r *Reloader) load() error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	r.cert = &cert
+	r.modTime = modTime
+	r.lastCheck = time.Now()
+
+	return nil
+}
+
+// latestModTime возвращает время последнего изменения файлов сертификата и ключа
+func (r *Reloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, name := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(name)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to stat %s: %w", name, err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
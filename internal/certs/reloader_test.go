@@ -0,0 +1,74 @@
+package certs
+
+import (
+	"crypto/x509"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/certs/certstest"
+	"github.com/gitslim/gophermart/internal/conf"
+	"github.com/gitslim/gophermart/internal/logging/sugared"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serialOf возвращает серийный номер текущего сертификата
+func serialOf(t *testing.T, r *Reloader) int64 {
+	t.Helper()
+
+	cert, err := r.GetCertificate(nil)
+	require.NoError(t, err)
+	parsed, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+
+	return parsed.SerialNumber.Int64()
+}
+
+// touch сдвигает время изменения файлов вперед и снимает ограничение на частоту проверок
+func touch(t *testing.T, r *Reloader, files ...string) {
+	t.Helper()
+
+	modTime := r.modTime.Add(time.Minute)
+	for _, name := range files {
+		require.NoError(t, os.Chtimes(name, modTime, modTime))
+	}
+	r.lastCheck = time.Time{}
+}
+
+func TestNewReloaderDisabled(t *testing.T) {
+	r, err := NewReloader(&conf.Config{}, nil)
+	require.NoError(t, err)
+	assert.Nil(t, r)
+}
+
+func TestReloaderReload(t *testing.T) {
+	log, err := sugared.NewLogger()
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile, keyFile := certstest.WriteSelfSigned(t, dir, 1)
+
+	r, err := NewReloader(&conf.Config{TLSCertFile: certFile, TLSKeyFile: keyFile}, log)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), serialOf(t, r))
+
+	// Новые файлы не читаются до истечения интервала проверки
+	certstest.WriteSelfSigned(t, dir, 2)
+	modTime := r.modTime.Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, modTime, modTime))
+	assert.Equal(t, int64(1), serialOf(t, r))
+
+	touch(t, r, certFile, keyFile)
+	assert.Equal(t, int64(2), serialOf(t, r))
+
+	// Поврежденный файл не заменяет действующий сертификат
+	require.NoError(t, os.WriteFile(certFile, []byte("partial"), 0o600))
+	touch(t, r, certFile)
+	assert.Equal(t, int64(2), serialOf(t, r))
+
+	// После исправления файла сертификат снова перечитывается
+	certstest.WriteSelfSigned(t, dir, 3)
+	touch(t, r, certFile, keyFile)
+	assert.Equal(t, int64(3), serialOf(t, r))
+}
@@ -4,8 +4,12 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/caarlos0/env/v6"
+	"github.com/gitslim/gophermart/internal/ratelimit"
 )
 
 type Config struct {
@@ -13,8 +17,99 @@ type Config struct {
 	DatabaseURI          string `env:"DATABASE_URI"`
 	AccrualSystemAddress string `env:"ACCRUAL_SYSTEM_ADDRESS"`
 	SecretKey            string `env:"SECRET_KEY"`
+
+	// Хранилище данных: postgres, sqlite или memory. По умолчанию выбирается по схеме DATABASE_URI:
+	// адрес вида sqlite://путь/к/файлу.db выбирает SQLite для развертывания на одном сервере, остальные - PostgreSQL.
+	// Хранилище в памяти не требует базы данных и теряет данные при остановке, оно предназначено для разработки и тестов
+	Storage string `env:"STORAGE"`
+
+	// Применять миграции при старте сервера. При отключении схема обновляется
+	// командой gophermart migrate up, например отдельным шагом развертывания
+	MigrateOnStart bool `env:"MIGRATE_ON_START" envDefault:"true"`
+
+	// Пул подключений к базе данных: ограничения числа подключений, время их жизни
+	// и период фоновой проверки простаивающих подключений PostgreSQL
+	DatabaseMaxConns          int32         `env:"DATABASE_MAX_CONNS" envDefault:"10"`
+	DatabaseMinConns          int32         `env:"DATABASE_MIN_CONNS" envDefault:"2"`
+	DatabaseMaxConnLifetime   time.Duration `env:"DATABASE_MAX_CONN_LIFETIME" envDefault:"1h"`
+	DatabaseMaxConnIdleTime   time.Duration `env:"DATABASE_MAX_CONN_IDLE_TIME" envDefault:"5m"`
+	DatabaseHealthCheckPeriod time.Duration `env:"DATABASE_HEALTH_CHECK_PERIOD" envDefault:"1m"`
+
+	// Хеширование и политика паролей
+	PasswordHasher         string `env:"PASSWORD_HASHER" envDefault:"argon2id"`
+	PasswordMinLength      int    `env:"PASSWORD_MIN_LENGTH" envDefault:"8"`
+	PasswordMaxLength      int    `env:"PASSWORD_MAX_LENGTH" envDefault:"128"`
+	PasswordRequireUpper   bool   `env:"PASSWORD_REQUIRE_UPPER"`
+	PasswordRequireLower   bool   `env:"PASSWORD_REQUIRE_LOWER"`
+	PasswordRequireDigit   bool   `env:"PASSWORD_REQUIRE_DIGIT"`
+	PasswordRequireSpecial bool   `env:"PASSWORD_REQUIRE_SPECIAL"`
+
+	// Время, в течение которого старый ключ магазина действует после ротации
+	APIKeyRotationGrace time.Duration `env:"API_KEY_ROTATION_GRACE" envDefault:"24h"`
+
+	// Издатель, отображаемый в приложении-аутентификаторе
+	TOTPIssuer string `env:"TOTP_ISSUER" envDefault:"Gophermart"`
+
+	// Сертификат и ключ TLS, при их указании HTTP сервер принимает только HTTPS и поддерживает HTTP/2,
+	// а gRPC сервер принимает только TLS соединения.
+	// Файлы перечитываются при изменении без перезапуска
+	TLSCertFile string `env:"TLS_CERT_FILE"`
+	TLSKeyFile  string `env:"TLS_KEY_FILE"`
+
+	// Адрес (в формате host:port), на котором HTTP запросы перенаправляются на HTTPS, пустой адрес отключает перенаправление
+	TLSRedirectAddress string `env:"TLS_REDIRECT_ADDRESS"`
+
+	// Атрибуты куки аутентификации. При включенном TLS куки всегда передается с атрибутом Secure
+	CookieName     string `env:"COOKIE_NAME" envDefault:"auth_token"`
+	CookieDomain   string `env:"COOKIE_DOMAIN"`
+	CookiePath     string `env:"COOKIE_PATH" envDefault:"/"`
+	CookieSecure   bool   `env:"COOKIE_SECURE"`
+	CookieSameSite string `env:"COOKIE_SAME_SITE" envDefault:"lax"`
+
+	// Время жизни сессии и токена аутентификации
+	SessionTTL time.Duration `env:"SESSION_TTL" envDefault:"24h"`
+
+	// Интервал отправки пустых сообщений в поток событий, чтобы прокси не закрывали соединение
+	EventsHeartbeatInterval time.Duration `env:"EVENTS_HEARTBEAT_INTERVAL" envDefault:"15s"`
+
+	// Время, в течение которого воркер при остановке дообрабатывает начатые заказы
+	WorkerShutdownTimeout time.Duration `env:"WORKER_SHUTDOWN_TIMEOUT" envDefault:"10s"`
+
+	// Минимальный размер ответа в байтах, начиная с которого он сжимается
+	CompressionMinSize int `env:"COMPRESSION_MIN_SIZE" envDefault:"1024"`
+
+	// Максимальный размер тела запроса в байтах для операций, у которых в спецификации не задан x-max-body-size
+	MaxBodySize int64 `env:"MAX_BODY_SIZE" envDefault:"65536"`
+
+	// Квоты запросов в формате requests/period, off отключает ограничение.
+	// Публичные маршруты ограничиваются по IP клиента, защищенные - по пользователю,
+	// маршруты магазинов - по ключу доступа
+	RateLimitPublic   ratelimit.Limit `env:"RATE_LIMIT_PUBLIC" envDefault:"20/1m"`
+	RateLimitAPI      ratelimit.Limit `env:"RATE_LIMIT_API" envDefault:"300/1m"`
+	RateLimitOrders   ratelimit.Limit `env:"RATE_LIMIT_ORDERS" envDefault:"30/1m"`
+	RateLimitMerchant ratelimit.Limit `env:"RATE_LIMIT_MERCHANT" envDefault:"120/1m"`
+
+	// Квота попыток ввода кода второго фактора на пользователя, ограничивает перебор кодов
+	// независимо от IP клиента
+	RateLimitTOTP ratelimit.Limit `env:"RATE_LIMIT_TOTP" envDefault:"5/5m"`
+
+	// Подсети прокси, которым доверяется X-Forwarded-For при определении IP клиента
+	TrustedProxies []string `env:"TRUSTED_PROXIES" envSeparator:","`
+
+	// Адрес gRPC сервера (в формате host:port), пустой адрес отключает сервер
+	GRPCAddress string `env:"GRPC_ADDRESS"`
 }
 
+// Поддерживаемые хранилища данных
+const (
+	StoragePostgres = "postgres"
+	StorageSQLite   = "sqlite"
+	StorageMemory   = "memory"
+)
+
+// SQLiteScheme - схема адреса базы данных SQLite
+const SQLiteScheme = "sqlite://"
+
 const (
 	DefaultRunAddress           = ":8080"
 	DefaultDatabaseURI          = ""
@@ -22,7 +117,9 @@ const (
 	DefaultSecretKey            = "secret"
 )
 
-func ParseConfig() (*Config, error) {
+// LoadConfig читает конфигурацию из флагов и переменных окружения и проверяет параметры хранилища.
+// Этого достаточно подкоманде migrate, перед запуском сервера конфигурация проверяется целиком методом Validate
+func LoadConfig() (*Config, error) {
 	runAddress := flag.String("a", DefaultRunAddress, "Адрес сервера (в формате host:port)")
 	databaseURI := flag.String("d", DefaultDatabaseURI, "Адрес подключения к базе данных (URI)")
 	accrualSystemAddress := flag.String("r", DefaultAccrualSystemAddress, "Адрес системы расчета начислений (в формате host:port)")
@@ -42,14 +139,131 @@ func ParseConfig() (*Config, error) {
 		return nil, fmt.Errorf("ошибка парсинга конфигурации: %w", err)
 	}
 
-	// проверка конфига
-	if cfg.RunAddress == "" {
-		return nil, errors.New("адрес сервера не может быть пустым")
+	if err := cfg.ValidateStorage(); err != nil {
+		return nil, err
 	}
 
-	if cfg.AccrualSystemAddress == "" {
-		return nil, errors.New("адрес системы расчета начислений не может быть пустым")
+	return cfg, nil
+}
+
+// ValidateStorage проверяет выбор хранилища и адрес базы данных. Если хранилище не задано,
+// оно определяется по схеме адреса
+func (c *Config) ValidateStorage() error {
+	if c.Storage == "" {
+		c.Storage = StoragePostgres
+		if strings.HasPrefix(c.DatabaseURI, SQLiteScheme) {
+			c.Storage = StorageSQLite
+		}
 	}
 
-	return cfg, nil
+	switch c.Storage {
+	case StoragePostgres, StorageMemory:
+	case StorageSQLite:
+		if !strings.HasPrefix(c.DatabaseURI, SQLiteScheme) {
+			return fmt.Errorf("хранилище SQLite требует адреса базы данных вида %sпуть/к/файлу.db", SQLiteScheme)
+		}
+	default:
+		return fmt.Errorf("неизвестное хранилище данных %q", c.Storage)
+	}
+
+	return nil
+}
+
+// Validate проверяет параметры сервера. При включенном TLS куки аутентификации получает атрибут Secure
+func (c *Config) Validate() error {
+	if c.RunAddress == "" {
+		return errors.New("адрес сервера не может быть пустым")
+	}
+
+	if c.AccrualSystemAddress == "" {
+		return errors.New("адрес системы расчета начислений не может быть пустым")
+	}
+
+	if c.DatabaseMaxConns < 1 {
+		return errors.New("максимальное число подключений к базе данных должно быть положительным")
+	}
+
+	if c.DatabaseMinConns < 0 || c.DatabaseMinConns > c.DatabaseMaxConns {
+		return errors.New("минимальное число подключений к базе данных должно быть от 0 до максимального")
+	}
+
+	if c.DatabaseMaxConnLifetime <= 0 || c.DatabaseMaxConnIdleTime <= 0 || c.DatabaseHealthCheckPeriod <= 0 {
+		return errors.New("время жизни подключений и период их проверки должны быть положительными")
+	}
+
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return errors.New("сертификат и ключ TLS должны быть указаны вместе")
+	}
+
+	if c.TLSRedirectAddress != "" && !c.TLSEnabled() {
+		return errors.New("перенаправление на HTTPS требует сертификата и ключа TLS")
+	}
+
+	if c.TLSEnabled() {
+		c.CookieSecure = true
+	}
+
+	if c.CookieName == "" {
+		return errors.New("имя куки аутентификации не может быть пустым")
+	}
+
+	sameSite, err := c.CookieSameSiteMode()
+	if err != nil {
+		return err
+	}
+
+	if sameSite == http.SameSiteNoneMode && !c.CookieSecure {
+		return errors.New("куки с SameSite=None требует атрибута Secure")
+	}
+
+	if c.SessionTTL <= 0 {
+		return errors.New("время жизни сессии должно быть положительным")
+	}
+
+	if c.EventsHeartbeatInterval <= 0 {
+		return errors.New("интервал отправки пустых сообщений в поток событий должен быть положительным")
+	}
+
+	if c.WorkerShutdownTimeout <= 0 {
+		return errors.New("время остановки воркера должно быть положительным")
+	}
+
+	if c.CompressionMinSize < 0 {
+		return errors.New("минимальный размер сжимаемого ответа не может быть отрицательным")
+	}
+
+	if c.MaxBodySize <= 0 {
+		return errors.New("максимальный размер тела запроса должен быть положительным")
+	}
+
+	if c.PasswordMinLength < 1 {
+		return errors.New("минимальная длина пароля должна быть положительной")
+	}
+
+	if c.PasswordMaxLength != 0 && c.PasswordMaxLength < c.PasswordMinLength {
+		return errors.New("максимальная длина пароля не может быть меньше минимальной")
+	}
+
+	return nil
+}
+
+// TLSEnabled сообщает, настроен ли TLS
+func (c *Config) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
+// CookieSameSiteMode возвращает значение атрибута SameSite куки аутентификации
+func (c *Config) CookieSameSiteMode() (http.SameSite, error) {
+	switch strings.ToLower(c.CookieSameSite) {
+	case "", "default":
+		return http.SameSiteDefaultMode, nil
+	case "lax":
+		return http.SameSiteLaxMode, nil
+	case "strict":
+		return http.SameSiteStrictMode, nil
+	case "none":
+		return http.SameSiteNoneMode, nil
+	default:
+		return 0, fmt.Errorf("неизвестное значение SameSite куки: %s", c.CookieSameSite)
+	}
 }
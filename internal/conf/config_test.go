@@ -0,0 +1,37 @@
+package conf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateStorage(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		want    string
+		wantErr bool
+	}{
+		{name: "postgres by default", config: Config{DatabaseURI: "postgres://localhost/gophermart"}, want: StoragePostgres},
+		{name: "sqlite by scheme", config: Config{DatabaseURI: SQLiteScheme + "gophermart.db"}, want: StorageSQLite},
+		{name: "explicit memory", config: Config{Storage: StorageMemory}, want: StorageMemory},
+		{name: "sqlite without file", config: Config{Storage: StorageSQLite, DatabaseURI: "postgres://localhost/gophermart"}, wantErr: true},
+		{name: "unknown storage", config: Config{Storage: "mysql"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Параметры сервера не заданы и для подкоманды migrate не проверяются
+			err := tt.config.ValidateStorage()
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, tt.config.Storage)
+			assert.Error(t, tt.config.Validate())
+		})
+	}
+}
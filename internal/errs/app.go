@@ -2,35 +2,57 @@ package errs
 
 import "net/http"
 
+// Общие типы ошибок, код соответствует HTTP статусу
 var (
-	ErrOk                   = NewErrorType(http.StatusOK)
-	ErrInternal             = NewErrorType(http.StatusInternalServerError)
-	ErrNotFound             = NewErrorType(http.StatusNotFound)
-	ErrBadRequest           = NewErrorType(http.StatusBadRequest)
-	ErrUnauthorized         = NewErrorType(http.StatusUnauthorized)
-	ErrForbidden            = NewErrorType(http.StatusForbidden)
-	ErrMethodNotAllowed     = NewErrorType(http.StatusMethodNotAllowed)
-	ErrConflict             = NewErrorType(http.StatusConflict)
-	ErrNocontent            = NewErrorType(http.StatusNoContent)
-	ErrUnsupportedMediaType = NewErrorType(http.StatusUnsupportedMediaType)
-	ErrTooManyRequests      = NewErrorType(http.StatusTooManyRequests)
-	ErrTimeout              = NewErrorType(http.StatusRequestTimeout)
-	ErrPaymentRequired      = NewErrorType(http.StatusPaymentRequired)
-	ErrUnprocessableEntity  = NewErrorType(http.StatusUnprocessableEntity)
-	ErrNotImplemented       = NewErrorType(http.StatusNotImplemented)
+	ErrOk                   = NewErrorType(http.StatusOK, "ok")
+	ErrInternal             = NewErrorType(http.StatusInternalServerError, "internal_error")
+	ErrNotFound             = NewErrorType(http.StatusNotFound, "not_found")
+	ErrBadRequest           = NewErrorType(http.StatusBadRequest, "bad_request")
+	ErrUnauthorized         = NewErrorType(http.StatusUnauthorized, "unauthorized")
+	ErrForbidden            = NewErrorType(http.StatusForbidden, "forbidden")
+	ErrMethodNotAllowed     = NewErrorType(http.StatusMethodNotAllowed, "method_not_allowed")
+	ErrConflict             = NewErrorType(http.StatusConflict, "conflict")
+	ErrNocontent            = NewErrorType(http.StatusNoContent, "no_content")
+	ErrUnsupportedMediaType = NewErrorType(http.StatusUnsupportedMediaType, "unsupported_media_type")
+	ErrTooManyRequests      = NewErrorType(http.StatusTooManyRequests, "too_many_requests")
+	ErrPayloadTooLarge      = NewErrorType(http.StatusRequestEntityTooLarge, "payload_too_large")
+	ErrTimeout              = NewErrorType(http.StatusRequestTimeout, "timeout")
+	ErrPaymentRequired      = NewErrorType(http.StatusPaymentRequired, "payment_required")
+	ErrUnprocessableEntity  = NewErrorType(http.StatusUnprocessableEntity, "unprocessable_entity")
+	ErrNotImplemented       = NewErrorType(http.StatusNotImplemented, "not_implemented")
 )
 
+// Типы ошибок предметной области, на код которых могут опираться клиенты
+var (
+	ErrValidation           = NewErrorType(http.StatusBadRequest, "validation_failed")
+	ErrInvalidCredentials   = NewErrorType(http.StatusUnauthorized, "invalid_credentials")
+	ErrLoginTaken           = NewErrorType(http.StatusConflict, "login_taken")
+	ErrInsufficientFunds    = NewErrorType(http.StatusPaymentRequired, "insufficient_funds")
+	ErrOrderAlreadyUploaded = NewErrorType(http.StatusOK, "order_already_uploaded")
+	ErrOrderConflict        = NewErrorType(http.StatusConflict, "order_conflict")
+	ErrInvalidOrderNumber   = NewErrorType(http.StatusUnprocessableEntity, "invalid_order_number")
+)
+
+// ErrorType описывает HTTP статус и машиночитаемый код ошибки
 type ErrorType struct {
 	HTTPStatus int
+	Code       string
 }
 
-func NewErrorType(httpStatus int) *ErrorType {
-	return &ErrorType{HTTPStatus: httpStatus}
+func NewErrorType(httpStatus int, code string) *ErrorType {
+	return &ErrorType{HTTPStatus: httpStatus, Code: code}
+}
+
+// FieldError описывает ошибку в отдельном поле запроса
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
 
 type AppError struct {
 	Type    *ErrorType
 	Message string
+	Fields  []FieldError
 }
 
 func (e *AppError) Error() string {
@@ -43,3 +65,9 @@ func NewAppError(errorType *ErrorType, message string) *AppError {
 		Message: message,
 	}
 }
+
+// WithFields добавляет к ошибке ошибки отдельных полей
+func (e *AppError) WithFields(fields ...FieldError) *AppError {
+	e.Fields = append(e.Fields, fields...)
+	return e
+}
@@ -0,0 +1,162 @@
+package events
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/fx"
+)
+
+// Типы событий
+const (
+	TypeOrderStatus = "order_status"
+	TypeBalance     = "balance"
+)
+
+const (
+	// historySize - количество последних событий, доступных для возобновления потока
+	historySize = 1024
+	// subscriberBuffer - размер буфера подписчика, переполнение отключает подписчика
+	subscriberBuffer = 64
+)
+
+// Event представляет событие пользователя
+type Event struct {
+	ID     uint64
+	UserID int64
+	Type   string
+	Data   any
+}
+
+// OrderStatusData описывает смену статуса заказа
+type OrderStatusData struct {
+	Number  string  `json:"number"`
+	Status  string  `json:"status"`
+	Accrual float64 `json:"accrual,omitempty"`
+}
+
+// BalanceData описывает изменение баланса
+type BalanceData struct {
+	Type   string  `json:"type"`
+	Amount float64 `json:"amount"`
+	Order  string  `json:"order,omitempty"`
+}
+
+type subscriber struct {
+	userID int64
+	ch     chan Event
+}
+
+// Broker рассылает события пользователей подписчикам внутри процесса
+type Broker struct {
+	mu          sync.Mutex
+	lastID      uint64
+	history     []Event
+	subscribers map[*subscriber]struct{}
+	closed      bool
+}
+
+// NewBroker создает новый экземпляр Broker
+func NewBroker() *Broker {
+	return &Broker{
+		history:     make([]Event, 0, historySize),
+		subscribers: make(map[*subscriber]struct{}),
+	}
+}
+
+// Publish публикует событие пользователя, медленные подписчики отключаются
+func (b *Broker) Publish(userID int64, eventType string, data any) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	b.lastID++
+	event := Event{
+		ID:     b.lastID,
+		UserID: userID,
+		Type:   eventType,
+		Data:   data,
+	}
+
+	if len(b.history) == historySize {
+		copy(b.history, b.history[1:])
+		b.history = b.history[:historySize-1]
+	}
+	b.history = append(b.history, event)
+
+	for sub := range b.subscribers {
+		if sub.userID != userID {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			// Клиент переподключится и дочитает пропущенное по Last-Event-ID
+			b.remove(sub)
+		}
+	}
+}
+
+// Subscribe подписывается на события пользователя и возвращает события после lastEventID из истории.
+// Канал закрывается при отписке, переполнении буфера или остановке брокера.
+func (b *Broker) Subscribe(userID int64, lastEventID uint64) ([]Event, <-chan Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var backlog []Event
+	if lastEventID > 0 {
+		for _, event := range b.history {
+			if event.UserID == userID && event.ID > lastEventID {
+				backlog = append(backlog, event)
+			}
+		}
+	}
+
+	sub := &subscriber{userID: userID, ch: make(chan Event, subscriberBuffer)}
+	if b.closed {
+		close(sub.ch)
+		return backlog, sub.ch, func() {}
+	}
+	b.subscribers[sub] = struct{}{}
+
+	cancel := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.remove(sub)
+	}
+
+	return backlog, sub.ch, cancel
+}
+
+// Close отключает всех подписчиков
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subscribers {
+		b.remove(sub)
+	}
+}
+
+// remove удаляет подписчика и закрывает его канал, вызывается под блокировкой
+func (b *Broker) remove(sub *subscriber) {
+	if _, ok := b.subscribers[sub]; !ok {
+		return
+	}
+	delete(b.subscribers, sub)
+	close(sub.ch)
+}
+
+// RegisterBrokerHooks отключает подписчиков до остановки HTTP сервера, чтобы открытые потоки не задерживали ее
+func RegisterBrokerHooks(lc fx.Lifecycle, broker *Broker) {
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			broker.Close()
+			return nil
+		},
+	})
+}
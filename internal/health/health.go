@@ -0,0 +1,124 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Статусы компонентов и сервиса в целом
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusDegraded = "degraded"
+)
+
+// checkTimeout ограничивает время одной проверки, чтобы зависшая зависимость не задерживала пробу
+const checkTimeout = 2 * time.Second
+
+// Checker проверяет состояние одной зависимости сервиса
+type Checker interface {
+	// Name возвращает имя компонента в отчете
+	Name() string
+	// Critical сообщает, делает ли отказ компонента сервис неготовым к приему запросов
+	Critical() bool
+	// Check проверяет компонент и возвращает дополнительные сведения о нем
+	Check(ctx context.Context) (map[string]interface{}, error)
+}
+
+// Component описывает состояние одного компонента
+type Component struct {
+	Status   string                 `json:"status"`
+	Critical bool                   `json:"critical"`
+	Error    string                 `json:"error,omitempty"`
+	Details  map[string]interface{} `json:"details,omitempty"`
+	Duration string                 `json:"duration,omitempty"`
+}
+
+// Report описывает состояние сервиса и его компонентов
+type Report struct {
+	Status     string               `json:"status"`
+	Components map[string]Component `json:"components,omitempty"`
+}
+
+// Summary возвращает отчет только со статусами компонентов. Ошибки и подробности
+// (адреса зависимостей, состояние пула соединений) не раскрываются публично
+func (r Report) Summary() Report {
+	summary := Report{Status: r.Status}
+	if r.Components != nil {
+		summary.Components = make(map[string]Component, len(r.Components))
+		for name, component := range r.Components {
+			summary.Components[name] = Component{Status: component.Status, Critical: component.Critical}
+		}
+	}
+	return summary
+}
+
+// Service выполняет проверки готовности сервиса
+type Service struct {
+	checkers []Checker
+}
+
+// NewService создает сервис проверок с указанными компонентами
+func NewService(checkers []Checker) *Service {
+	return &Service{checkers: checkers}
+}
+
+// Ready параллельно проверяет все компоненты. Сервис не готов при отказе критичного компонента
+// и работает с ограничениями при отказе некритичного
+func (s *Service) Ready(ctx context.Context) Report {
+	report := Report{
+		Status:     StatusUp,
+		Components: make(map[string]Component, len(s.checkers)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, checker := range s.checkers {
+		wg.Add(1)
+		go func(checker Checker) {
+			defer wg.Done()
+
+			component := check(ctx, checker)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			report.Components[checker.Name()] = component
+			if component.Status == StatusDown {
+				if checker.Critical() {
+					report.Status = StatusDown
+				} else if report.Status == StatusUp {
+					report.Status = StatusDegraded
+				}
+			}
+		}(checker)
+	}
+	wg.Wait()
+
+	return report
+}
+
+// check выполняет одну проверку с ограничением по времени
+func check(ctx context.Context, checker Checker) Component {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	details, err := checker.Check(ctx)
+
+	component := Component{
+		Status:   StatusUp,
+		Critical: checker.Critical(),
+		Details:  details,
+		Duration: time.Since(start).String(),
+	}
+	if err != nil {
+		component.Status = StatusDown
+		component.Error = err.Error()
+	}
+
+	return component
+}
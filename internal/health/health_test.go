@@ -0,0 +1,96 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeChecker возвращает заданные сведения и ошибку, при block ждет истечения времени проверки
+type fakeChecker struct {
+	name     string
+	critical bool
+	details  map[string]interface{}
+	err      error
+	block    bool
+}
+
+func (c *fakeChecker) Name() string {
+	return c.name
+}
+
+func (c *fakeChecker) Critical() bool {
+	return c.critical
+}
+
+func (c *fakeChecker) Check(ctx context.Context) (map[string]interface{}, error) {
+	if c.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return c.details, c.err
+}
+
+func TestServiceReady(t *testing.T) {
+	failed := errors.New("unreachable")
+
+	tests := []struct {
+		name     string
+		checkers []Checker
+		want     string
+	}{
+		{
+			name:     "all up",
+			checkers: []Checker{&fakeChecker{name: "database", critical: true}, &fakeChecker{name: "accrual"}},
+			want:     StatusUp,
+		},
+		{
+			name:     "non-critical down",
+			checkers: []Checker{&fakeChecker{name: "database", critical: true}, &fakeChecker{name: "accrual", err: failed}},
+			want:     StatusDegraded,
+		},
+		{
+			name:     "critical down",
+			checkers: []Checker{&fakeChecker{name: "database", critical: true, err: failed}, &fakeChecker{name: "accrual", err: failed}},
+			want:     StatusDown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := NewService(tt.checkers).Ready(context.Background())
+			assert.Equal(t, tt.want, report.Status)
+			assert.Len(t, report.Components, len(tt.checkers))
+		})
+	}
+}
+
+func TestServiceReadyTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Проверка прерывается по контексту и считается отказом
+	report := NewService([]Checker{&fakeChecker{name: "database", critical: true, block: true}}).Ready(ctx)
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, context.Canceled.Error(), report.Components["database"].Error)
+}
+
+func TestReportSummary(t *testing.T) {
+	report := NewService([]Checker{
+		&fakeChecker{name: "database", critical: true, details: map[string]interface{}{"open_connections": 4}},
+		&fakeChecker{name: "accrual", details: map[string]interface{}{"address": "http://accrual.internal:8080"}, err: errors.New("dial tcp 10.0.0.5:8080: connection refused")},
+	}).Ready(context.Background())
+
+	summary := report.Summary()
+	assert.Equal(t, StatusDegraded, summary.Status)
+	assert.Equal(t, map[string]Component{
+		"database": {Status: StatusUp, Critical: true},
+		"accrual":  {Status: StatusDown},
+	}, summary.Components)
+
+	// Исходный отчет сохраняет подробности
+	assert.NotEmpty(t, report.Components["accrual"].Error)
+	assert.NotEmpty(t, report.Components["database"].Details)
+}
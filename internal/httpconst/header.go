@@ -2,12 +2,28 @@ package httpconst
 
 // HTTP header keys
 const (
-	HeaderContentType     = "Content-Type"
-	HeaderContentEncoding = "Content-Encoding"
-	HeaderAcceptEncoding  = "Accept-Encoding"
-	HeaderAuthorization   = "Authorization"
-	HeaderUserAgent       = "User-Agent"
-	HeaderHashSHA256      = "HashSHA256"
+	HeaderContentType        = "Content-Type"
+	HeaderContentEncoding    = "Content-Encoding"
+	HeaderContentLength      = "Content-Length"
+	HeaderAcceptEncoding     = "Accept-Encoding"
+	HeaderAccept             = "Accept"
+	HeaderCacheControl       = "Cache-Control"
+	HeaderLastEventID        = "Last-Event-ID"
+	HeaderAuthorization      = "Authorization"
+	HeaderUserAgent          = "User-Agent"
+	HeaderHashSHA256         = "HashSHA256"
+	HeaderAPIKey             = "X-API-Key"
+	HeaderContentDisposition = "Content-Disposition"
+	HeaderLink               = "Link"
+	HeaderRequestID          = "X-Request-ID"
+	HeaderVary               = "Vary"
+	HeaderRetryAfter         = "Retry-After"
+
+	// Заголовки квот: https://datatracker.ietf.org/doc/draft-ietf-httpapi-ratelimit-headers/
+	HeaderRateLimitPolicy    = "RateLimit-Policy"
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
 )
 
 // HTTP header values
@@ -17,6 +33,14 @@ const (
 	ContentTypeHTML  = "text/html"
 	ContentTypeXML   = "application/xml"
 	ContentTypeJSON  = "application/json"
+	ContentTypeCSV   = "text/csv"
+	ContentTypeJS    = "application/javascript"
+
+	// Content-Type потока событий: https://html.spec.whatwg.org/multipage/server-sent-events.html
+	ContentTypeEventStream = "text/event-stream"
+
+	// Content-Type ответов с ошибками: https://www.rfc-editor.org/rfc/rfc7807
+	ContentTypeProblemJSON = "application/problem+json"
 
 	// Content-Encoding: https://developer.mozilla.org/en-US/docs/Web/HTTP/Headers/Content-Encoding
 	ContentEncodingGzip     = "gzip"
@@ -24,6 +48,7 @@ const (
 	ContentEncodingDeflate  = "deflate"
 	ContentEncodingBr       = "br"
 	ContentEncodingZstd     = "zstd"
+	ContentEncodingIdentity = "identity"
 
 	// Accept-Encoding: https://developer.mozilla.org/en-US/docs/Web/HTTP/Headers/Accept-Encoding
 	AcceptEncodingGzip     = "gzip"
@@ -0,0 +1,18 @@
+package logging
+
+import "context"
+
+type contextKey struct{}
+
+// WithContext возвращает контекст с логгером, привязанным к запросу или задаче
+func WithContext(ctx context.Context, log Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, log)
+}
+
+// FromContext возвращает логгер из контекста, при его отсутствии - fallback
+func FromContext(ctx context.Context, fallback Logger) Logger {
+	if log, ok := ctx.Value(contextKey{}).(Logger); ok {
+		return log
+	}
+	return fallback
+}
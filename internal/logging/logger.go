@@ -12,4 +12,7 @@ type Logger interface {
 	Warnf(msg string, args ...interface{})
 	Errorf(msg string, args ...interface{})
 	Fatalf(msg string, args ...interface{})
+
+	// With возвращает логгер, добавляющий к записям указанные пары ключ-значение
+	With(args ...interface{}) Logger
 }
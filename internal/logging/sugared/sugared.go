@@ -1,6 +1,9 @@
 package sugared
 
-import "go.uber.org/zap"
+import (
+	"github.com/gitslim/gophermart/internal/logging"
+	"go.uber.org/zap"
+)
 
 type Logger struct {
 	sugar *zap.SugaredLogger
@@ -61,3 +64,7 @@ func (l *Logger) Errorf(msg string, args ...interface{}) {
 func (l *Logger) Fatalf(msg string, args ...interface{}) {
 	l.sugar.Fatalf(msg, args...)
 }
+
+func (l *Logger) With(args ...interface{}) logging.Logger {
+	return &Logger{sugar: l.sugar.With(args...)}
+}
@@ -0,0 +1,29 @@
+package luhn
+
+// Valid проверяет, что строка состоит из цифр и проходит проверку по алгоритму Луна
+func Valid(number string) bool {
+	if number == "" {
+		return false
+	}
+
+	checksum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		checksum += d
+		double = !double
+	}
+
+	return checksum%10 == 0
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Cursor указывает позицию последней записи страницы при постраничной выборке
+type Cursor struct {
+	At time.Time `json:"t"`
+	ID int64     `json:"id"`
+}
+
+// Encode кодирует курсор в непрозрачную строку для клиента
+func (c *Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor декодирует курсор, полученный от клиента
+func DecodeCursor(v string) (*Cursor, error) {
+	invalid := errors.New("invalid cursor")
+
+	data, err := base64.RawURLEncoding.DecodeString(v)
+	if err != nil {
+		return nil, invalid
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID <= 0 || cursor.At.IsZero() {
+		return nil, invalid
+	}
+
+	return &cursor, nil
+}
+
+// OrderFilter описывает фильтры и позицию страницы для списка заказов
+type OrderFilter struct {
+	Statuses []string
+	From     *time.Time // включительно
+	To       *time.Time // не включительно
+	After    *Cursor
+	Limit    int
+}
+
+// WithdrawalFilter описывает фильтры и позицию страницы для списка списаний
+type WithdrawalFilter struct {
+	From  *time.Time // включительно
+	To    *time.Time // не включительно
+	After *Cursor
+	Limit int
+}
@@ -1,16 +1,23 @@
 package models
 
 import (
+	"strings"
 	"time"
 )
 
 // User представляет пользователя системы
 type User struct {
-	ID           int64     `json:"-" db:"id"`
-	Login        string    `json:"login" db:"login"`
-	PasswordHash string    `json:"-" db:"password_hash"`
-	Balance      float64   `json:"balance" db:"balance"`
-	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	ID           int64      `json:"-" db:"id"`
+	Login        string     `json:"login" db:"login"`
+	PasswordHash string     `json:"-" db:"password_hash"`
+	Balance      float64    `json:"balance" db:"balance"`
+	Role         string     `json:"role" db:"role"`
+	TokenVersion int64      `json:"-" db:"token_version"`
+	TOTPSecret   string     `json:"-" db:"totp_secret"`
+	TOTPEnabled  bool       `json:"-" db:"totp_enabled"`
+	TOTPLastStep int64      `json:"-" db:"totp_last_step"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	DeletedAt    *time.Time `json:"-" db:"deleted_at"`
 }
 
 // Order представляет заказ в системе
@@ -24,6 +31,29 @@ type Order struct {
 	ProcessedAt time.Time `json:"processed_at,omitempty" db:"processed_at"`
 }
 
+// OrderStatusChange представляет проверку заказа в системе начислений, сменившую его статус или баланс пользователя
+type OrderStatusChange struct {
+	ID             int64     `json:"-" db:"id"`
+	OrderID        int64     `json:"-" db:"order_id"`
+	PreviousStatus string    `json:"previous_status" db:"previous_status"`
+	Status         string    `json:"status" db:"status"`
+	Accrual        float64   `json:"accrual,omitempty" db:"accrual"`
+	Credited       float64   `json:"credited,omitempty" db:"credited"` // сумма, зачисленная на баланс по итогам проверки
+	CheckedAt      time.Time `json:"checked_at" db:"checked_at"`
+}
+
+// HasEffect сообщает, меняет ли проверка статус заказа или баланс пользователя.
+// В историю попадают только такие проверки
+func (c *OrderStatusChange) HasEffect() bool {
+	return c.PreviousStatus != c.Status || c.Credited > 0
+}
+
+// OrderDetail представляет заказ с историей его обработки
+type OrderDetail struct {
+	*Order
+	History []*OrderStatusChange `json:"history"`
+}
+
 // Withdrawal представляет операцию списания баллов
 type Withdrawal struct {
 	ID          int64     `json:"-" db:"id"`
@@ -33,6 +63,105 @@ type Withdrawal struct {
 	ProcessedAt time.Time `json:"processed_at" db:"processed_at"`
 }
 
+// BalanceAdjustment представляет ручную корректировку баланса администратором
+type BalanceAdjustment struct {
+	ID        int64     `json:"id" db:"id"`
+	UserID    int64     `json:"user_id" db:"user_id"`
+	AdminID   int64     `json:"admin_id" db:"admin_id"`
+	Amount    float64   `json:"amount" db:"amount"`
+	Reason    string    `json:"reason" db:"reason"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// Merchant представляет магазин-партнера, загружающего заказы через API
+type Merchant struct {
+	ID        int64     `json:"id" db:"id"`
+	Name      string    `json:"name" db:"name"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// APIKey представляет ключ доступа магазина к API, сам ключ хранится только в виде хеша
+type APIKey struct {
+	ID         int64      `json:"id" db:"id"`
+	MerchantID int64      `json:"merchant_id" db:"merchant_id"`
+	Prefix     string     `json:"prefix" db:"prefix"`
+	KeyHash    string     `json:"-" db:"key_hash"`
+	Scopes     string     `json:"scopes" db:"scopes"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+}
+
+// HasScope проверяет, выдано ли ключу указанное право
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range strings.Fields(k.Scopes) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IsActive проверяет, что ключ не отозван и не истек на указанный момент
+func (k *APIKey) IsActive(now time.Time) bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || now.Before(*k.ExpiresAt)
+}
+
+// Session представляет сессию пользователя на устройстве
+type Session struct {
+	ID         int64      `json:"id" db:"id"`
+	UserID     int64      `json:"-" db:"user_id"`
+	UserAgent  string     `json:"user_agent" db:"user_agent"`
+	IP         string     `json:"ip" db:"ip"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at" db:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt  *time.Time `json:"-" db:"revoked_at"`
+}
+
+// IsActive проверяет, что сессия не отозвана и не истекла на указанный момент
+func (s *Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// BalanceHistoryEntry представляет одно изменение баланса пользователя
+type BalanceHistoryEntry struct {
+	Type      string    `json:"type"`
+	Amount    float64   `json:"amount"`
+	Reference string    `json:"reference,omitempty"`
+	Reason    string    `json:"reason,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+// UserDataExport представляет архив персональных данных пользователя
+type UserDataExport struct {
+	Profile        UserProfile           `json:"profile"`
+	Balance        float64               `json:"balance"`
+	Orders         []*Order              `json:"orders"`
+	Withdrawals    []*Withdrawal         `json:"withdrawals"`
+	BalanceHistory []BalanceHistoryEntry `json:"balance_history"`
+	Sessions       []*Session            `json:"sessions"`
+	ExportedAt     time.Time             `json:"exported_at"`
+}
+
+// UserProfile представляет профиль пользователя в архиве персональных данных
+type UserProfile struct {
+	Login       string    `json:"login"`
+	Role        string    `json:"role"`
+	TOTPEnabled bool      `json:"two_factor_enabled"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// OrderUploadResult представляет результат загрузки одного номера из пакета
+type OrderUploadResult struct {
+	Number string `json:"number" db:"number"`
+	Result string `json:"result" db:"result"`
+}
+
 // OrderStatus определяет возможные статусы заказа
 const (
 	OrderStatusNew        = "NEW"
@@ -40,3 +169,30 @@ const (
 	OrderStatusInvalid    = "INVALID"
 	OrderStatusProcessed  = "PROCESSED"
 )
+
+// OrderUpload определяет возможные результаты загрузки номера заказа в пакете
+const (
+	OrderUploadAccepted        = "accepted"
+	OrderUploadAlreadyUploaded = "already_uploaded"
+	OrderUploadConflict        = "conflict"
+	OrderUploadInvalid         = "invalid"
+)
+
+// Role определяет возможные роли пользователя
+const (
+	RoleUser    = "user"
+	RoleSupport = "support"
+	RoleAdmin   = "admin"
+)
+
+// Scope определяет права ключей доступа магазинов
+const (
+	ScopeOrdersWrite = "orders:write"
+)
+
+// BalanceHistoryType определяет виды изменений баланса
+const (
+	BalanceHistoryAccrual    = "accrual"
+	BalanceHistoryWithdrawal = "withdrawal"
+	BalanceHistoryAdjustment = "adjustment"
+)
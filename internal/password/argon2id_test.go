@@ -0,0 +1,110 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testArgon2idParams - облегченные параметры, чтобы тесты не тратили память и время
+var testArgon2idParams = Argon2idParams{
+	Memory:      64,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func TestArgon2idHasher(t *testing.T) {
+	h := NewArgon2idHasher(testArgon2idParams)
+
+	hash, err := h.Hash("secret")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$"), hash)
+	assert.True(t, h.Supports(hash))
+	assert.False(t, h.NeedsRehash(hash))
+
+	// Соль случайная, поэтому одинаковые пароли дают разные хеши
+	other, err := h.Hash("secret")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other)
+
+	params, salt, key, err := decodeArgon2id(hash)
+	require.NoError(t, err)
+	assert.Equal(t, testArgon2idParams, params)
+	assert.Len(t, salt, 16)
+	assert.Len(t, key, 32)
+
+	parts := strings.Split(hash, "$")
+
+	tests := []struct {
+		name     string
+		hash     string
+		password string
+		want     bool
+		wantErr  bool
+	}{
+		{name: "round trip", hash: hash, password: "secret", want: true},
+		{name: "wrong password", hash: hash, password: "Secret"},
+		{name: "tampered key", hash: strings.Join(append(parts[:5:5], flipFirst(parts[5])), "$"), password: "secret"},
+		{name: "tampered salt", hash: strings.Join(append(parts[:4:4], flipFirst(parts[4]), parts[5]), "$"), password: "secret"},
+		{name: "wrong parameters", hash: strings.Replace(hash, "t=1", "t=2", 1), password: "secret"},
+		{name: "unsupported version", hash: strings.Replace(hash, "v=19", "v=16", 1), password: "secret", wantErr: true},
+		{name: "malformed parameters", hash: strings.Replace(hash, "m=64", "m=x", 1), password: "secret", wantErr: true},
+		{name: "malformed salt", hash: strings.Join(append(parts[:4:4], "!", parts[5]), "$"), password: "secret", wantErr: true},
+		{name: "missing key", hash: strings.Join(parts[:5], "$"), password: "secret", wantErr: true},
+		{name: "bcrypt hash", hash: "$2a$04$abcdefghijklmnopqrstuuMgwMoBvM5P7w1CeBFOIqlpr3nPTj4Q2", password: "secret", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := h.Verify(tt.hash, tt.password)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, ok)
+		})
+	}
+}
+
+func TestArgon2idNeedsRehash(t *testing.T) {
+	hash, err := NewArgon2idHasher(testArgon2idParams).Hash("secret")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		modify func(p *Argon2idParams)
+		want   bool
+	}{
+		{name: "same parameters", modify: func(*Argon2idParams) {}},
+		{name: "memory", modify: func(p *Argon2idParams) { p.Memory = 128 }, want: true},
+		{name: "iterations", modify: func(p *Argon2idParams) { p.Iterations = 2 }, want: true},
+		{name: "parallelism", modify: func(p *Argon2idParams) { p.Parallelism = 2 }, want: true},
+		{name: "salt length", modify: func(p *Argon2idParams) { p.SaltLength = 32 }, want: true},
+		{name: "key length", modify: func(p *Argon2idParams) { p.KeyLength = 64 }, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := testArgon2idParams
+			tt.modify(&params)
+			assert.Equal(t, tt.want, NewArgon2idHasher(params).NeedsRehash(hash))
+		})
+	}
+
+	t.Run("malformed hash", func(t *testing.T) {
+		assert.True(t, NewArgon2idHasher(testArgon2idParams).NeedsRehash("$argon2id$broken"))
+	})
+}
+
+// flipFirst заменяет первый символ base64-строки, сохраняя ее корректность
+func flipFirst(s string) string {
+	if s[0] == 'A' {
+		return "B" + s[1:]
+	}
+	return "A" + s[1:]
+}
@@ -0,0 +1,95 @@
+package password
+
+import (
+	"fmt"
+
+	"github.com/gitslim/gophermart/internal/conf"
+)
+
+// Поддерживаемые алгоритмы хеширования паролей
+const (
+	AlgorithmArgon2id = "argon2id"
+	AlgorithmBcrypt   = "bcrypt"
+)
+
+// Hasher определяет интерфейс для хеширования и проверки паролей
+type Hasher interface {
+	// Hash возвращает хеш пароля в самоописываемом формате
+	Hash(password string) (string, error)
+	// Verify проверяет соответствие пароля хешу
+	Verify(hash, password string) (bool, error)
+	// Supports сообщает, создан ли хеш этим алгоритмом
+	Supports(hash string) bool
+	// NeedsRehash сообщает, что хеш следует пересчитать с текущими параметрами
+	NeedsRehash(hash string) bool
+}
+
+// ChainHasher хеширует пароли основным алгоритмом и проверяет хеши,
+// созданные как основным, так и устаревшими алгоритмами
+type ChainHasher struct {
+	primary Hasher
+	legacy  []Hasher
+}
+
+// NewChainHasher создает новый экземпляр ChainHasher
+func NewChainHasher(primary Hasher, legacy ...Hasher) *ChainHasher {
+	return &ChainHasher{
+		primary: primary,
+		legacy:  legacy,
+	}
+}
+
+// NewHasher создает хешер паролей в соответствии с конфигурацией
+func NewHasher(config *conf.Config) (Hasher, error) {
+	argon := NewArgon2idHasher(DefaultArgon2idParams)
+	bcrypt := NewBcryptHasher(0)
+
+	switch config.PasswordHasher {
+	case AlgorithmArgon2id:
+		return NewChainHasher(argon, bcrypt), nil
+	case AlgorithmBcrypt:
+		return NewChainHasher(bcrypt, argon), nil
+	default:
+		return nil, fmt.Errorf("unsupported password hasher: %s", config.PasswordHasher)
+	}
+}
+
+// Hash хеширует пароль основным алгоритмом
+func (h *ChainHasher) Hash(password string) (string, error) {
+	return h.primary.Hash(password)
+}
+
+// Verify проверяет пароль алгоритмом, которым был создан хеш
+func (h *ChainHasher) Verify(hash, password string) (bool, error) {
+	hasher := h.find(hash)
+	if hasher == nil {
+		return false, fmt.Errorf("unknown password hash format")
+	}
+	return hasher.Verify(hash, password)
+}
+
+// Supports сообщает, поддерживается ли формат хеша хотя бы одним алгоритмом
+func (h *ChainHasher) Supports(hash string) bool {
+	return h.find(hash) != nil
+}
+
+// NeedsRehash сообщает, что хеш создан не основным алгоритмом или с устаревшими параметрами
+func (h *ChainHasher) NeedsRehash(hash string) bool {
+	if !h.primary.Supports(hash) {
+		return true
+	}
+	return h.primary.NeedsRehash(hash)
+}
+
+// find возвращает алгоритм, которым был создан хеш
+func (h *ChainHasher) find(hash string) Hasher {
+	if h.primary.Supports(hash) {
+		return h.primary
+	}
+	for _, hasher := range h.legacy {
+		if hasher.Supports(hash) {
+			return hasher
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gitslim/gophermart/internal/conf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainHasherUpgrade(t *testing.T) {
+	bcrypt := NewBcryptHasher(4)
+	argon := NewArgon2idHasher(testArgon2idParams)
+	chain := NewChainHasher(argon, bcrypt)
+
+	legacy, err := bcrypt.Hash("secret")
+	require.NoError(t, err)
+
+	// Устаревший хеш проверяется, но требует пересчета основным алгоритмом
+	assert.True(t, chain.Supports(legacy))
+	ok, err := chain.Verify(legacy, "secret")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = chain.Verify(legacy, "wrong")
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.True(t, chain.NeedsRehash(legacy))
+
+	upgraded, err := chain.Hash("secret")
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(upgraded, argon2idPrefix), upgraded)
+	assert.False(t, chain.NeedsRehash(upgraded))
+	ok, err = chain.Verify(upgraded, "secret")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// Хеш основного алгоритма с устаревшими параметрами тоже пересчитывается
+	weaker := testArgon2idParams
+	weaker.Iterations = 2
+	stale, err := NewArgon2idHasher(weaker).Hash("secret")
+	require.NoError(t, err)
+	assert.True(t, chain.NeedsRehash(stale))
+
+	// Неизвестный формат не проверяется
+	assert.False(t, chain.Supports("plain"))
+	_, err = chain.Verify("plain", "plain")
+	require.Error(t, err)
+}
+
+func TestNewHasher(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		prefix    string
+		wantErr   bool
+	}{
+		{algorithm: AlgorithmArgon2id, prefix: argon2idPrefix},
+		{algorithm: AlgorithmBcrypt, prefix: "$2a$"},
+		{algorithm: "md5", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			h, err := NewHasher(&conf.Config{PasswordHasher: tt.algorithm})
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			hash, err := h.Hash("secret")
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(hash, tt.prefix), hash)
+			assert.False(t, h.NeedsRehash(hash))
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package password
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gitslim/gophermart/internal/conf"
+	"github.com/gitslim/gophermart/internal/errs"
+)
+
+// Policy описывает требования к паролю
+type Policy struct {
+	MinLength      int
+	MaxLength      int
+	RequireUpper   bool
+	RequireLower   bool
+	RequireDigit   bool
+	RequireSpecial bool
+}
+
+// NewPolicy создает политику паролей в соответствии с конфигурацией
+func NewPolicy(config *conf.Config) *Policy {
+	return &Policy{
+		MinLength:      config.PasswordMinLength,
+		MaxLength:      config.PasswordMaxLength,
+		RequireUpper:   config.PasswordRequireUpper,
+		RequireLower:   config.PasswordRequireLower,
+		RequireDigit:   config.PasswordRequireDigit,
+		RequireSpecial: config.PasswordRequireSpecial,
+	}
+}
+
+// Validate проверяет пароль на соответствие политике
+func (p *Policy) Validate(password string) error {
+	var violations []string
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		violations = append(violations, fmt.Sprintf("at least %d characters", p.MinLength))
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violations = append(violations, fmt.Sprintf("at most %d characters", p.MaxLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSpecial bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSpecial = true
+		}
+	}
+
+	if p.RequireUpper && !hasUpper {
+		violations = append(violations, "an uppercase letter")
+	}
+	if p.RequireLower && !hasLower {
+		violations = append(violations, "a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		violations = append(violations, "a digit")
+	}
+	if p.RequireSpecial && !hasSpecial {
+		violations = append(violations, "a special character")
+	}
+
+	if len(violations) > 0 {
+		return errs.NewAppError(errs.ErrValidation, "password must contain "+strings.Join(violations, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package password
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/gitslim/gophermart/internal/errs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPolicyValidate(t *testing.T) {
+	strict := &Policy{
+		MinLength:      8,
+		MaxLength:      16,
+		RequireUpper:   true,
+		RequireLower:   true,
+		RequireDigit:   true,
+		RequireSpecial: true,
+	}
+
+	tests := []struct {
+		name     string
+		policy   *Policy
+		password string
+		wantErr  string
+	}{
+		{name: "valid", policy: strict, password: "Passw0rd!"},
+		{name: "too short", policy: strict, password: "Pa0!", wantErr: "at least 8 characters"},
+		{name: "too long", policy: strict, password: "Passw0rd!" + strings.Repeat("x", 8), wantErr: "at most 16 characters"},
+		{name: "length counted in runes", policy: &Policy{MinLength: 6, MaxLength: 6}, password: "пароль"},
+		{name: "no upper", policy: strict, password: "passw0rd!", wantErr: "an uppercase letter"},
+		{name: "no lower", policy: strict, password: "PASSW0RD!", wantErr: "a lowercase letter"},
+		{name: "no digit", policy: strict, password: "Password!", wantErr: "a digit"},
+		{name: "no special", policy: strict, password: "Passw0rdd", wantErr: "a special character"},
+		{name: "symbol counts as special", policy: strict, password: "Passw0rd+"},
+		{name: "all violations", policy: strict, password: "", wantErr: "at least 8 characters, an uppercase letter, a lowercase letter, a digit, a special character"},
+		{name: "classes not required", policy: &Policy{MinLength: 1}, password: "x"},
+		{name: "no max length", policy: &Policy{MinLength: 1}, password: strings.Repeat("x", 1024)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate(tt.password)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			var appErr *errs.AppError
+			require.True(t, errors.As(err, &appErr), "unexpected error %v", err)
+			assert.Equal(t, errs.ErrValidation, appErr.Type)
+			assert.Contains(t, appErr.Message, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStoreRefill(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	s := NewMemoryStore().(*MemoryStore)
+	s.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	limit := Limit{Requests: 2, Period: 10 * time.Second}
+
+	for i := 0; i < 2; i++ {
+		result, err := s.Take(ctx, "user:1", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+	}
+
+	// Квота исчерпана: токен восстанавливается за Period/Requests
+	result, err := s.Take(ctx, "user:1", limit)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Zero(t, result.Remaining)
+	assert.Equal(t, 5*time.Second, result.RetryAfter)
+	assert.Equal(t, 10*time.Second, result.Reset)
+
+	// Другие ключи расходуют свою квоту
+	result, err = s.Take(ctx, "user:2", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	now = now.Add(5 * time.Second)
+	result, err = s.Take(ctx, "user:1", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Zero(t, result.Remaining)
+
+	// Пополнение не превышает размер квоты
+	now = now.Add(time.Hour)
+	result, err = s.Take(ctx, "user:1", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, result.Remaining)
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	s := NewMemoryStore().(*MemoryStore)
+	s.now = func() time.Time { return now }
+	s.lastSweep = now
+
+	_, err := s.Take(context.Background(), "user:1", Limit{Requests: 1, Period: time.Second})
+	require.NoError(t, err)
+	require.Len(t, s.buckets, 1)
+
+	// Восстановившиеся корзины удаляются при очередном обращении
+	now = now.Add(sweepInterval)
+	_, err = s.Take(context.Background(), "user:2", Limit{Requests: 1, Period: time.Second})
+	require.NoError(t, err)
+	assert.Len(t, s.buckets, 1)
+	assert.Contains(t, s.buckets, "user:2")
+}
@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Классы ограничений, у каждого из которых своя квота. HTTP и gRPC API расходуют
+// квоту одного класса по одному ключу, поэтому смена протокола не увеличивает лимит
+const (
+	ClassPublic   = "public"
+	ClassAPI      = "api"
+	ClassOrders   = "orders"
+	ClassMerchant = "merchant"
+	ClassTOTP     = "totp"
+)
+
+// Key возвращает ключ корзины для клиента в классе ограничений
+func Key(class, client string) string {
+	return class + ":" + client
+}
+
+// Limit описывает квоту: не более Requests запросов за Period.
+// Нулевая квота отключает ограничение
+type Limit struct {
+	Requests int
+	Period   time.Duration
+}
+
+// Enabled сообщает, задано ли ограничение
+func (l Limit) Enabled() bool {
+	return l.Requests > 0 && l.Period > 0
+}
+
+// String возвращает квоту в формате requests/period
+func (l Limit) String() string {
+	if !l.Enabled() {
+		return "off"
+	}
+	return fmt.Sprintf("%d/%s", l.Requests, l.Period)
+}
+
+// UnmarshalText разбирает квоту в формате requests/period, например 60/1m, или off
+func (l *Limit) UnmarshalText(text []byte) error {
+	v := strings.TrimSpace(string(text))
+	if v == "" || v == "off" {
+		*l = Limit{}
+		return nil
+	}
+
+	requests, period, ok := strings.Cut(v, "/")
+	if !ok {
+		return fmt.Errorf("invalid rate limit %q, expected requests/period", v)
+	}
+
+	n, err := strconv.Atoi(requests)
+	if err != nil || n < 0 {
+		return fmt.Errorf("invalid rate limit requests %q", requests)
+	}
+
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return fmt.Errorf("invalid rate limit period %q", period)
+	}
+
+	*l = Limit{Requests: n, Period: d}
+	return nil
+}
+
+// Result описывает решение по запросу и состояние квоты после него
+type Result struct {
+	Allowed bool
+	// Remaining - количество запросов, доступных прямо сейчас
+	Remaining int
+	// Reset - время до полного восстановления квоты
+	Reset time.Duration
+	// RetryAfter - время до появления следующего доступного запроса, если запрос отклонен
+	RetryAfter time.Duration
+}
+
+// Store хранит состояние квот, реализации могут быть локальными или разделяемыми между экземплярами сервиса
+type Store interface {
+	// Take расходует один запрос из квоты ключа
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
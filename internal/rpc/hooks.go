@@ -0,0 +1,82 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/gitslim/gophermart/internal/certs"
+	"github.com/gitslim/gophermart/internal/conf"
+	"github.com/gitslim/gophermart/internal/logging"
+	"github.com/gitslim/gophermart/internal/rpc/pb"
+	"go.uber.org/fx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// newGRPCServer создает gRPC сервер с цепочкой перехватчиков и регистрирует в нем API.
+// Перехват паники идет первым, чтобы охватить остальные перехватчики
+func newGRPCServer(server *Server, opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts, grpc.ChainUnaryInterceptor(
+		server.recoveryInterceptor,
+		server.errorInterceptor,
+		server.authInterceptor,
+		server.rateLimitInterceptor,
+	))
+
+	srv := grpc.NewServer(opts...)
+	pb.RegisterGophermartServer(srv, server)
+
+	return srv
+}
+
+// serverOptions возвращает параметры gRPC сервера. При настроенном TLS сервер использует
+// тот же перечитываемый сертификат, что и HTTP сервер
+func serverOptions(reloader *certs.Reloader) []grpc.ServerOption {
+	if reloader == nil {
+		return nil
+	}
+	return []grpc.ServerOption{grpc.Creds(credentials.NewTLS(reloader.TLSConfig()))}
+}
+
+// RegisterServerHooks регистрирует хуки для запуска и остановки gRPC сервера, если задан его адрес
+func RegisterServerHooks(lc fx.Lifecycle, cfg *conf.Config, log logging.Logger, server *Server, reloader *certs.Reloader) {
+	if cfg.GRPCAddress == "" {
+		return
+	}
+
+	srv := newGRPCServer(server, serverOptions(reloader)...)
+
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			lis, err := net.Listen("tcp", cfg.GRPCAddress)
+			if err != nil {
+				return fmt.Errorf("failed to listen on %s: %w", cfg.GRPCAddress, err)
+			}
+
+			go func() {
+				log.Infof("Starting gRPC server on %v", cfg.GRPCAddress)
+				if err := srv.Serve(lis); err != nil {
+					log.Fatalf("gRPC server failed: %s", err)
+				}
+			}()
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			log.Info("Stopping gRPC server")
+
+			stopped := make(chan struct{})
+			go func() {
+				srv.GracefulStop()
+				close(stopped)
+			}()
+
+			select {
+			case <-stopped:
+			case <-ctx.Done():
+				srv.Stop()
+			}
+			return nil
+		},
+	})
+}
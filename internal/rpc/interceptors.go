@@ -0,0 +1,178 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"github.com/gitslim/gophermart/internal/errs"
+	"github.com/gitslim/gophermart/internal/logging"
+	"github.com/gitslim/gophermart/internal/ratelimit"
+	"github.com/gitslim/gophermart/internal/rpc/pb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// errorDomain указывается в деталях ошибок gRPC API
+const errorDomain = "gophermart"
+
+// publicMethods перечисляет методы, доступные без аутентификации
+var publicMethods = map[string]bool{
+	pb.Gophermart_Register_FullMethodName:          true,
+	pb.Gophermart_Login_FullMethodName:             true,
+	pb.Gophermart_LoginSecondFactor_FullMethodName: true,
+}
+
+type userIDKey struct{}
+
+// userIDFromContext возвращает ID пользователя, установленный authInterceptor
+func userIDFromContext(ctx context.Context) int64 {
+	userID, _ := ctx.Value(userIDKey{}).(int64)
+	return userID
+}
+
+// recoveryInterceptor перехватывает панику обработчика, чтобы она не остановила процесс, и отвечает codes.Internal
+func (s *Server) recoveryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logging.FromContext(ctx, s.log).Errorf("gRPC method %s panicked: %v\n%s", info.FullMethod, r, debug.Stack())
+			err = status.Error(codes.Internal, "internal server error")
+		}
+	}()
+
+	return handler(ctx, req)
+}
+
+// authInterceptor проверяет токен сессии из метаданных authorization: Bearer <token>
+func (s *Server) authInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if publicMethods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, errs.NewAppError(errs.ErrUnauthorized, "unauthorized")
+	}
+
+	token, ok := strings.CutPrefix(values[0], "Bearer ")
+	if !ok || token == "" {
+		return nil, errs.NewAppError(errs.ErrUnauthorized, "unauthorized")
+	}
+
+	user, _, err := s.auth.Authenticate(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(context.WithValue(ctx, userIDKey{}, user.ID), req)
+}
+
+// rateLimitInterceptor ограничивает частоту вызовов квотами HTTP API: публичные методы по IP клиента,
+// остальные по ID пользователя, загрузку заказов дополнительно квотой заказов. Используется после authInterceptor
+func (s *Server) rateLimitInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if publicMethods[info.FullMethod] {
+		_, ip := clientInfo(ctx)
+		if err := s.takeRateLimit(ctx, ratelimit.ClassPublic, s.publicLimit, ip); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+
+	userID := strconv.FormatInt(userIDFromContext(ctx), 10)
+	if err := s.takeRateLimit(ctx, ratelimit.ClassAPI, s.apiLimit, userID); err != nil {
+		return nil, err
+	}
+	if info.FullMethod == pb.Gophermart_UploadOrder_FullMethodName {
+		if err := s.takeRateLimit(ctx, ratelimit.ClassOrders, s.ordersLimit, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	return handler(ctx, req)
+}
+
+// takeRateLimit расходует вызов из квоты класса, при превышении передает клиенту retry-after в метаданных ответа
+func (s *Server) takeRateLimit(ctx context.Context, class string, limit ratelimit.Limit, key string) error {
+	if !limit.Enabled() {
+		return nil
+	}
+
+	result, err := s.rateLimits.Take(ctx, ratelimit.Key(class, key), limit)
+	if err != nil {
+		// Недоступность хранилища квот не должна останавливать сервис
+		logging.FromContext(ctx, s.log).Errorf("Failed to check rate limit %s for %s: %v", class, key, err)
+		return nil
+	}
+	if result.Allowed {
+		return nil
+	}
+
+	retryAfter := max(int(math.Ceil(result.RetryAfter.Seconds())), 1)
+	_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(retryAfter)))
+
+	return errs.NewAppError(errs.ErrTooManyRequests, "rate limit exceeded")
+}
+
+// errorInterceptor преобразует ошибки приложения в статусы gRPC
+func (s *Server) errorInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	resp, err := handler(ctx, req)
+	if err != nil {
+		return nil, s.toStatus(info.FullMethod, err)
+	}
+	return resp, nil
+}
+
+// toStatus преобразует ошибку в статус gRPC, внутренние ошибки логируются и не раскрываются клиенту
+func (s *Server) toStatus(method string, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	var e *errs.AppError
+	if !errors.As(err, &e) || e.Type.HTTPStatus >= http.StatusInternalServerError && e.Type != errs.ErrNotImplemented {
+		s.log.Errorf("gRPC method %s failed: %v", method, err)
+		return status.Error(codes.Internal, "internal server error")
+	}
+
+	st := status.New(grpcCode(e.Type.HTTPStatus), e.Message)
+	info := &errdetails.ErrorInfo{Reason: e.Type.Code, Domain: errorDomain}
+	if detailed, err := st.WithDetails(info); err == nil {
+		st = detailed
+	}
+
+	return st.Err()
+}
+
+// grpcCode сопоставляет HTTP статус ошибки коду gRPC
+func grpcCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusUnsupportedMediaType:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusPaymentRequired:
+		return codes.FailedPrecondition
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.AlreadyExists
+	case http.StatusRequestTimeout:
+		return codes.DeadlineExceeded
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	default:
+		return codes.Unknown
+	}
+}
@@ -0,0 +1,66 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/errs"
+	"github.com/gitslim/gophermart/internal/logging/sugared"
+	"github.com/gitslim/gophermart/internal/ratelimit"
+	"github.com/gitslim/gophermart/internal/rpc/pb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+)
+
+func TestRateLimitInterceptor(t *testing.T) {
+	log, err := sugared.NewLogger()
+	require.NoError(t, err)
+
+	s := &Server{
+		log:         log,
+		rateLimits:  ratelimit.NewMemoryStore(),
+		publicLimit: ratelimit.Limit{Requests: 1, Period: time.Minute},
+		apiLimit:    ratelimit.Limit{Requests: 3, Period: time.Minute},
+		ordersLimit: ratelimit.Limit{Requests: 1, Period: time.Minute},
+	}
+
+	handler := func(context.Context, interface{}) (interface{}, error) {
+		return "ok", nil
+	}
+	call := func(ctx context.Context, method string) error {
+		_, err := s.rateLimitInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		return err
+	}
+	tooManyRequests := func(t *testing.T, err error) {
+		t.Helper()
+		var e *errs.AppError
+		require.True(t, errors.As(err, &e), "unexpected error %v", err)
+		assert.Equal(t, errs.ErrTooManyRequests, e.Type)
+	}
+
+	t.Run("public methods by client IP", func(t *testing.T) {
+		client := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 5000}})
+		other := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 5000}})
+
+		require.NoError(t, call(client, pb.Gophermart_Login_FullMethodName))
+		// Методы входа расходуют общую квоту, второй фактор не дает обойти ее
+		tooManyRequests(t, call(client, pb.Gophermart_LoginSecondFactor_FullMethodName))
+		require.NoError(t, call(other, pb.Gophermart_Login_FullMethodName))
+	})
+
+	t.Run("order upload by user", func(t *testing.T) {
+		user := context.WithValue(context.Background(), userIDKey{}, int64(1))
+
+		require.NoError(t, call(user, pb.Gophermart_UploadOrder_FullMethodName))
+		tooManyRequests(t, call(user, pb.Gophermart_UploadOrder_FullMethodName))
+
+		// Остальные методы ограничены только общей квотой пользователя
+		require.NoError(t, call(user, pb.Gophermart_ListOrders_FullMethodName))
+		tooManyRequests(t, call(user, pb.Gophermart_GetBalance_FullMethodName))
+	})
+}
@@ -0,0 +1,339 @@
+package rpc
+
+//go:generate protoc -I ../../api/proto --go_out=../.. --go_opt=module=github.com/gitslim/gophermart --go-grpc_out=../.. --go-grpc_opt=module=github.com/gitslim/gophermart gophermart/v1/gophermart.proto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/conf"
+	"github.com/gitslim/gophermart/internal/errs"
+	"github.com/gitslim/gophermart/internal/logging"
+	"github.com/gitslim/gophermart/internal/luhn"
+	"github.com/gitslim/gophermart/internal/models"
+	"github.com/gitslim/gophermart/internal/ratelimit"
+	"github.com/gitslim/gophermart/internal/rpc/pb"
+	"github.com/gitslim/gophermart/internal/service"
+	"github.com/gitslim/gophermart/internal/web/middleware"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 100
+)
+
+// Server реализует gRPC API, повторяющее пользовательское HTTP API
+type Server struct {
+	pb.UnimplementedGophermartServer
+
+	userService    service.UserService
+	orderService   service.OrderService
+	balanceService service.BalanceService
+	auth           *middleware.AuthMiddleware
+	log            logging.Logger
+
+	// Квоты вызовов, общие с HTTP API
+	rateLimits  ratelimit.Store
+	publicLimit ratelimit.Limit
+	apiLimit    ratelimit.Limit
+	ordersLimit ratelimit.Limit
+}
+
+// NewServer создает новый экземпляр gRPC сервера
+func NewServer(config *conf.Config, log logging.Logger, userService service.UserService, orderService service.OrderService, balanceService service.BalanceService, auth *middleware.AuthMiddleware, rateLimits ratelimit.Store) *Server {
+	return &Server{
+		userService:    userService,
+		orderService:   orderService,
+		balanceService: balanceService,
+		auth:           auth,
+		log:            log,
+
+		rateLimits:  rateLimits,
+		publicLimit: config.RateLimitPublic,
+		apiLimit:    config.RateLimitAPI,
+		ordersLimit: config.RateLimitOrders,
+	}
+}
+
+// Register регистрирует пользователя и выдает токен новой сессии
+func (s *Server) Register(ctx context.Context, req *pb.Credentials) (*pb.AuthResponse, error) {
+	if err := validateCredentials(req); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userService.Register(ctx, req.Login, req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.startSession(ctx, user)
+}
+
+// Login аутентифицирует пользователя, при включенной двухфакторной аутентификации выдает токен для второго шага
+func (s *Server) Login(ctx context.Context, req *pb.Credentials) (*pb.AuthResponse, error) {
+	if err := validateCredentials(req); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userService.Login(ctx, req.Login, req.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPEnabled {
+		preAuthToken, err := s.auth.GeneratePreAuthToken(user)
+		if err != nil {
+			return nil, err
+		}
+
+		return &pb.AuthResponse{
+			TwoFactorRequired: true,
+			PreAuthToken:      preAuthToken,
+		}, nil
+	}
+
+	return s.startSession(ctx, user)
+}
+
+// LoginSecondFactor завершает вход одноразовым кодом или кодом восстановления
+func (s *Server) LoginSecondFactor(ctx context.Context, req *pb.SecondFactorRequest) (*pb.AuthResponse, error) {
+	if req.PreAuthToken == "" || req.Code == "" {
+		return nil, errs.NewAppError(errs.ErrBadRequest, "pre_auth_token and code are required")
+	}
+
+	preAuth, err := s.auth.ParsePreAuthToken(ctx, req.PreAuthToken)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userService.VerifySecondFactor(ctx, preAuth.UserID, req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.auth.ConsumePreAuthToken(ctx, preAuth); err != nil {
+		return nil, err
+	}
+
+	return s.startSession(ctx, user)
+}
+
+// UploadOrder загружает номер заказа
+func (s *Server) UploadOrder(ctx context.Context, req *pb.UploadOrderRequest) (*pb.UploadOrderResponse, error) {
+	userID := userIDFromContext(ctx)
+
+	if !luhn.Valid(req.Number) {
+		return nil, errs.NewAppError(errs.ErrInvalidOrderNumber, "invalid order number")
+	}
+
+	err := s.orderService.UploadOrder(ctx, userID, req.Number)
+	var e *errs.AppError
+	if errors.As(err, &e) && e.Type == errs.ErrOrderAlreadyUploaded {
+		return &pb.UploadOrderResponse{AlreadyUploaded: true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.UploadOrderResponse{}, nil
+}
+
+// ListOrders возвращает страницу заказов пользователя
+func (s *Server) ListOrders(ctx context.Context, req *pb.ListOrdersRequest) (*pb.ListOrdersResponse, error) {
+	userID := userIDFromContext(ctx)
+
+	limit, after, from, to, err := parsePage(req.PageSize, req.PageToken, req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := models.OrderFilter{From: from, To: to, After: after, Limit: limit}
+	for _, st := range req.Statuses {
+		status, ok := orderStatuses[st]
+		if !ok {
+			return nil, errs.NewAppError(errs.ErrBadRequest, fmt.Sprintf("invalid status %s", st))
+		}
+		filter.Statuses = append(filter.Statuses, status)
+	}
+
+	orders, next, err := s.orderService.GetUserOrdersPage(ctx, userID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListOrdersResponse{Orders: make([]*pb.Order, 0, len(orders))}
+	for _, o := range orders {
+		resp.Orders = append(resp.Orders, &pb.Order{
+			Number:     o.Number,
+			Status:     pb.OrderStatus(pb.OrderStatus_value["ORDER_STATUS_"+o.Status]),
+			Accrual:    o.Accrual,
+			UploadedAt: timestamppb.New(o.UploadedAt),
+		})
+	}
+	if next != nil {
+		resp.NextPageToken = next.Encode()
+	}
+
+	return resp, nil
+}
+
+// GetBalance возвращает текущий баланс и сумму списаний пользователя
+func (s *Server) GetBalance(ctx context.Context, _ *emptypb.Empty) (*pb.Balance, error) {
+	userID := userIDFromContext(ctx)
+
+	balance, err := s.balanceService.GetBalance(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	withdrawals, err := s.balanceService.GetWithdrawals(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var withdrawn float64
+	for _, w := range withdrawals {
+		withdrawn += w.Sum
+	}
+
+	return &pb.Balance{Current: balance, Withdrawn: withdrawn}, nil
+}
+
+// Withdraw списывает средства в счет оплаты заказа
+func (s *Server) Withdraw(ctx context.Context, req *pb.WithdrawRequest) (*emptypb.Empty, error) {
+	userID := userIDFromContext(ctx)
+
+	if !luhn.Valid(req.Order) {
+		return nil, errs.NewAppError(errs.ErrInvalidOrderNumber, "invalid order number")
+	}
+
+	// В отличие от HTTP запроса, сообщение protobuf не проверяется по схеме, поэтому сумма проверяется здесь
+	if !(req.Sum > 0) || math.IsInf(req.Sum, 1) {
+		return nil, errs.NewAppError(errs.ErrBadRequest, "withdrawal sum must be positive")
+	}
+
+	if err := s.balanceService.Withdraw(ctx, userID, req.Order, req.Sum); err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// ListWithdrawals возвращает страницу списаний пользователя
+func (s *Server) ListWithdrawals(ctx context.Context, req *pb.ListWithdrawalsRequest) (*pb.ListWithdrawalsResponse, error) {
+	userID := userIDFromContext(ctx)
+
+	limit, after, from, to, err := parsePage(req.PageSize, req.PageToken, req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	withdrawals, next, err := s.balanceService.GetWithdrawalsPage(ctx, userID, models.WithdrawalFilter{From: from, To: to, After: after, Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListWithdrawalsResponse{Withdrawals: make([]*pb.Withdrawal, 0, len(withdrawals))}
+	for _, w := range withdrawals {
+		resp.Withdrawals = append(resp.Withdrawals, &pb.Withdrawal{
+			Order:       w.Order,
+			Sum:         w.Sum,
+			ProcessedAt: timestamppb.New(w.ProcessedAt),
+		})
+	}
+	if next != nil {
+		resp.NextPageToken = next.Encode()
+	}
+
+	return resp, nil
+}
+
+// orderStatuses сопоставляет статусы заказа gRPC API статусам модели
+var orderStatuses = map[pb.OrderStatus]string{
+	pb.OrderStatus_ORDER_STATUS_NEW:        models.OrderStatusNew,
+	pb.OrderStatus_ORDER_STATUS_PROCESSING: models.OrderStatusProcessing,
+	pb.OrderStatus_ORDER_STATUS_INVALID:    models.OrderStatusInvalid,
+	pb.OrderStatus_ORDER_STATUS_PROCESSED:  models.OrderStatusProcessed,
+}
+
+// startSession создает сессию для клиента и возвращает ее токен
+func (s *Server) startSession(ctx context.Context, user *models.User) (*pb.AuthResponse, error) {
+	userAgent, ip := clientInfo(ctx)
+
+	session, token, err := s.auth.IssueSession(ctx, user, userAgent, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.AuthResponse{
+		Token:     token,
+		ExpiresAt: timestamppb.New(session.ExpiresAt),
+	}, nil
+}
+
+// clientInfo возвращает user agent и IP адрес клиента для записи сессии
+func clientInfo(ctx context.Context) (userAgent, ip string) {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get("user-agent"); len(v) > 0 {
+			userAgent = v[0]
+		}
+	}
+
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		ip = p.Addr.String()
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+	}
+
+	return userAgent, ip
+}
+
+// validateCredentials проверяет наличие логина и пароля
+func validateCredentials(req *pb.Credentials) error {
+	if req.Login == "" || req.Password == "" {
+		return errs.NewAppError(errs.ErrBadRequest, "login and password are required")
+	}
+	return nil
+}
+
+// parsePage разбирает общие параметры страницы: размер, токен страницы и период
+func parsePage(pageSize int32, pageToken string, fromTS, toTS *timestamppb.Timestamp) (limit int, after *models.Cursor, from, to *time.Time, err error) {
+	limit = defaultPageSize
+	if pageSize != 0 {
+		if pageSize < 0 || pageSize > maxPageSize {
+			return 0, nil, nil, nil, errs.NewAppError(errs.ErrBadRequest, fmt.Sprintf("page_size must be between 1 and %d", maxPageSize))
+		}
+		limit = int(pageSize)
+	}
+
+	if pageToken != "" {
+		after, err = models.DecodeCursor(pageToken)
+		if err != nil {
+			return 0, nil, nil, nil, errs.NewAppError(errs.ErrBadRequest, "invalid page_token")
+		}
+	}
+
+	if fromTS != nil {
+		t := fromTS.AsTime()
+		from = &t
+	}
+	if toTS != nil {
+		t := toTS.AsTime()
+		to = &t
+	}
+
+	if from != nil && to != nil && !from.Before(*to) {
+		return 0, nil, nil, nil, errs.NewAppError(errs.ErrBadRequest, "from must be before to")
+	}
+
+	return limit, after, from, to, nil
+}
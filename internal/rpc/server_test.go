@@ -0,0 +1,244 @@
+package rpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"math"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/certs"
+	"github.com/gitslim/gophermart/internal/certs/certstest"
+	"github.com/gitslim/gophermart/internal/conf"
+	"github.com/gitslim/gophermart/internal/errs"
+	"github.com/gitslim/gophermart/internal/logging/sugared"
+	"github.com/gitslim/gophermart/internal/models"
+	"github.com/gitslim/gophermart/internal/rpc/pb"
+	"github.com/gitslim/gophermart/internal/service"
+	"github.com/gitslim/gophermart/internal/storage/memory"
+	"github.com/gitslim/gophermart/internal/web/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Номера заказов, по которым fakeBalanceService выбирает результат списания
+const (
+	orderInsufficientFunds = "79927398713"
+	orderInternalError     = "12345678903"
+	orderPanic             = "4561261212345467"
+)
+
+// testSessionID - единственная действующая сессия в fakeSessionService
+const testSessionID = 1
+
+type fakeSessionService struct {
+	service.SessionService
+}
+
+func (s *fakeSessionService) ValidateSession(_ context.Context, _, sessionID int64) (*models.Session, error) {
+	if sessionID != testSessionID {
+		return nil, errs.NewAppError(errs.ErrUnauthorized, "session revoked")
+	}
+	return &models.Session{ID: sessionID}, nil
+}
+
+type fakeBalanceService struct {
+	service.BalanceService
+}
+
+func (s *fakeBalanceService) GetBalance(_ context.Context, _ int64) (float64, error) {
+	return 42.5, nil
+}
+
+func (s *fakeBalanceService) GetWithdrawals(_ context.Context, _ int64) ([]*models.Withdrawal, error) {
+	return []*models.Withdrawal{{Sum: 7.5}}, nil
+}
+
+func (s *fakeBalanceService) Withdraw(_ context.Context, _ int64, orderNumber string, _ float64) error {
+	switch orderNumber {
+	case orderInsufficientFunds:
+		return errs.NewAppError(errs.ErrInsufficientFunds, "insufficient funds")
+	case orderInternalError:
+		return errors.New("connection refused")
+	case orderPanic:
+		panic("unexpected state")
+	}
+	return nil
+}
+
+// newTestClient запускает gRPC сервер на bufconn и возвращает клиента и токены пользователя:
+// действующий и выданный для отозванной сессии
+func newTestClient(t *testing.T) (client pb.GophermartClient, token, revokedToken string) {
+	t.Helper()
+
+	lis, token, revokedToken := startTestServer(t)
+	return dialTestServer(t, lis, insecure.NewCredentials()), token, revokedToken
+}
+
+// startTestServer запускает gRPC сервер с параметрами opts на bufconn и возвращает его listener и токены пользователя
+func startTestServer(t *testing.T, opts ...grpc.ServerOption) (lis *bufconn.Listener, token, revokedToken string) {
+	t.Helper()
+
+	log, err := sugared.NewLogger()
+	require.NoError(t, err)
+
+	users := memory.NewMemUserStorage(memory.NewDB())
+	user := &models.User{Login: "alice", PasswordHash: "hash", Role: models.RoleUser}
+	require.NoError(t, users.CreateUser(context.Background(), user))
+
+	auth, err := middleware.NewAuthMiddleware(&conf.Config{SecretKey: "secret"}, users, &fakeSessionService{}, log)
+	require.NoError(t, err)
+
+	expiresAt := time.Now().Add(time.Hour)
+	token, err = auth.GenerateToken(user, &models.Session{ID: testSessionID, ExpiresAt: expiresAt})
+	require.NoError(t, err)
+	revokedToken, err = auth.GenerateToken(user, &models.Session{ID: testSessionID + 1, ExpiresAt: expiresAt})
+	require.NoError(t, err)
+
+	s := NewServer(&conf.Config{}, log, nil, nil, &fakeBalanceService{}, auth, nil)
+
+	lis = bufconn.Listen(1 << 20)
+	srv := newGRPCServer(s, opts...)
+	go func() {
+		_ = srv.Serve(lis)
+	}()
+	t.Cleanup(srv.Stop)
+
+	return lis, token, revokedToken
+}
+
+// dialTestServer подключается к серверу на bufconn с заданными учетными данными транспорта
+func dialTestServer(t *testing.T, lis *bufconn.Listener, creds credentials.TransportCredentials) pb.GophermartClient {
+	t.Helper()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(creds),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewGophermartClient(conn)
+}
+
+// withToken добавляет токен сессии в метаданные вызова
+func withToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+// requireStatus проверяет код статуса и причину из ErrorInfo, пустая причина означает ее отсутствие
+func requireStatus(t *testing.T, err error, code codes.Code, reason string) {
+	t.Helper()
+
+	st, ok := status.FromError(err)
+	require.True(t, ok, "unexpected error %v", err)
+	require.Equal(t, code, st.Code(), st.Message())
+
+	var got string
+	for _, d := range st.Details() {
+		if info, ok := d.(*errdetails.ErrorInfo); ok {
+			assert.Equal(t, errorDomain, info.Domain)
+			got = info.Reason
+		}
+	}
+	assert.Equal(t, reason, got)
+}
+
+func TestAuthInterceptor(t *testing.T) {
+	client, token, revokedToken := newTestClient(t)
+
+	_, err := client.GetBalance(context.Background(), &emptypb.Empty{})
+	requireStatus(t, err, codes.Unauthenticated, errs.ErrUnauthorized.Code)
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Basic "+token)
+	_, err = client.GetBalance(ctx, &emptypb.Empty{})
+	requireStatus(t, err, codes.Unauthenticated, errs.ErrUnauthorized.Code)
+
+	_, err = client.GetBalance(withToken("not-a-jwt"), &emptypb.Empty{})
+	requireStatus(t, err, codes.Unauthenticated, errs.ErrUnauthorized.Code)
+
+	_, err = client.GetBalance(withToken(revokedToken), &emptypb.Empty{})
+	requireStatus(t, err, codes.Unauthenticated, errs.ErrUnauthorized.Code)
+
+	balance, err := client.GetBalance(withToken(token), &emptypb.Empty{})
+	require.NoError(t, err)
+	assert.InDelta(t, 42.5, balance.Current, 0.001)
+	assert.InDelta(t, 7.5, balance.Withdrawn, 0.001)
+}
+
+func TestErrorInterceptor(t *testing.T) {
+	client, token, _ := newTestClient(t)
+	ctx := withToken(token)
+
+	_, err := client.Withdraw(ctx, &pb.WithdrawRequest{Order: "12345", Sum: 1})
+	requireStatus(t, err, codes.InvalidArgument, errs.ErrInvalidOrderNumber.Code)
+
+	// Нулевая или отрицательная сумма не доходит до сервиса и не пополняет баланс
+	for _, sum := range []float64{0, -1000, math.NaN()} {
+		_, err = client.Withdraw(ctx, &pb.WithdrawRequest{Order: "2377225624", Sum: sum})
+		requireStatus(t, err, codes.InvalidArgument, errs.ErrBadRequest.Code)
+	}
+
+	_, err = client.Withdraw(ctx, &pb.WithdrawRequest{Order: orderInsufficientFunds, Sum: 1})
+	requireStatus(t, err, codes.FailedPrecondition, errs.ErrInsufficientFunds.Code)
+
+	// Внутренние ошибки не раскрываются клиенту
+	_, err = client.Withdraw(ctx, &pb.WithdrawRequest{Order: orderInternalError, Sum: 1})
+	requireStatus(t, err, codes.Internal, "")
+	assert.Equal(t, "internal server error", status.Convert(err).Message())
+}
+
+func TestRecoveryInterceptor(t *testing.T) {
+	client, token, _ := newTestClient(t)
+	ctx := withToken(token)
+
+	_, err := client.Withdraw(ctx, &pb.WithdrawRequest{Order: orderPanic, Sum: 1})
+	requireStatus(t, err, codes.Internal, "")
+
+	// Паника одного вызова не останавливает сервер
+	_, err = client.GetBalance(ctx, &emptypb.Empty{})
+	require.NoError(t, err)
+}
+
+func TestServerTLS(t *testing.T) {
+	log, err := sugared.NewLogger()
+	require.NoError(t, err)
+
+	certFile, keyFile := certstest.WriteSelfSigned(t, t.TempDir(), 1)
+	reloader, err := certs.NewReloader(&conf.Config{TLSCertFile: certFile, TLSKeyFile: keyFile}, log)
+	require.NoError(t, err)
+
+	lis, token, _ := startTestServer(t, serverOptions(reloader)...)
+
+	pem, err := os.ReadFile(certFile)
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(pem))
+
+	client := dialTestServer(t, lis, credentials.NewTLS(&tls.Config{RootCAs: roots, ServerName: "localhost"}))
+	balance, err := client.GetBalance(withToken(token), &emptypb.Empty{})
+	require.NoError(t, err)
+	assert.Equal(t, 42.5, balance.Current)
+
+	// Соединение без TLS сервер не принимает
+	ctx, cancel := context.WithTimeout(withToken(token), time.Second)
+	defer cancel()
+	_, err = dialTestServer(t, lis, insecure.NewCredentials()).GetBalance(ctx, &emptypb.Empty{})
+	require.Error(t, err)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
@@ -0,0 +1,201 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/errs"
+	"github.com/gitslim/gophermart/internal/events"
+	"github.com/gitslim/gophermart/internal/models"
+	"github.com/gitslim/gophermart/internal/service"
+	"github.com/gitslim/gophermart/internal/storage"
+)
+
+const maxSearchLimit = 100
+
+// AdminServiceImpl реализует интерфейс service.AdminService
+type AdminServiceImpl struct {
+	userStorage       storage.UserStorage
+	orderStorage      storage.OrderStorage
+	adjustmentStorage storage.BalanceAdjustmentStorage
+	broker            *events.Broker
+}
+
+// NewAdminService создает новый экземпляр административного сервиса
+func NewAdminService(userStorage storage.UserStorage, orderStorage storage.OrderStorage, adjustmentStorage storage.BalanceAdjustmentStorage, broker *events.Broker) service.AdminService {
+	return &AdminServiceImpl{
+		userStorage:       userStorage,
+		orderStorage:      orderStorage,
+		adjustmentStorage: adjustmentStorage,
+		broker:            broker,
+	}
+}
+
+// SearchUsers ищет пользователей по подстроке логина
+func (s *AdminServiceImpl) SearchUsers(ctx context.Context, login string, limit int) ([]*models.User, error) {
+	if limit <= 0 || limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	users, err := s.userStorage.SearchUsers(ctx, "%"+escapeLike(login)+"%", limit)
+	if err != nil {
+		return nil, errs.NewAppError(errs.ErrInternal, "failed to search users")
+	}
+
+	return users, nil
+}
+
+// GetUser возвращает пользователя по ID
+func (s *AdminServiceImpl) GetUser(ctx context.Context, userID int64) (*models.User, error) {
+	user, err := s.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, errs.NewAppError(errs.ErrInternal, "failed to get user")
+	}
+	if user == nil {
+		return nil, errs.NewAppError(errs.ErrNotFound, "user not found")
+	}
+
+	return user, nil
+}
+
+// SetUserRole назначает пользователю роль
+func (s *AdminServiceImpl) SetUserRole(ctx context.Context, userID int64, role string) error {
+	switch role {
+	case models.RoleUser, models.RoleSupport, models.RoleAdmin:
+	default:
+		return errs.NewAppError(errs.ErrBadRequest, "unknown role")
+	}
+
+	if _, err := s.GetUser(ctx, userID); err != nil {
+		return err
+	}
+
+	if err := s.userStorage.UpdateUserRole(ctx, userID, role); err != nil {
+		return errs.NewAppError(errs.ErrInternal, "failed to update user role")
+	}
+
+	return nil
+}
+
+// GetUserOrders возвращает все заказы пользователя
+func (s *AdminServiceImpl) GetUserOrders(ctx context.Context, userID int64) ([]*models.Order, error) {
+	if _, err := s.GetUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	orders, err := s.orderStorage.GetUserOrders(ctx, userID)
+	if err != nil {
+		return nil, errs.NewAppError(errs.ErrInternal, "failed to get orders")
+	}
+
+	return orders, nil
+}
+
+// GetOrder возвращает заказ по номеру
+func (s *AdminServiceImpl) GetOrder(ctx context.Context, number string) (*models.Order, error) {
+	order, err := s.orderStorage.GetOrderByNumber(ctx, number)
+	if err != nil {
+		return nil, errs.NewAppError(errs.ErrInternal, "failed to get order")
+	}
+	if order == nil {
+		return nil, errs.NewAppError(errs.ErrNotFound, "order not found")
+	}
+
+	return order, nil
+}
+
+// ReprocessOrder возвращает заказ в очередь обработки
+func (s *AdminServiceImpl) ReprocessOrder(ctx context.Context, number string) error {
+	order, err := s.GetOrder(ctx, number)
+	if err != nil {
+		return err
+	}
+
+	// Начисление по обработанному заказу уже зачислено на баланс, повторная обработка зачислила бы его дважды
+	if order.Status == models.OrderStatusProcessed {
+		return errs.NewAppError(errs.ErrConflict, "order already processed")
+	}
+
+	// Статус меняется, только если он остался прочитанным выше: иначе воркер мог успеть
+	// зачислить начисление, и сброс заказа в NEW привел бы к повторному зачислению
+	change := &models.OrderStatusChange{
+		OrderID:        order.ID,
+		PreviousStatus: order.Status,
+		Status:         models.OrderStatusNew,
+		CheckedAt:      time.Now(),
+	}
+	applied, err := s.orderStorage.ApplyOrderStatusChange(ctx, change)
+	if err != nil {
+		return errs.NewAppError(errs.ErrInternal, "failed to update order status")
+	}
+	if !applied {
+		return errs.NewAppError(errs.ErrConflict, "order status changed concurrently")
+	}
+
+	if order.Status != models.OrderStatusNew {
+		s.broker.Publish(order.UserID, events.TypeOrderStatus, events.OrderStatusData{
+			Number: order.Number,
+			Status: models.OrderStatusNew,
+		})
+	}
+
+	return nil
+}
+
+// AdjustBalance вручную изменяет баланс пользователя с указанием причины
+func (s *AdminServiceImpl) AdjustBalance(ctx context.Context, adminID, userID int64, amount float64, reason string) (*models.BalanceAdjustment, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errs.NewAppError(errs.ErrBadRequest, "reason is required")
+	}
+	if amount == 0 {
+		return nil, errs.NewAppError(errs.ErrBadRequest, "amount must not be zero")
+	}
+
+	if _, err := s.GetUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	adjustment := &models.BalanceAdjustment{
+		UserID:    userID,
+		AdminID:   adminID,
+		Amount:    amount,
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.adjustmentStorage.CreateBalanceAdjustment(ctx, adjustment); err != nil {
+		if errors.Is(err, storage.ErrInsufficientFunds) {
+			return nil, errs.NewAppError(errs.ErrInsufficientFunds, "insufficient funds")
+		}
+		return nil, errs.NewAppError(errs.ErrInternal, "failed to adjust balance")
+	}
+
+	s.broker.Publish(userID, events.TypeBalance, events.BalanceData{
+		Type:   models.BalanceHistoryAdjustment,
+		Amount: amount,
+	})
+
+	return adjustment, nil
+}
+
+// GetBalanceAdjustments возвращает историю ручных корректировок баланса пользователя
+func (s *AdminServiceImpl) GetBalanceAdjustments(ctx context.Context, userID int64) ([]*models.BalanceAdjustment, error) {
+	if _, err := s.GetUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	adjustments, err := s.adjustmentStorage.GetUserBalanceAdjustments(ctx, userID)
+	if err != nil {
+		return nil, errs.NewAppError(errs.ErrInternal, "failed to get balance adjustments")
+	}
+
+	return adjustments, nil
+}
+
+// escapeLike экранирует спецсимволы шаблона LIKE
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,86 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/errs"
+	"github.com/gitslim/gophermart/internal/events"
+	"github.com/gitslim/gophermart/internal/models"
+	"github.com/gitslim/gophermart/internal/storage"
+	"github.com/gitslim/gophermart/internal/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// racingOrderStorage после чтения заказа применяет изменение, как если бы воркер
+// обработал заказ между чтением и обновлением статуса
+type racingOrderStorage struct {
+	storage.OrderStorage
+	change *models.OrderStatusChange
+}
+
+func (s *racingOrderStorage) GetOrderByNumber(ctx context.Context, number string) (*models.Order, error) {
+	order, err := s.OrderStorage.GetOrderByNumber(ctx, number)
+	if err != nil || order == nil || s.change == nil {
+		return order, err
+	}
+
+	s.change.OrderID = order.ID
+	if _, err := s.OrderStorage.ApplyOrderStatusChange(ctx, s.change); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+func TestReprocessOrder(t *testing.T) {
+	ctx := context.Background()
+
+	db := memory.NewDB()
+	users := memory.NewMemUserStorage(db)
+	orders := &racingOrderStorage{OrderStorage: memory.NewMemOrderStorage(db)}
+	svc := NewAdminService(users, orders, memory.NewMemBalanceAdjustmentStorage(db), events.NewBroker())
+
+	user := &models.User{Login: "alice", PasswordHash: "hash", Role: models.RoleUser}
+	require.NoError(t, users.CreateUser(ctx, user))
+
+	order := &models.Order{Number: "12345678903", UserID: user.ID, Status: models.OrderStatusInvalid, UploadedAt: time.Now()}
+	require.NoError(t, orders.CreateOrder(ctx, order))
+
+	// Заказ с неизменившимся статусом возвращается в очередь
+	require.NoError(t, svc.ReprocessOrder(ctx, order.Number))
+	got, err := svc.GetOrder(ctx, order.Number)
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderStatusNew, got.Status)
+
+	// Воркер зачисляет начисление после чтения заказа, сброс статуса отклоняется
+	orders.change = &models.OrderStatusChange{
+		PreviousStatus: models.OrderStatusNew,
+		Status:         models.OrderStatusProcessed,
+		Accrual:        42.5,
+		Credited:       42.5,
+		CheckedAt:      time.Now(),
+	}
+	err = svc.ReprocessOrder(ctx, order.Number)
+
+	var appErr *errs.AppError
+	require.True(t, errors.As(err, &appErr), "unexpected error %v", err)
+	assert.Equal(t, errs.ErrConflict, appErr.Type)
+
+	orders.change = nil
+	got, err = svc.GetOrder(ctx, order.Number)
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderStatusProcessed, got.Status)
+	assert.Equal(t, 42.5, got.Accrual)
+
+	u, err := users.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 42.5, u.Balance)
+
+	// Обработанный заказ повторно не обрабатывается
+	err = svc.ReprocessOrder(ctx, order.Number)
+	require.True(t, errors.As(err, &appErr), "unexpected error %v", err)
+	assert.Equal(t, errs.ErrConflict, appErr.Type)
+}
@@ -2,9 +2,12 @@ package balance
 
 import (
 	"context"
+	"errors"
+	"math"
 	"time"
 
 	"github.com/gitslim/gophermart/internal/errs"
+	"github.com/gitslim/gophermart/internal/events"
 	"github.com/gitslim/gophermart/internal/models"
 	"github.com/gitslim/gophermart/internal/service"
 	"github.com/gitslim/gophermart/internal/storage"
@@ -14,13 +17,15 @@ import (
 type BalanceServiceImpl struct {
 	userStorage       storage.UserStorage
 	withdrawalStorage storage.WithdrawalStorage
+	broker            *events.Broker
 }
 
 // NewBalanceService создает новый экземпляр сервиса баланса
-func NewBalanceService(userStorage storage.UserStorage, withdrawalStorage storage.WithdrawalStorage) service.BalanceService {
+func NewBalanceService(userStorage storage.UserStorage, withdrawalStorage storage.WithdrawalStorage, broker *events.Broker) service.BalanceService {
 	return &BalanceServiceImpl{
 		userStorage:       userStorage,
 		withdrawalStorage: withdrawalStorage,
+		broker:            broker,
 	}
 }
 
@@ -39,17 +44,13 @@ func (s *BalanceServiceImpl) GetBalance(ctx context.Context, userID int64) (floa
 
 // Withdraw списывает средства с баланса пользователя
 func (s *BalanceServiceImpl) Withdraw(ctx context.Context, userID int64, orderNumber string, amount float64) error {
-	// Проверяем баланс пользователя
-	user, err := s.userStorage.GetUserByID(ctx, userID)
-	if err != nil || user == nil {
-		return errs.NewAppError(errs.ErrUnauthorized, "user not found")
-	}
-
-	if user.Balance < amount {
-		return errs.NewAppError(errs.ErrPaymentRequired, "insufficient funds")
+	// Хранилища вычитают сумму без проверки знака, отрицательное списание пополнило бы баланс
+	if !validAmount(amount) {
+		return errs.NewAppError(errs.ErrBadRequest, "withdrawal sum must be positive")
 	}
 
-	// Создаем запись о списании
+	// Проверка баланса, списание и запись операции выполняются атомарно в хранилище,
+	// поэтому параллельные списания не уводят баланс в минус
 	withdrawal := &models.Withdrawal{
 		UserID:      userID,
 		Order:       orderNumber,
@@ -57,19 +58,55 @@ func (s *BalanceServiceImpl) Withdraw(ctx context.Context, userID int64, orderNu
 		ProcessedAt: time.Now(),
 	}
 
-	if err := s.withdrawalStorage.CreateWithdrawal(ctx, withdrawal); err != nil {
+	err := s.withdrawalStorage.Withdraw(ctx, withdrawal)
+	if errors.Is(err, storage.ErrInsufficientFunds) {
+		return errs.NewAppError(errs.ErrInsufficientFunds, "insufficient funds")
+	}
+	if err != nil {
 		return errs.NewAppError(errs.ErrInternal, "failed to create withdrawal")
 	}
 
-	// Обновляем баланс пользователя
-	if err := s.userStorage.UpdateBalance(ctx, userID, -amount); err != nil {
-		return errs.NewAppError(errs.ErrInternal, "failed to update balance")
-	}
+	s.broker.Publish(userID, events.TypeBalance, events.BalanceData{
+		Type:   models.BalanceHistoryWithdrawal,
+		Amount: -amount,
+		Order:  orderNumber,
+	})
 
 	return nil
 }
 
+// validAmount сообщает, является ли сумма списания конечным положительным числом
+func validAmount(amount float64) bool {
+	return amount > 0 && !math.IsInf(amount, 1)
+}
+
 // GetWithdrawals возвращает историю списаний пользователя
 func (s *BalanceServiceImpl) GetWithdrawals(ctx context.Context, userID int64) ([]*models.Withdrawal, error) {
 	return s.withdrawalStorage.GetUserWithdrawals(ctx, userID)
 }
+
+// GetWithdrawalsPage возвращает страницу списаний пользователя и курсор следующей страницы
+func (s *BalanceServiceImpl) GetWithdrawalsPage(ctx context.Context, userID int64, filter models.WithdrawalFilter) ([]*models.Withdrawal, *models.Cursor, error) {
+	// Запрашиваем на одну запись больше, чтобы узнать, есть ли следующая страница
+	limit := filter.Limit
+	filter.Limit++
+
+	withdrawals, err := s.withdrawalStorage.GetUserWithdrawalsPage(ctx, userID, filter)
+	if err != nil {
+		return nil, nil, errs.NewAppError(errs.ErrInternal, "failed to get withdrawals")
+	}
+
+	if len(withdrawals) <= limit {
+		return withdrawals, nil, nil
+	}
+
+	withdrawals = withdrawals[:limit]
+	last := withdrawals[limit-1]
+
+	return withdrawals, &models.Cursor{At: last.ProcessedAt, ID: last.ID}, nil
+}
+
+// ExportWithdrawals построчно передает в fn списания пользователя для выгрузки
+func (s *BalanceServiceImpl) ExportWithdrawals(ctx context.Context, userID int64, filter models.WithdrawalFilter, fn func(*models.Withdrawal) error) error {
+	return s.withdrawalStorage.ExportUserWithdrawals(ctx, userID, filter, fn)
+}
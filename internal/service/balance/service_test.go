@@ -0,0 +1,49 @@
+package balance
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/gitslim/gophermart/internal/errs"
+	"github.com/gitslim/gophermart/internal/events"
+	"github.com/gitslim/gophermart/internal/models"
+	"github.com/gitslim/gophermart/internal/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithdrawRejectsNonPositiveSum(t *testing.T) {
+	ctx := context.Background()
+
+	db := memory.NewDB()
+	users := memory.NewMemUserStorage(db)
+	svc := NewBalanceService(users, memory.NewMemWithdrawalStorage(db), events.NewBroker())
+
+	user := &models.User{Login: "alice", PasswordHash: "hash", Role: models.RoleUser}
+	require.NoError(t, users.CreateUser(ctx, user))
+	require.NoError(t, users.UpdateBalance(ctx, user.ID, 10))
+
+	for _, sum := range []float64{0, -1000, math.NaN(), math.Inf(1)} {
+		err := svc.Withdraw(ctx, user.ID, "2377225624", sum)
+
+		var appErr *errs.AppError
+		require.True(t, errors.As(err, &appErr), "sum %v: unexpected error %v", sum, err)
+		assert.Equal(t, errs.ErrBadRequest, appErr.Type, "sum %v", sum)
+	}
+
+	// Баланс не изменился, списания не записаны
+	balance, err := svc.GetBalance(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 10.0, balance)
+
+	withdrawals, err := svc.GetWithdrawals(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Empty(t, withdrawals)
+
+	require.NoError(t, svc.Withdraw(ctx, user.ID, "2377225624", 2.5))
+	balance, err = svc.GetBalance(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 7.5, balance)
+}
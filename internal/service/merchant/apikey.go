@@ -0,0 +1,49 @@
+package merchant
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+const apiKeyPrefix = "gm"
+
+// generateAPIKey создает новый ключ вида gm_<префикс>_<секрет> и возвращает ключ и его префикс
+func generateAPIKey() (string, string, error) {
+	prefix := make([]byte, 6)
+	if _, err := rand.Read(prefix); err != nil {
+		return "", "", fmt.Errorf("failed to generate api key prefix: %w", err)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", fmt.Errorf("failed to generate api key secret: %w", err)
+	}
+
+	p := hex.EncodeToString(prefix)
+	return fmt.Sprintf("%s_%s_%s", apiKeyPrefix, p, base64.RawURLEncoding.EncodeToString(secret)), p, nil
+}
+
+// parseAPIKey извлекает префикс из ключа
+func parseAPIKey(rawKey string) (string, bool) {
+	parts := strings.SplitN(rawKey, "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyPrefix || parts[1] == "" || parts[2] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// hashAPIKey хеширует ключ, ключи имеют высокую энтропию, поэтому медленное хеширование не требуется
+func hashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// compareAPIKeyHash сравнивает хеши за постоянное время
+func compareAPIKeyHash(hash, rawKey string) bool {
+	return subtle.ConstantTimeCompare([]byte(hash), []byte(hashAPIKey(rawKey))) == 1
+}
@@ -0,0 +1,236 @@
+package merchant
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/conf"
+	"github.com/gitslim/gophermart/internal/errs"
+	"github.com/gitslim/gophermart/internal/logging"
+	"github.com/gitslim/gophermart/internal/models"
+	"github.com/gitslim/gophermart/internal/service"
+	"github.com/gitslim/gophermart/internal/storage"
+)
+
+// knownScopes содержит права, которые можно выдать ключу
+var knownScopes = map[string]bool{
+	models.ScopeOrdersWrite: true,
+}
+
+// MerchantServiceImpl реализует интерфейс service.MerchantService
+type MerchantServiceImpl struct {
+	merchantStorage storage.MerchantStorage
+	userStorage     storage.UserStorage
+	orderService    service.OrderService
+	rotationGrace   time.Duration
+	log             logging.Logger
+}
+
+// NewMerchantService создает новый экземпляр сервиса магазинов
+func NewMerchantService(config *conf.Config, merchantStorage storage.MerchantStorage, userStorage storage.UserStorage, orderService service.OrderService, log logging.Logger) service.MerchantService {
+	return &MerchantServiceImpl{
+		merchantStorage: merchantStorage,
+		userStorage:     userStorage,
+		orderService:    orderService,
+		rotationGrace:   config.APIKeyRotationGrace,
+		log:             log,
+	}
+}
+
+// CreateMerchant регистрирует новый магазин
+func (s *MerchantServiceImpl) CreateMerchant(ctx context.Context, name string) (*models.Merchant, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errs.NewAppError(errs.ErrBadRequest, "merchant name is required")
+	}
+
+	existing, err := s.merchantStorage.GetMerchantByName(ctx, name)
+	if err != nil {
+		return nil, errs.NewAppError(errs.ErrInternal, "failed to get merchant")
+	}
+	if existing != nil {
+		return nil, errs.NewAppError(errs.ErrConflict, "merchant already exists")
+	}
+
+	merchant := &models.Merchant{
+		Name:      name,
+		CreatedAt: time.Now(),
+	}
+
+	if err := s.merchantStorage.CreateMerchant(ctx, merchant); err != nil {
+		return nil, errs.NewAppError(errs.ErrInternal, "failed to create merchant")
+	}
+
+	return merchant, nil
+}
+
+// GetMerchants возвращает все магазины
+func (s *MerchantServiceImpl) GetMerchants(ctx context.Context) ([]*models.Merchant, error) {
+	merchants, err := s.merchantStorage.GetMerchants(ctx)
+	if err != nil {
+		return nil, errs.NewAppError(errs.ErrInternal, "failed to get merchants")
+	}
+	return merchants, nil
+}
+
+// GetAPIKeys возвращает все ключи доступа магазина
+func (s *MerchantServiceImpl) GetAPIKeys(ctx context.Context, merchantID int64) ([]*models.APIKey, error) {
+	if err := s.checkMerchant(ctx, merchantID); err != nil {
+		return nil, err
+	}
+
+	keys, err := s.merchantStorage.GetMerchantAPIKeys(ctx, merchantID)
+	if err != nil {
+		return nil, errs.NewAppError(errs.ErrInternal, "failed to get api keys")
+	}
+	return keys, nil
+}
+
+// IssueAPIKey выпускает новый ключ доступа, открытый ключ возвращается только один раз
+func (s *MerchantServiceImpl) IssueAPIKey(ctx context.Context, merchantID int64, scopes []string) (*models.APIKey, string, error) {
+	if err := s.checkMerchant(ctx, merchantID); err != nil {
+		return nil, "", err
+	}
+
+	if len(scopes) == 0 {
+		return nil, "", errs.NewAppError(errs.ErrBadRequest, "at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if !knownScopes[scope] {
+			return nil, "", errs.NewAppError(errs.ErrBadRequest, "unknown scope: "+scope)
+		}
+	}
+
+	return s.createAPIKey(ctx, merchantID, strings.Join(scopes, " "))
+}
+
+// RotateAPIKey выпускает замену ключа с теми же правами, старый ключ действует еще rotationGrace
+func (s *MerchantServiceImpl) RotateAPIKey(ctx context.Context, merchantID, keyID int64) (*models.APIKey, string, error) {
+	old, err := s.getMerchantAPIKey(ctx, merchantID, keyID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	if !old.IsActive(now) {
+		return nil, "", errs.NewAppError(errs.ErrConflict, "api key is not active")
+	}
+
+	key, rawKey, err := s.createAPIKey(ctx, merchantID, old.Scopes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := s.merchantStorage.ExpireAPIKey(ctx, old.ID, now.Add(s.rotationGrace)); err != nil {
+		return nil, "", errs.NewAppError(errs.ErrInternal, "failed to expire api key")
+	}
+
+	return key, rawKey, nil
+}
+
+// RevokeAPIKey немедленно отзывает ключ доступа
+func (s *MerchantServiceImpl) RevokeAPIKey(ctx context.Context, merchantID, keyID int64) error {
+	if _, err := s.getMerchantAPIKey(ctx, merchantID, keyID); err != nil {
+		return err
+	}
+
+	if err := s.merchantStorage.RevokeAPIKey(ctx, keyID, time.Now()); err != nil {
+		return errs.NewAppError(errs.ErrInternal, "failed to revoke api key")
+	}
+	return nil
+}
+
+// Authenticate проверяет ключ доступа и возвращает его описание
+func (s *MerchantServiceImpl) Authenticate(ctx context.Context, rawKey string) (*models.APIKey, error) {
+	unauthorized := errs.NewAppError(errs.ErrUnauthorized, "invalid api key")
+
+	prefix, ok := parseAPIKey(rawKey)
+	if !ok {
+		return nil, unauthorized
+	}
+
+	key, err := s.merchantStorage.GetAPIKeyByPrefix(ctx, prefix)
+	if err != nil {
+		return nil, errs.NewAppError(errs.ErrInternal, "failed to get api key")
+	}
+	if key == nil || !compareAPIKeyHash(key.KeyHash, rawKey) {
+		return nil, unauthorized
+	}
+
+	now := time.Now()
+	if !key.IsActive(now) {
+		return nil, unauthorized
+	}
+
+	if err := s.merchantStorage.TouchAPIKey(ctx, key.ID, now); err != nil {
+		logging.FromContext(ctx, s.log).Errorf("Failed to update last usage of api key %d: %v", key.ID, err)
+	}
+
+	return key, nil
+}
+
+// UploadOrder загружает заказ от имени пользователя с указанным логином
+func (s *MerchantServiceImpl) UploadOrder(ctx context.Context, merchantID int64, login, orderNumber string) error {
+	user, err := s.userStorage.GetUserByLogin(ctx, login)
+	if err != nil {
+		return errs.NewAppError(errs.ErrInternal, "failed to get user")
+	}
+	if user == nil {
+		return errs.NewAppError(errs.ErrNotFound, "user not found")
+	}
+
+	if err := s.orderService.UploadOrder(ctx, user.ID, orderNumber); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx, s.log).Infof("Order %s uploaded by merchant %d for user %d", orderNumber, merchantID, user.ID)
+
+	return nil
+}
+
+// checkMerchant проверяет существование магазина
+func (s *MerchantServiceImpl) checkMerchant(ctx context.Context, merchantID int64) error {
+	merchant, err := s.merchantStorage.GetMerchantByID(ctx, merchantID)
+	if err != nil {
+		return errs.NewAppError(errs.ErrInternal, "failed to get merchant")
+	}
+	if merchant == nil {
+		return errs.NewAppError(errs.ErrNotFound, "merchant not found")
+	}
+	return nil
+}
+
+// getMerchantAPIKey возвращает ключ, принадлежащий магазину
+func (s *MerchantServiceImpl) getMerchantAPIKey(ctx context.Context, merchantID, keyID int64) (*models.APIKey, error) {
+	key, err := s.merchantStorage.GetAPIKeyByID(ctx, keyID)
+	if err != nil {
+		return nil, errs.NewAppError(errs.ErrInternal, "failed to get api key")
+	}
+	if key == nil || key.MerchantID != merchantID {
+		return nil, errs.NewAppError(errs.ErrNotFound, "api key not found")
+	}
+	return key, nil
+}
+
+// createAPIKey генерирует и сохраняет новый ключ
+func (s *MerchantServiceImpl) createAPIKey(ctx context.Context, merchantID int64, scopes string) (*models.APIKey, string, error) {
+	rawKey, prefix, err := generateAPIKey()
+	if err != nil {
+		return nil, "", errs.NewAppError(errs.ErrInternal, "failed to generate api key")
+	}
+
+	key := &models.APIKey{
+		MerchantID: merchantID,
+		Prefix:     prefix,
+		KeyHash:    hashAPIKey(rawKey),
+		Scopes:     scopes,
+		CreatedAt:  time.Now(),
+	}
+
+	if err := s.merchantStorage.CreateAPIKey(ctx, key); err != nil {
+		return nil, "", errs.NewAppError(errs.ErrInternal, "failed to create api key")
+	}
+
+	return key, rawKey, nil
+}
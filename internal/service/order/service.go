@@ -7,6 +7,8 @@ import (
 
 	"github.com/gitslim/gophermart/internal/accrual"
 	"github.com/gitslim/gophermart/internal/errs"
+	"github.com/gitslim/gophermart/internal/events"
+	"github.com/gitslim/gophermart/internal/logging"
 	"github.com/gitslim/gophermart/internal/models"
 	"github.com/gitslim/gophermart/internal/service"
 	"github.com/gitslim/gophermart/internal/storage"
@@ -15,16 +17,18 @@ import (
 // OrderServiceImpl реализует интерфейс service.OrderService
 type OrderServiceImpl struct {
 	orderStorage  storage.OrderStorage
-	userStorage   storage.UserStorage
 	accrualClient *accrual.Client
+	broker        *events.Broker
+	log           logging.Logger
 }
 
 // NewOrderService создает новый экземпляр сервиса заказов
-func NewOrderService(orderStorage storage.OrderStorage, userStorage storage.UserStorage, accrualClient *accrual.Client) service.OrderService {
+func NewOrderService(orderStorage storage.OrderStorage, accrualClient *accrual.Client, broker *events.Broker, log logging.Logger) service.OrderService {
 	return &OrderServiceImpl{
 		orderStorage:  orderStorage,
-		userStorage:   userStorage,
 		accrualClient: accrualClient,
+		broker:        broker,
+		log:           log,
 	}
 }
 
@@ -37,9 +41,9 @@ func (s *OrderServiceImpl) UploadOrder(ctx context.Context, userID int64, orderN
 	}
 	if existingOrder != nil {
 		if existingOrder.UserID == userID {
-			return errs.NewAppError(errs.ErrOk, "order already uploaded by the same user")
+			return errs.NewAppError(errs.ErrOrderAlreadyUploaded, "order already uploaded by the same user")
 		}
-		return errs.NewAppError(errs.ErrConflict, "order already uploaded by another user")
+		return errs.NewAppError(errs.ErrOrderConflict, "order already uploaded by another user")
 	}
 
 	// Создаем заказ
@@ -57,11 +61,68 @@ func (s *OrderServiceImpl) UploadOrder(ctx context.Context, userID int64, orderN
 	return nil
 }
 
+// UploadOrders загружает пакет заказов, номера должны быть предварительно проверены
+func (s *OrderServiceImpl) UploadOrders(ctx context.Context, userID int64, orderNumbers []string) ([]*models.OrderUploadResult, error) {
+	results, err := s.orderStorage.CreateOrders(ctx, userID, orderNumbers, time.Now())
+	if err != nil {
+		return nil, errs.NewAppError(errs.ErrInternal, "failed to create orders")
+	}
+
+	return results, nil
+}
+
 // GetUserOrders возвращает все заказы пользователя
 func (s *OrderServiceImpl) GetUserOrders(ctx context.Context, userID int64) ([]*models.Order, error) {
 	return s.orderStorage.GetUserOrders(ctx, userID)
 }
 
+// GetUserOrdersPage возвращает страницу заказов пользователя и курсор следующей страницы
+func (s *OrderServiceImpl) GetUserOrdersPage(ctx context.Context, userID int64, filter models.OrderFilter) ([]*models.Order, *models.Cursor, error) {
+	// Запрашиваем на одну запись больше, чтобы узнать, есть ли следующая страница
+	limit := filter.Limit
+	filter.Limit++
+
+	orders, err := s.orderStorage.GetUserOrdersPage(ctx, userID, filter)
+	if err != nil {
+		return nil, nil, errs.NewAppError(errs.ErrInternal, "failed to get orders")
+	}
+
+	if len(orders) <= limit {
+		return orders, nil, nil
+	}
+
+	orders = orders[:limit]
+	last := orders[limit-1]
+
+	return orders, &models.Cursor{At: last.UploadedAt, ID: last.ID}, nil
+}
+
+// ExportUserOrders построчно передает в fn заказы пользователя для выгрузки
+func (s *OrderServiceImpl) ExportUserOrders(ctx context.Context, userID int64, filter models.OrderFilter, fn func(*models.Order) error) error {
+	return s.orderStorage.ExportUserOrders(ctx, userID, filter, fn)
+}
+
+// GetUserOrder возвращает заказ пользователя с историей обработки, чужие заказы не раскрываются
+func (s *OrderServiceImpl) GetUserOrder(ctx context.Context, userID int64, orderNumber string) (*models.OrderDetail, error) {
+	order, err := s.orderStorage.GetOrderByNumber(ctx, orderNumber)
+	if err != nil {
+		return nil, errs.NewAppError(errs.ErrInternal, "failed to get order")
+	}
+	if order == nil || order.UserID != userID {
+		return nil, errs.NewAppError(errs.ErrNotFound, "order not found")
+	}
+
+	history, err := s.orderStorage.GetOrderStatusHistory(ctx, order.ID)
+	if err != nil {
+		return nil, errs.NewAppError(errs.ErrInternal, "failed to get order history")
+	}
+	if history == nil {
+		history = []*models.OrderStatusChange{}
+	}
+
+	return &models.OrderDetail{Order: order, History: history}, nil
+}
+
 // ProcessOrder обрабатывает заказ
 func (s *OrderServiceImpl) ProcessOrder(ctx context.Context, orderNumber string) error {
 	order, err := s.orderStorage.GetOrderByNumber(ctx, orderNumber)
@@ -85,24 +146,57 @@ func (s *OrderServiceImpl) ProcessOrder(ctx context.Context, orderNumber string)
 	}
 
 	// Если ответ пустой, значит заказ еще не зарегистрирован в системе начислений
-	if accrualResp == nil {
-		if err := s.orderStorage.UpdateOrderStatus(ctx, order.ID, models.OrderStatusProcessing, 0); err != nil {
-			return errs.NewAppError(errs.ErrInternal, "failed to update order status")
-		}
-		return nil
+	status, accrualAmount := models.OrderStatusProcessing, 0.0
+	if accrualResp != nil {
+		status, accrualAmount = accrualResp.Status, accrualResp.Accrual
+	}
+
+	// Если заказ обработан и есть начисление, сумма зачисляется на баланс пользователя
+	var credited float64
+	if status == models.OrderStatusProcessed && accrualAmount > 0 {
+		credited = accrualAmount
+	}
+
+	// Статус, начисление и запись истории сохраняются атомарно: прерванная обработка
+	// не оставит обработанный заказ без зачисления
+	change := &models.OrderStatusChange{
+		OrderID:        order.ID,
+		PreviousStatus: order.Status,
+		Status:         status,
+		Accrual:        accrualAmount,
+		Credited:       credited,
+		CheckedAt:      time.Now(),
 	}
 
-	// Обновляем статус и начисление в соответствии с ответом от системы
-	if err := s.orderStorage.UpdateOrderStatus(ctx, order.ID, accrualResp.Status, accrualResp.Accrual); err != nil {
+	applied, err := s.orderStorage.ApplyOrderStatusChange(ctx, change)
+	if err != nil {
 		return errs.NewAppError(errs.ErrInternal, "failed to update order")
 	}
+	if !applied {
+		// Статус заказа изменился после чтения, заказ будет проверен в следующем цикле
+		return nil
+	}
 
-	// Если заказ обработан и есть начисление, обновляем баланс пользователя
-	if accrualResp.Status == models.OrderStatusProcessed && accrualResp.Accrual > 0 {
-		if err := s.userStorage.UpdateBalance(ctx, order.UserID, accrualResp.Accrual); err != nil {
-			return errs.NewAppError(errs.ErrInternal, "failed to update user balance")
-		}
+	s.publishOrderStatus(order, status, accrualAmount)
+	if credited > 0 {
+		s.broker.Publish(order.UserID, events.TypeBalance, events.BalanceData{
+			Type:   models.BalanceHistoryAccrual,
+			Amount: credited,
+			Order:  order.Number,
+		})
 	}
 
 	return nil
 }
+
+// publishOrderStatus уведомляет пользователя о смене статуса заказа
+func (s *OrderServiceImpl) publishOrderStatus(order *models.Order, status string, accrual float64) {
+	if order.Status == status {
+		return
+	}
+	s.broker.Publish(order.UserID, events.TypeOrderStatus, events.OrderStatusData{
+		Number:  order.Number,
+		Status:  status,
+		Accrual: accrual,
+	})
+}
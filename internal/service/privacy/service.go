@@ -0,0 +1,180 @@
+package privacy
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/errs"
+	"github.com/gitslim/gophermart/internal/logging"
+	"github.com/gitslim/gophermart/internal/models"
+	"github.com/gitslim/gophermart/internal/password"
+	"github.com/gitslim/gophermart/internal/service"
+	"github.com/gitslim/gophermart/internal/storage"
+)
+
+// PrivacyServiceImpl реализует интерфейс service.PrivacyService
+type PrivacyServiceImpl struct {
+	userStorage       storage.UserStorage
+	orderStorage      storage.OrderStorage
+	withdrawalStorage storage.WithdrawalStorage
+	adjustmentStorage storage.BalanceAdjustmentStorage
+	sessionStorage    storage.SessionStorage
+	hasher            password.Hasher
+	log               logging.Logger
+}
+
+// NewPrivacyService создает новый экземпляр сервиса персональных данных
+func NewPrivacyService(userStorage storage.UserStorage, orderStorage storage.OrderStorage, withdrawalStorage storage.WithdrawalStorage, adjustmentStorage storage.BalanceAdjustmentStorage, sessionStorage storage.SessionStorage, hasher password.Hasher, log logging.Logger) service.PrivacyService {
+	return &PrivacyServiceImpl{
+		userStorage:       userStorage,
+		orderStorage:      orderStorage,
+		withdrawalStorage: withdrawalStorage,
+		adjustmentStorage: adjustmentStorage,
+		sessionStorage:    sessionStorage,
+		hasher:            hasher,
+		log:               log,
+	}
+}
+
+// ExportData собирает архив персональных данных пользователя
+func (s *PrivacyServiceImpl) ExportData(ctx context.Context, userID int64) (*models.UserDataExport, error) {
+	user, err := s.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, errs.NewAppError(errs.ErrInternal, "failed to get user")
+	}
+	if user == nil || user.DeletedAt != nil {
+		return nil, errs.NewAppError(errs.ErrNotFound, "user not found")
+	}
+
+	orders, err := s.orderStorage.GetUserOrders(ctx, userID)
+	if err != nil {
+		return nil, errs.NewAppError(errs.ErrInternal, "failed to get orders")
+	}
+
+	withdrawals, err := s.withdrawalStorage.GetUserWithdrawals(ctx, userID)
+	if err != nil {
+		return nil, errs.NewAppError(errs.ErrInternal, "failed to get withdrawals")
+	}
+
+	adjustments, err := s.adjustmentStorage.GetUserBalanceAdjustments(ctx, userID)
+	if err != nil {
+		return nil, errs.NewAppError(errs.ErrInternal, "failed to get balance adjustments")
+	}
+
+	now := time.Now()
+	sessions, err := s.sessionStorage.GetUserSessions(ctx, userID, now)
+	if err != nil {
+		return nil, errs.NewAppError(errs.ErrInternal, "failed to get sessions")
+	}
+
+	return &models.UserDataExport{
+		Profile: models.UserProfile{
+			Login:       user.Login,
+			Role:        user.Role,
+			TOTPEnabled: user.TOTPEnabled,
+			CreatedAt:   user.CreatedAt,
+		},
+		Balance:        user.Balance,
+		Orders:         nonNil(orders),
+		Withdrawals:    nonNil(withdrawals),
+		BalanceHistory: balanceHistory(orders, withdrawals, adjustments),
+		Sessions:       nonNil(sessions),
+		ExportedAt:     now,
+	}, nil
+}
+
+// DeleteAccount обезличивает пользователя после подтверждения паролем.
+// Заказы и списания сохраняются за обезличенной записью для бухгалтерской отчетности
+func (s *PrivacyServiceImpl) DeleteAccount(ctx context.Context, userID int64, password string) error {
+	user, err := s.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		return errs.NewAppError(errs.ErrInternal, "failed to get user")
+	}
+	if user == nil || user.DeletedAt != nil {
+		return errs.NewAppError(errs.ErrNotFound, "user not found")
+	}
+
+	ok, err := s.hasher.Verify(user.PasswordHash, password)
+	if err != nil || !ok {
+		return errs.NewAppError(errs.ErrInvalidCredentials, "invalid password")
+	}
+
+	login, err := anonymizedLogin(userID)
+	if err != nil {
+		return errs.NewAppError(errs.ErrInternal, "failed to delete account")
+	}
+
+	deleted, err := s.userStorage.AnonymizeUser(ctx, userID, login, time.Now())
+	if err != nil {
+		return errs.NewAppError(errs.ErrInternal, "failed to delete account")
+	}
+	if !deleted {
+		return errs.NewAppError(errs.ErrNotFound, "user not found")
+	}
+
+	logging.FromContext(ctx, s.log).Infof("Account of user %d deleted", userID)
+
+	return nil
+}
+
+// balanceHistory собирает хронологию изменений баланса, новые записи первыми
+func balanceHistory(orders []*models.Order, withdrawals []*models.Withdrawal, adjustments []*models.BalanceAdjustment) []models.BalanceHistoryEntry {
+	history := make([]models.BalanceHistoryEntry, 0, len(orders)+len(withdrawals)+len(adjustments))
+
+	for _, o := range orders {
+		if o.Status != models.OrderStatusProcessed || o.Accrual <= 0 {
+			continue
+		}
+		history = append(history, models.BalanceHistoryEntry{
+			Type:      models.BalanceHistoryAccrual,
+			Amount:    o.Accrual,
+			Reference: o.Number,
+			At:        o.ProcessedAt,
+		})
+	}
+
+	for _, w := range withdrawals {
+		history = append(history, models.BalanceHistoryEntry{
+			Type:      models.BalanceHistoryWithdrawal,
+			Amount:    -w.Sum,
+			Reference: w.Order,
+			At:        w.ProcessedAt,
+		})
+	}
+
+	for _, a := range adjustments {
+		history = append(history, models.BalanceHistoryEntry{
+			Type:   models.BalanceHistoryAdjustment,
+			Amount: a.Amount,
+			Reason: a.Reason,
+			At:     a.CreatedAt,
+		})
+	}
+
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].At.After(history[j].At)
+	})
+
+	return history
+}
+
+// anonymizedLogin создает уникальный логин, не связанный с исходным
+func anonymizedLogin(userID int64) (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate anonymized login: %w", err)
+	}
+	return fmt.Sprintf("deleted-%d-%s", userID, hex.EncodeToString(b)), nil
+}
+
+// nonNil заменяет nil-срез пустым, чтобы в архиве были массивы, а не null
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
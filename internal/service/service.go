@@ -11,12 +11,21 @@ type UserService interface {
 	Register(ctx context.Context, login, password string) (*models.User, error)
 	Login(ctx context.Context, login, password string) (*models.User, error)
 	GetUserByID(ctx context.Context, id int64) (*models.User, error)
+	ChangePassword(ctx context.Context, userID int64, oldPassword, newPassword string) (*models.User, error)
+	EnrollTOTP(ctx context.Context, userID int64) (secret string, uri string, err error)
+	ConfirmTOTP(ctx context.Context, userID int64, code string) (recoveryCodes []string, err error)
+	DisableTOTP(ctx context.Context, userID int64, password, code string) error
+	VerifySecondFactor(ctx context.Context, userID int64, code string) (*models.User, error)
 }
 
 // OrderService определяет интерфейс для работы с заказами
 type OrderService interface {
 	UploadOrder(ctx context.Context, userID int64, orderNumber string) error
+	UploadOrders(ctx context.Context, userID int64, orderNumbers []string) ([]*models.OrderUploadResult, error)
 	GetUserOrders(ctx context.Context, userID int64) ([]*models.Order, error)
+	GetUserOrdersPage(ctx context.Context, userID int64, filter models.OrderFilter) ([]*models.Order, *models.Cursor, error)
+	ExportUserOrders(ctx context.Context, userID int64, filter models.OrderFilter, fn func(*models.Order) error) error
+	GetUserOrder(ctx context.Context, userID int64, orderNumber string) (*models.OrderDetail, error)
 	ProcessOrder(ctx context.Context, orderNumber string) error
 }
 
@@ -25,4 +34,45 @@ type BalanceService interface {
 	GetBalance(ctx context.Context, userID int64) (float64, error)
 	Withdraw(ctx context.Context, userID int64, orderNumber string, amount float64) error
 	GetWithdrawals(ctx context.Context, userID int64) ([]*models.Withdrawal, error)
+	GetWithdrawalsPage(ctx context.Context, userID int64, filter models.WithdrawalFilter) ([]*models.Withdrawal, *models.Cursor, error)
+	ExportWithdrawals(ctx context.Context, userID int64, filter models.WithdrawalFilter, fn func(*models.Withdrawal) error) error
+}
+
+// AdminService определяет интерфейс для административных операций службы поддержки
+type AdminService interface {
+	SearchUsers(ctx context.Context, login string, limit int) ([]*models.User, error)
+	GetUser(ctx context.Context, userID int64) (*models.User, error)
+	SetUserRole(ctx context.Context, userID int64, role string) error
+	GetUserOrders(ctx context.Context, userID int64) ([]*models.Order, error)
+	GetOrder(ctx context.Context, number string) (*models.Order, error)
+	ReprocessOrder(ctx context.Context, number string) error
+	AdjustBalance(ctx context.Context, adminID, userID int64, amount float64, reason string) (*models.BalanceAdjustment, error)
+	GetBalanceAdjustments(ctx context.Context, userID int64) ([]*models.BalanceAdjustment, error)
+}
+
+// MerchantService определяет интерфейс для работы с магазинами и их ключами доступа
+type MerchantService interface {
+	CreateMerchant(ctx context.Context, name string) (*models.Merchant, error)
+	GetMerchants(ctx context.Context) ([]*models.Merchant, error)
+	GetAPIKeys(ctx context.Context, merchantID int64) ([]*models.APIKey, error)
+	IssueAPIKey(ctx context.Context, merchantID int64, scopes []string) (*models.APIKey, string, error)
+	RotateAPIKey(ctx context.Context, merchantID, keyID int64) (*models.APIKey, string, error)
+	RevokeAPIKey(ctx context.Context, merchantID, keyID int64) error
+	Authenticate(ctx context.Context, rawKey string) (*models.APIKey, error)
+	UploadOrder(ctx context.Context, merchantID int64, login, orderNumber string) error
+}
+
+// SessionService определяет интерфейс для работы с сессиями пользователей
+type SessionService interface {
+	CreateSession(ctx context.Context, userID int64, userAgent, ip string) (*models.Session, error)
+	ValidateSession(ctx context.Context, userID, sessionID int64) (*models.Session, error)
+	GetSessions(ctx context.Context, userID int64) ([]*models.Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID int64) error
+	RevokeOtherSessions(ctx context.Context, userID, currentSessionID int64) error
+}
+
+// PrivacyService определяет интерфейс для экспорта и удаления персональных данных
+type PrivacyService interface {
+	ExportData(ctx context.Context, userID int64) (*models.UserDataExport, error)
+	DeleteAccount(ctx context.Context, userID int64, password string) error
 }
@@ -0,0 +1,102 @@
+package session
+
+import (
+	"context"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/conf"
+	"github.com/gitslim/gophermart/internal/errs"
+	"github.com/gitslim/gophermart/internal/logging"
+	"github.com/gitslim/gophermart/internal/models"
+	"github.com/gitslim/gophermart/internal/service"
+	"github.com/gitslim/gophermart/internal/storage"
+)
+
+// touchInterval ограничивает частоту обновления времени последней активности
+const touchInterval = time.Minute
+
+// SessionServiceImpl реализует интерфейс service.SessionService
+type SessionServiceImpl struct {
+	sessionStorage storage.SessionStorage
+	ttl            time.Duration
+	log            logging.Logger
+}
+
+// NewSessionService создает новый экземпляр сервиса сессий
+func NewSessionService(config *conf.Config, sessionStorage storage.SessionStorage, log logging.Logger) service.SessionService {
+	return &SessionServiceImpl{
+		sessionStorage: sessionStorage,
+		ttl:            config.SessionTTL,
+		log:            log,
+	}
+}
+
+// CreateSession создает сессию для нового входа
+func (s *SessionServiceImpl) CreateSession(ctx context.Context, userID int64, userAgent, ip string) (*models.Session, error) {
+	now := time.Now()
+	session := &models.Session{
+		UserID:     userID,
+		UserAgent:  userAgent,
+		IP:         ip,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(s.ttl),
+	}
+
+	if err := s.sessionStorage.CreateSession(ctx, session); err != nil {
+		return nil, errs.NewAppError(errs.ErrInternal, "failed to create session")
+	}
+
+	return session, nil
+}
+
+// ValidateSession проверяет, что сессия принадлежит пользователю и активна
+func (s *SessionServiceImpl) ValidateSession(ctx context.Context, userID, sessionID int64) (*models.Session, error) {
+	session, err := s.sessionStorage.GetSessionByID(ctx, sessionID)
+	if err != nil {
+		return nil, errs.NewAppError(errs.ErrInternal, "failed to get session")
+	}
+
+	now := time.Now()
+	if session == nil || session.UserID != userID || !session.IsActive(now) {
+		return nil, errs.NewAppError(errs.ErrUnauthorized, "session revoked")
+	}
+
+	if now.Sub(session.LastSeenAt) > touchInterval {
+		if err := s.sessionStorage.TouchSession(ctx, session.ID, now); err != nil {
+			logging.FromContext(ctx, s.log).Errorf("Failed to update last activity of session %d: %v", session.ID, err)
+		}
+		session.LastSeenAt = now
+	}
+
+	return session, nil
+}
+
+// GetSessions возвращает активные сессии пользователя
+func (s *SessionServiceImpl) GetSessions(ctx context.Context, userID int64) ([]*models.Session, error) {
+	sessions, err := s.sessionStorage.GetUserSessions(ctx, userID, time.Now())
+	if err != nil {
+		return nil, errs.NewAppError(errs.ErrInternal, "failed to get sessions")
+	}
+	return sessions, nil
+}
+
+// RevokeSession завершает сессию пользователя
+func (s *SessionServiceImpl) RevokeSession(ctx context.Context, userID, sessionID int64) error {
+	revoked, err := s.sessionStorage.RevokeSession(ctx, userID, sessionID, time.Now())
+	if err != nil {
+		return errs.NewAppError(errs.ErrInternal, "failed to revoke session")
+	}
+	if !revoked {
+		return errs.NewAppError(errs.ErrNotFound, "session not found")
+	}
+	return nil
+}
+
+// RevokeOtherSessions завершает все сессии пользователя, кроме текущей
+func (s *SessionServiceImpl) RevokeOtherSessions(ctx context.Context, userID, currentSessionID int64) error {
+	if err := s.sessionStorage.RevokeOtherSessions(ctx, userID, currentSessionID, time.Now()); err != nil {
+		return errs.NewAppError(errs.ErrInternal, "failed to revoke sessions")
+	}
+	return nil
+}
@@ -5,38 +5,66 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/gitslim/gophermart/internal/conf"
 	"github.com/gitslim/gophermart/internal/errs"
+	"github.com/gitslim/gophermart/internal/logging"
 	"github.com/gitslim/gophermart/internal/models"
+	"github.com/gitslim/gophermart/internal/password"
+	"github.com/gitslim/gophermart/internal/ratelimit"
 	"github.com/gitslim/gophermart/internal/service"
 	"github.com/gitslim/gophermart/internal/storage"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/gitslim/gophermart/internal/totp"
 )
 
 // UserServiceImpl реализует интерфейс service.UserService
 type UserServiceImpl struct {
 	userStorage storage.UserStorage
+	hasher      password.Hasher
+	policy      *password.Policy
+	totpIssuer  string
+	totpCipher  *totp.Cipher
+	totpLimit   ratelimit.Limit
+	rateLimits  ratelimit.Store
+	log         logging.Logger
 }
 
 // NewUserService создает новый экземпляр сервиса пользователей
-func NewUserService(userStorage storage.UserStorage) service.UserService {
+func NewUserService(config *conf.Config, userStorage storage.UserStorage, hasher password.Hasher, policy *password.Policy, rateLimits ratelimit.Store, log logging.Logger) (service.UserService, error) {
+	totpCipher, err := totp.NewCipher(config.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+
 	return &UserServiceImpl{
 		userStorage: userStorage,
-	}
+		hasher:      hasher,
+		policy:      policy,
+		totpIssuer:  config.TOTPIssuer,
+		totpCipher:  totpCipher,
+		totpLimit:   config.RateLimitTOTP,
+		rateLimits:  rateLimits,
+		log:         log,
+	}, nil
 }
 
 // Register регистрирует нового пользователя
 func (s *UserServiceImpl) Register(ctx context.Context, login, password string) (*models.User, error) {
+	// Проверяем пароль на соответствие политике
+	if err := s.policy.Validate(password); err != nil {
+		return nil, err
+	}
+
 	// Проверяем, существует ли пользователь
 	existingUser, err := s.userStorage.GetUserByLogin(ctx, login)
 	if err != nil {
 		return nil, errs.NewAppError(errs.ErrInternal, "failed to get user")
 	}
 	if existingUser != nil {
-		return nil, errs.NewAppError(errs.ErrConflict, "user already exists")
+		return nil, errs.NewAppError(errs.ErrLoginTaken, "user already exists")
 	}
 
 	// Хешируем пароль
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hashedPassword, err := s.hasher.Hash(password)
 	if err != nil {
 		return nil, fmt.Errorf("failed to hash password: %w", err)
 	}
@@ -44,8 +72,9 @@ func (s *UserServiceImpl) Register(ctx context.Context, login, password string)
 	// Создаем пользователя
 	user := &models.User{
 		Login:        login,
-		PasswordHash: string(hashedPassword),
+		PasswordHash: hashedPassword,
 		Balance:      0,
+		Role:         models.RoleUser,
 		CreatedAt:    time.Now(),
 	}
 
@@ -66,8 +95,13 @@ func (s *UserServiceImpl) Login(ctx context.Context, login, password string) (*m
 		return nil, errs.NewAppError(errs.ErrNotFound, "user not found")
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		return nil, errs.NewAppError(errs.ErrUnauthorized, "invalid password")
+	if err := s.verifyPassword(user, password); err != nil {
+		return nil, err
+	}
+
+	// Пересчитываем хеш, созданный устаревшим алгоритмом или с устаревшими параметрами
+	if s.hasher.NeedsRehash(user.PasswordHash) {
+		s.upgradePasswordHash(ctx, user, password)
 	}
 
 	return user, nil
@@ -77,3 +111,69 @@ func (s *UserServiceImpl) Login(ctx context.Context, login, password string) (*m
 func (s *UserServiceImpl) GetUserByID(ctx context.Context, id int64) (*models.User, error) {
 	return s.userStorage.GetUserByID(ctx, id)
 }
+
+// ChangePassword меняет пароль пользователя и отзывает ранее выданные токены
+func (s *UserServiceImpl) ChangePassword(ctx context.Context, userID int64, oldPassword, newPassword string) (*models.User, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.verifyPassword(user, oldPassword); err != nil {
+		return nil, err
+	}
+
+	if oldPassword == newPassword {
+		return nil, errs.NewAppError(errs.ErrBadRequest, "new password must differ from the old one")
+	}
+
+	if err := s.policy.Validate(newPassword); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	tokenVersion, err := s.userStorage.ChangePassword(ctx, userID, hashedPassword)
+	if err != nil {
+		return nil, errs.NewAppError(errs.ErrInternal, "failed to change password")
+	}
+
+	user.PasswordHash = hashedPassword
+	user.TokenVersion = tokenVersion
+
+	return user, nil
+}
+
+// verifyPassword проверяет пароль пользователя
+func (s *UserServiceImpl) verifyPassword(user *models.User, password string) error {
+	ok, err := s.hasher.Verify(user.PasswordHash, password)
+	if err != nil {
+		s.log.Errorf("Failed to verify password of user %d: %v", user.ID, err)
+		return errs.NewAppError(errs.ErrInvalidCredentials, "invalid password")
+	}
+	if !ok {
+		return errs.NewAppError(errs.ErrInvalidCredentials, "invalid password")
+	}
+	return nil
+}
+
+// upgradePasswordHash пересчитывает хеш пароля текущим алгоритмом, ошибки не прерывают вход
+func (s *UserServiceImpl) upgradePasswordHash(ctx context.Context, user *models.User, password string) {
+	log := logging.FromContext(ctx, s.log)
+
+	hashedPassword, err := s.hasher.Hash(password)
+	if err != nil {
+		log.Errorf("Failed to rehash password of user %d: %v", user.ID, err)
+		return
+	}
+
+	if err := s.userStorage.UpdatePasswordHash(ctx, user.ID, hashedPassword); err != nil {
+		log.Errorf("Failed to upgrade password hash of user %d: %v", user.ID, err)
+		return
+	}
+
+	user.PasswordHash = hashedPassword
+}
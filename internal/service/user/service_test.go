@@ -0,0 +1,65 @@
+package user
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/gitslim/gophermart/internal/conf"
+	"github.com/gitslim/gophermart/internal/errs"
+	"github.com/gitslim/gophermart/internal/logging/sugared"
+	"github.com/gitslim/gophermart/internal/models"
+	"github.com/gitslim/gophermart/internal/password"
+	"github.com/gitslim/gophermart/internal/ratelimit"
+	"github.com/gitslim/gophermart/internal/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoginUpgradesPasswordHash(t *testing.T) {
+	ctx := context.Background()
+
+	log, err := sugared.NewLogger()
+	require.NoError(t, err)
+
+	legacy := password.NewBcryptHasher(4)
+	argon := password.NewArgon2idHasher(password.Argon2idParams{
+		Memory:      64,
+		Iterations:  1,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+	})
+
+	users := memory.NewMemUserStorage(memory.NewDB())
+	config := &conf.Config{SecretKey: "secret", PasswordMinLength: 1}
+	svc, err := NewUserService(config, users, password.NewChainHasher(argon, legacy), password.NewPolicy(config), ratelimit.NewMemoryStore(), log)
+	require.NoError(t, err)
+
+	bcryptHash, err := legacy.Hash("secret")
+	require.NoError(t, err)
+	user := &models.User{Login: "alice", PasswordHash: bcryptHash, Role: models.RoleUser}
+	require.NoError(t, users.CreateUser(ctx, user))
+
+	// Неверный пароль не меняет хеш
+	_, err = svc.Login(ctx, "alice", "wrong")
+	requireErrorType(t, err, errs.ErrInvalidCredentials)
+	stored, err := users.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, bcryptHash, stored.PasswordHash)
+
+	// Успешный вход пересчитывает bcrypt-хеш основным алгоритмом
+	logged, err := svc.Login(ctx, "alice", "secret")
+	require.NoError(t, err)
+	stored, err = users.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(stored.PasswordHash, "$argon2id$"), stored.PasswordHash)
+	assert.Equal(t, stored.PasswordHash, logged.PasswordHash)
+
+	// С новым хешем вход продолжает работать и хеш больше не меняется
+	_, err = svc.Login(ctx, "alice", "secret")
+	require.NoError(t, err)
+	again, err := users.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, stored.PasswordHash, again.PasswordHash)
+}
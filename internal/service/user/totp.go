@@ -0,0 +1,251 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/errs"
+	"github.com/gitslim/gophermart/internal/logging"
+	"github.com/gitslim/gophermart/internal/models"
+	"github.com/gitslim/gophermart/internal/ratelimit"
+	"github.com/gitslim/gophermart/internal/totp"
+)
+
+const recoveryCodesCount = 10
+
+// EnrollTOTP создает секрет TOTP, двухфакторная аутентификация включается после подтверждения кодом
+func (s *UserServiceImpl) EnrollTOTP(ctx context.Context, userID int64) (string, string, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return "", "", err
+	}
+	if user.TOTPEnabled {
+		return "", "", errs.NewAppError(errs.ErrConflict, "two-factor authentication already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", "", errs.NewAppError(errs.ErrInternal, "failed to generate totp secret")
+	}
+
+	sealed, err := s.totpCipher.Seal(secret, userID)
+	if err != nil {
+		return "", "", errs.NewAppError(errs.ErrInternal, "failed to encrypt totp secret")
+	}
+
+	if err := s.userStorage.SetTOTPSecret(ctx, userID, sealed); err != nil {
+		return "", "", errs.NewAppError(errs.ErrInternal, "failed to save totp secret")
+	}
+
+	return secret, totp.URI(s.totpIssuer, user.Login, secret), nil
+}
+
+// ConfirmTOTP включает двухфакторную аутентификацию и возвращает коды восстановления
+func (s *UserServiceImpl) ConfirmTOTP(ctx context.Context, userID int64, code string) ([]string, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, errs.NewAppError(errs.ErrConflict, "two-factor authentication already enabled")
+	}
+	if user.TOTPSecret == "" {
+		return nil, errs.NewAppError(errs.ErrBadRequest, "two-factor authentication enrolment not started")
+	}
+
+	if err := s.takeTOTPAttempt(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	secret, err := s.totpSecret(user)
+	if err != nil {
+		return nil, err
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now(), user.TOTPLastStep)
+	if !ok {
+		return nil, errs.NewAppError(errs.ErrUnauthorized, "invalid code")
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, errs.NewAppError(errs.ErrInternal, "failed to generate recovery codes")
+	}
+
+	// Коды восстановления сохраняются вместе с включением, иначе при сбое между записями
+	// пользователь остался бы без действующих кодов или с кодами, которые ему не показаны
+	enabled, err := s.userStorage.EnableTOTP(ctx, userID, step, hashes)
+	if err != nil {
+		return nil, errs.NewAppError(errs.ErrInternal, "failed to enable two-factor authentication")
+	}
+	if !enabled {
+		return nil, errs.NewAppError(errs.ErrConflict, "two-factor authentication already enabled")
+	}
+
+	return codes, nil
+}
+
+// DisableTOTP отключает двухфакторную аутентификацию после проверки пароля и второго фактора
+func (s *UserServiceImpl) DisableTOTP(ctx context.Context, userID int64, password, code string) error {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return errs.NewAppError(errs.ErrConflict, "two-factor authentication not enabled")
+	}
+
+	if err := s.verifyPassword(user, password); err != nil {
+		return err
+	}
+
+	if err := s.verifySecondFactor(ctx, user, code); err != nil {
+		return err
+	}
+
+	if err := s.userStorage.DisableTOTP(ctx, userID); err != nil {
+		return errs.NewAppError(errs.ErrInternal, "failed to disable two-factor authentication")
+	}
+
+	return nil
+}
+
+// VerifySecondFactor проверяет одноразовый код или код восстановления на втором шаге входа
+func (s *UserServiceImpl) VerifySecondFactor(ctx context.Context, userID int64, code string) (*models.User, error) {
+	user, err := s.getUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, errs.NewAppError(errs.ErrUnauthorized, "two-factor authentication not enabled")
+	}
+
+	if err := s.verifySecondFactor(ctx, user, code); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// getUser возвращает существующего пользователя
+func (s *UserServiceImpl) getUser(ctx context.Context, userID int64) (*models.User, error) {
+	user, err := s.userStorage.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, errs.NewAppError(errs.ErrInternal, "failed to get user")
+	}
+	if user == nil {
+		return nil, errs.NewAppError(errs.ErrUnauthorized, "user not found")
+	}
+	return user, nil
+}
+
+// takeTOTPAttempt расходует попытку ввода кода второго фактора из квоты пользователя.
+// Квота общая для всех клиентов пользователя, поэтому перебор кодов с разных IP также ограничен
+func (s *UserServiceImpl) takeTOTPAttempt(ctx context.Context, userID int64) error {
+	if !s.totpLimit.Enabled() {
+		return nil
+	}
+
+	log := logging.FromContext(ctx, s.log)
+
+	result, err := s.rateLimits.Take(ctx, ratelimit.Key(ratelimit.ClassTOTP, strconv.FormatInt(userID, 10)), s.totpLimit)
+	if err != nil {
+		// Недоступность хранилища квот не должна останавливать сервис
+		log.Errorf("Failed to check second factor attempts for user %d: %v", userID, err)
+		return nil
+	}
+	if !result.Allowed {
+		log.Warnf("Too many second factor attempts for user %d", userID)
+		return errs.NewAppError(errs.ErrTooManyRequests, "too many verification attempts, try again later")
+	}
+
+	return nil
+}
+
+// totpSecret расшифровывает секрет TOTP пользователя
+func (s *UserServiceImpl) totpSecret(user *models.User) (string, error) {
+	secret, err := s.totpCipher.Open(user.TOTPSecret, user.ID)
+	if err != nil {
+		return "", errs.NewAppError(errs.ErrInternal, "failed to decrypt totp secret")
+	}
+	return secret, nil
+}
+
+// verifySecondFactor проверяет код TOTP, а при несовпадении формата — код восстановления
+func (s *UserServiceImpl) verifySecondFactor(ctx context.Context, user *models.User, code string) error {
+	if err := s.takeTOTPAttempt(ctx, user.ID); err != nil {
+		return err
+	}
+
+	invalid := errs.NewAppError(errs.ErrUnauthorized, "invalid code")
+
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		secret, err := s.totpSecret(user)
+		if err != nil {
+			return err
+		}
+
+		step, ok := totp.Validate(secret, code, time.Now(), user.TOTPLastStep)
+		if !ok {
+			return invalid
+		}
+
+		// Условное обновление исключает повторное использование кода параллельными запросами
+		updated, err := s.userStorage.UpdateTOTPLastStep(ctx, user.ID, step)
+		if err != nil {
+			return errs.NewAppError(errs.ErrInternal, "failed to verify code")
+		}
+		if !updated {
+			return invalid
+		}
+		return nil
+	}
+
+	used, err := s.userStorage.UseRecoveryCode(ctx, user.ID, hashRecoveryCode(code), time.Now())
+	if err != nil {
+		return errs.NewAppError(errs.ErrInternal, "failed to verify code")
+	}
+	if !used {
+		return invalid
+	}
+
+	logging.FromContext(ctx, s.log).Infof("Recovery code used by user %d", user.ID)
+
+	return nil
+}
+
+// generateRecoveryCodes создает коды восстановления вида xxxx-xxxx и их хеши
+func generateRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+
+	codes := make([]string, 0, recoveryCodesCount)
+	hashes := make([]string, 0, recoveryCodesCount)
+	for i := 0; i < recoveryCodesCount; i++ {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		raw := strings.ToLower(encoding.EncodeToString(b))
+		code := raw[:4] + "-" + raw[4:]
+
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode хеширует код восстановления без учета регистра и дефисов
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
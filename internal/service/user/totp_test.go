@@ -0,0 +1,82 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/conf"
+	"github.com/gitslim/gophermart/internal/errs"
+	"github.com/gitslim/gophermart/internal/logging/sugared"
+	"github.com/gitslim/gophermart/internal/models"
+	"github.com/gitslim/gophermart/internal/password"
+	"github.com/gitslim/gophermart/internal/ratelimit"
+	"github.com/gitslim/gophermart/internal/storage/memory"
+	"github.com/gitslim/gophermart/internal/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requireErrorType проверяет тип ошибки приложения
+func requireErrorType(t *testing.T, err error, want *errs.ErrorType) {
+	t.Helper()
+
+	var appErr *errs.AppError
+	require.True(t, errors.As(err, &appErr), "unexpected error %v", err)
+	require.Equal(t, want, appErr.Type, appErr.Message)
+}
+
+func TestTOTP(t *testing.T) {
+	ctx := context.Background()
+
+	log, err := sugared.NewLogger()
+	require.NoError(t, err)
+
+	users := memory.NewMemUserStorage(memory.NewDB())
+	config := &conf.Config{
+		SecretKey:     "secret",
+		RateLimitTOTP: ratelimit.Limit{Requests: 3, Period: time.Hour},
+	}
+	svc, err := NewUserService(config, users, password.NewBcryptHasher(4), password.NewPolicy(config), ratelimit.NewMemoryStore(), log)
+	require.NoError(t, err)
+
+	user := &models.User{Login: "alice", PasswordHash: "hash", Role: models.RoleUser}
+	require.NoError(t, users.CreateUser(ctx, user))
+
+	secret, _, err := svc.EnrollTOTP(ctx, user.ID)
+	require.NoError(t, err)
+
+	// В хранилище секрет лежит только в зашифрованном виде
+	stored, err := users.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.NotEmpty(t, stored.TOTPSecret)
+	assert.NotContains(t, stored.TOTPSecret, secret)
+
+	// Подтверждение расшифровывает секрет и расходует попытку
+	code, err := totp.Code(secret, totp.Step(time.Now()))
+	require.NoError(t, err)
+	codes, err := svc.ConfirmTOTP(ctx, user.ID, code)
+	require.NoError(t, err)
+
+	// Коды восстановления сохранены вместе с включением
+	require.Len(t, codes, recoveryCodesCount)
+	stored, err = users.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.TOTPEnabled)
+	used, err := users.UseRecoveryCode(ctx, user.ID, hashRecoveryCode(codes[0]), time.Now())
+	require.NoError(t, err)
+	assert.True(t, used)
+
+	// Неверные коды расходуют оставшиеся попытки
+	for i := 0; i < 2; i++ {
+		_, err = svc.VerifySecondFactor(ctx, user.ID, "000000")
+		requireErrorType(t, err, errs.ErrUnauthorized)
+	}
+
+	// После исчерпания квоты отклоняется даже верный код, перебор не продолжается
+	code, err = totp.Code(secret, totp.Step(time.Now())+1)
+	require.NoError(t, err)
+	_, err = svc.VerifySecondFactor(ctx, user.ID, code)
+	requireErrorType(t, err, errs.ErrTooManyRequests)
+}
@@ -0,0 +1,64 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/gitslim/gophermart/internal/models"
+	"github.com/gitslim/gophermart/internal/storage"
+)
+
+// MemBalanceAdjustmentStorage представляет хранилище ручных корректировок баланса в памяти
+type MemBalanceAdjustmentStorage struct {
+	db *DB
+}
+
+// NewMemBalanceAdjustmentStorage создает новый экземпляр хранилища в памяти
+func NewMemBalanceAdjustmentStorage(db *DB) *MemBalanceAdjustmentStorage {
+	return &MemBalanceAdjustmentStorage{
+		db: db,
+	}
+}
+
+// CreateBalanceAdjustment изменяет баланс пользователя и сохраняет корректировку атомарно
+func (s *MemBalanceAdjustmentStorage) CreateBalanceAdjustment(_ context.Context, adjustment *models.BalanceAdjustment) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	user, ok := s.db.users[adjustment.UserID]
+	if !ok || user.Balance+adjustment.Amount < 0 {
+		return storage.ErrInsufficientFunds
+	}
+	if _, ok := s.db.users[adjustment.AdminID]; !ok {
+		return foreignKeyViolation("balance_adjustments_admin_id_fkey")
+	}
+
+	user.Balance += adjustment.Amount
+
+	a := *adjustment
+	a.ID = s.db.nextID("balance_adjustments")
+	s.db.adjustments = append(s.db.adjustments, &a)
+
+	adjustment.ID = a.ID
+	return nil
+}
+
+// GetUserBalanceAdjustments возвращает все корректировки баланса пользователя
+func (s *MemBalanceAdjustmentStorage) GetUserBalanceAdjustments(_ context.Context, userID int64) ([]*models.BalanceAdjustment, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	var adjustments []*models.BalanceAdjustment
+	for _, a := range s.db.adjustments {
+		if a.UserID == userID {
+			c := *a
+			adjustments = append(adjustments, &c)
+		}
+	}
+
+	sort.Slice(adjustments, func(i, j int) bool {
+		return newestFirst(adjustments[i].CreatedAt, adjustments[i].ID, adjustments[j].CreatedAt, adjustments[j].ID) < 0
+	})
+
+	return adjustments, nil
+}
@@ -0,0 +1,159 @@
+package memory
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/models"
+)
+
+// recoveryCode представляет код восстановления двухфакторной аутентификации
+type recoveryCode struct {
+	userID   int64
+	codeHash string
+	usedAt   *time.Time
+}
+
+// DB хранит все таблицы в памяти процесса под одной блокировкой, как одна база данных
+// разделяется хранилищами PostgreSQL. Данные теряются при остановке
+type DB struct {
+	mu sync.RWMutex
+
+	users         map[int64]*models.User
+	recoveryCodes []*recoveryCode
+	// Колонка users.last_pre_auth_at, которой нет в модели пользователя
+	lastPreAuthAt map[int64]int64
+	orders        map[int64]*models.Order
+	orderHistory  []*models.OrderStatusChange
+	withdrawals   []*models.Withdrawal
+	adjustments   []*models.BalanceAdjustment
+	merchants     map[int64]*models.Merchant
+	apiKeys       map[int64]*models.APIKey
+	sessions      map[int64]*models.Session
+
+	// Последние выданные ID по таблицам, аналог последовательностей BIGSERIAL
+	seq map[string]int64
+}
+
+// NewDB создает пустую базу данных в памяти
+func NewDB() *DB {
+	return &DB{
+		users:         make(map[int64]*models.User),
+		lastPreAuthAt: make(map[int64]int64),
+		orders:        make(map[int64]*models.Order),
+		merchants:     make(map[int64]*models.Merchant),
+		apiKeys:       make(map[int64]*models.APIKey),
+		sessions:      make(map[int64]*models.Session),
+		seq:           make(map[string]int64),
+	}
+}
+
+// nextID выдает следующий ID таблицы, вызывается под блокировкой на запись
+func (db *DB) nextID(table string) int64 {
+	db.seq[table]++
+	return db.seq[table]
+}
+
+// uniqueViolation возвращает ошибку нарушения уникальности, как ее описывает PostgreSQL
+func uniqueViolation(constraint string) error {
+	return fmt.Errorf("duplicate key value violates unique constraint %q", constraint)
+}
+
+// foreignKeyViolation возвращает ошибку нарушения внешнего ключа, как ее описывает PostgreSQL
+func foreignKeyViolation(constraint string) error {
+	return fmt.Errorf("insert or update violates foreign key constraint %q", constraint)
+}
+
+// userByLogin ищет пользователя по логину, вызывается под блокировкой
+func (db *DB) userByLogin(login string) *models.User {
+	for _, u := range db.users {
+		if u.Login == login {
+			return u
+		}
+	}
+	return nil
+}
+
+// orderByNumber ищет заказ по номеру, вызывается под блокировкой
+func (db *DB) orderByNumber(number string) *models.Order {
+	for _, o := range db.orders {
+		if o.Number == number {
+			return o
+		}
+	}
+	return nil
+}
+
+// cloneTime копирует необязательное время, чтобы вызывающий не мог изменить хранимое значение
+func cloneTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	v := *t
+	return &v
+}
+
+// inRange проверяет попадание времени в полуинтервал [from, to) фильтра
+func inRange(t time.Time, from, to *time.Time) bool {
+	if from != nil && t.Before(*from) {
+		return false
+	}
+	if to != nil && !t.Before(*to) {
+		return false
+	}
+	return true
+}
+
+// beforeCursor проверяет, что запись идет после курсора при сортировке по убыванию (at, id)
+func beforeCursor(at time.Time, id int64, cursor *models.Cursor) bool {
+	if cursor == nil {
+		return true
+	}
+	return at.Before(cursor.At) || at.Equal(cursor.At) && id < cursor.ID
+}
+
+// newestFirst сравнивает записи по убыванию (at, id)
+func newestFirst(aAt time.Time, aID int64, bAt time.Time, bID int64) int {
+	if c := bAt.Compare(aAt); c != 0 {
+		return c
+	}
+	switch {
+	case aID > bID:
+		return -1
+	case aID < bID:
+		return 1
+	}
+	return 0
+}
+
+// likePattern преобразует шаблон ILIKE с экранированием обратной косой чертой в регулярное выражение
+func likePattern(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString(`(?is)^`)
+
+	escaped := false
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '%':
+			b.WriteString(`.*`)
+		case r == '_':
+			b.WriteString(`.`)
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	if escaped {
+		return nil, fmt.Errorf("LIKE pattern must not end with escape character")
+	}
+
+	b.WriteString(`$`)
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,21 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/gitslim/gophermart/internal/storage/storagetest"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Storages {
+		db := NewDB()
+		return storagetest.Storages{
+			Users:              NewMemUserStorage(db),
+			Orders:             NewMemOrderStorage(db),
+			Withdrawals:        NewMemWithdrawalStorage(db),
+			BalanceAdjustments: NewMemBalanceAdjustmentStorage(db),
+			Merchants:          NewMemMerchantStorage(db),
+			Sessions:           NewMemSessionStorage(db),
+		}
+	})
+}
@@ -0,0 +1,194 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/models"
+)
+
+// MemMerchantStorage представляет хранилище магазинов и ключей доступа в памяти
+type MemMerchantStorage struct {
+	db *DB
+}
+
+// NewMemMerchantStorage создает новый экземпляр хранилища в памяти
+func NewMemMerchantStorage(db *DB) *MemMerchantStorage {
+	return &MemMerchantStorage{
+		db: db,
+	}
+}
+
+// CreateMerchant создает новый магазин
+func (s *MemMerchantStorage) CreateMerchant(_ context.Context, merchant *models.Merchant) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for _, m := range s.db.merchants {
+		if m.Name == merchant.Name {
+			return uniqueViolation("merchants_name_key")
+		}
+	}
+
+	m := *merchant
+	m.ID = s.db.nextID("merchants")
+	s.db.merchants[m.ID] = &m
+
+	merchant.ID = m.ID
+	return nil
+}
+
+// GetMerchantByID возвращает магазин по ID
+func (s *MemMerchantStorage) GetMerchantByID(_ context.Context, id int64) (*models.Merchant, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	m, ok := s.db.merchants[id]
+	if !ok {
+		return nil, nil
+	}
+	c := *m
+	return &c, nil
+}
+
+// GetMerchantByName возвращает магазин по названию
+func (s *MemMerchantStorage) GetMerchantByName(_ context.Context, name string) (*models.Merchant, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	for _, m := range s.db.merchants {
+		if m.Name == name {
+			c := *m
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetMerchants возвращает все магазины
+func (s *MemMerchantStorage) GetMerchants(_ context.Context) ([]*models.Merchant, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	var merchants []*models.Merchant
+	for _, m := range s.db.merchants {
+		c := *m
+		merchants = append(merchants, &c)
+	}
+
+	sort.Slice(merchants, func(i, j int) bool {
+		return merchants[i].Name < merchants[j].Name
+	})
+
+	return merchants, nil
+}
+
+// CreateAPIKey сохраняет новый ключ доступа
+func (s *MemMerchantStorage) CreateAPIKey(_ context.Context, key *models.APIKey) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.merchants[key.MerchantID]; !ok {
+		return foreignKeyViolation("merchant_api_keys_merchant_id_fkey")
+	}
+	for _, k := range s.db.apiKeys {
+		if k.Prefix == key.Prefix {
+			return uniqueViolation("merchant_api_keys_prefix_key")
+		}
+	}
+
+	k := cloneAPIKey(key)
+	k.ID = s.db.nextID("merchant_api_keys")
+	k.RevokedAt = nil
+	k.LastUsedAt = nil
+	s.db.apiKeys[k.ID] = k
+
+	key.ID = k.ID
+	return nil
+}
+
+// GetAPIKeyByID возвращает ключ доступа по ID
+func (s *MemMerchantStorage) GetAPIKeyByID(_ context.Context, id int64) (*models.APIKey, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return cloneAPIKey(s.db.apiKeys[id]), nil
+}
+
+// GetAPIKeyByPrefix возвращает ключ доступа по префиксу
+func (s *MemMerchantStorage) GetAPIKeyByPrefix(_ context.Context, prefix string) (*models.APIKey, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	for _, k := range s.db.apiKeys {
+		if k.Prefix == prefix {
+			return cloneAPIKey(k), nil
+		}
+	}
+	return nil, nil
+}
+
+// GetMerchantAPIKeys возвращает все ключи доступа магазина
+func (s *MemMerchantStorage) GetMerchantAPIKeys(_ context.Context, merchantID int64) ([]*models.APIKey, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	var keys []*models.APIKey
+	for _, k := range s.db.apiKeys {
+		if k.MerchantID == merchantID {
+			keys = append(keys, cloneAPIKey(k))
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return newestFirst(keys[i].CreatedAt, keys[i].ID, keys[j].CreatedAt, keys[j].ID) < 0
+	})
+
+	return keys, nil
+}
+
+// ExpireAPIKey ограничивает срок действия ключа, не продлевая уже истекающий
+func (s *MemMerchantStorage) ExpireAPIKey(_ context.Context, id int64, expiresAt time.Time) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if k, ok := s.db.apiKeys[id]; ok && (k.ExpiresAt == nil || k.ExpiresAt.After(expiresAt)) {
+		k.ExpiresAt = cloneTime(&expiresAt)
+	}
+	return nil
+}
+
+// RevokeAPIKey немедленно отзывает ключ
+func (s *MemMerchantStorage) RevokeAPIKey(_ context.Context, id int64, revokedAt time.Time) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if k, ok := s.db.apiKeys[id]; ok && k.RevokedAt == nil {
+		k.RevokedAt = cloneTime(&revokedAt)
+	}
+	return nil
+}
+
+// TouchAPIKey обновляет время последнего использования ключа
+func (s *MemMerchantStorage) TouchAPIKey(_ context.Context, id int64, usedAt time.Time) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if k, ok := s.db.apiKeys[id]; ok {
+		k.LastUsedAt = cloneTime(&usedAt)
+	}
+	return nil
+}
+
+// cloneAPIKey копирует ключ доступа, чтобы вызывающий не мог изменить хранимую запись
+func cloneAPIKey(k *models.APIKey) *models.APIKey {
+	if k == nil {
+		return nil
+	}
+	c := *k
+	c.ExpiresAt = cloneTime(k.ExpiresAt)
+	c.RevokedAt = cloneTime(k.RevokedAt)
+	c.LastUsedAt = cloneTime(k.LastUsedAt)
+	return &c
+}
@@ -0,0 +1,267 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/models"
+)
+
+// MemOrderStorage представляет хранилище заказов в памяти
+type MemOrderStorage struct {
+	db *DB
+}
+
+// NewMemOrderStorage создает новый экземпляр хранилища в памяти
+func NewMemOrderStorage(db *DB) *MemOrderStorage {
+	return &MemOrderStorage{
+		db: db,
+	}
+}
+
+// CreateOrder создает новый заказ
+func (s *MemOrderStorage) CreateOrder(_ context.Context, order *models.Order) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if s.db.orderByNumber(order.Number) != nil {
+		return uniqueViolation("orders_number_key")
+	}
+	if _, ok := s.db.users[order.UserID]; !ok {
+		return foreignKeyViolation("orders_user_id_fkey")
+	}
+
+	o := *order
+	o.ID = s.db.nextID("orders")
+	s.db.orders[o.ID] = &o
+
+	return nil
+}
+
+// CreateOrders создает новые заказы пакетом и возвращает результат по каждому номеру
+func (s *MemOrderStorage) CreateOrders(_ context.Context, userID int64, numbers []string, uploadedAt time.Time) ([]*models.OrderUploadResult, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[userID]; !ok {
+		return nil, foreignKeyViolation("orders_user_id_fkey")
+	}
+
+	// Повторы номера внутри пакета дают один результат, как SELECT DISTINCT
+	seen := make(map[string]bool, len(numbers))
+	var results []*models.OrderUploadResult
+	for _, number := range numbers {
+		if seen[number] {
+			continue
+		}
+		seen[number] = true
+
+		result := &models.OrderUploadResult{Number: number}
+		switch existing := s.db.orderByNumber(number); {
+		case existing == nil:
+			id := s.db.nextID("orders")
+			s.db.orders[id] = &models.Order{
+				ID:         id,
+				Number:     number,
+				UserID:     userID,
+				Status:     models.OrderStatusNew,
+				UploadedAt: uploadedAt,
+			}
+			result.Result = models.OrderUploadAccepted
+		case existing.UserID == userID:
+			result.Result = models.OrderUploadAlreadyUploaded
+		default:
+			result.Result = models.OrderUploadConflict
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// GetOrderByNumber возвращает заказ по номеру
+func (s *MemOrderStorage) GetOrderByNumber(_ context.Context, number string) (*models.Order, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return cloneOrder(s.db.orderByNumber(number)), nil
+}
+
+// GetUserOrders возвращает все заказы пользователя
+func (s *MemOrderStorage) GetUserOrders(_ context.Context, userID int64) ([]*models.Order, error) {
+	return s.selectOrders(func(o *models.Order) bool {
+		return o.UserID == userID
+	}, 0), nil
+}
+
+// GetUserOrdersPage возвращает страницу заказов пользователя с учетом фильтров
+func (s *MemOrderStorage) GetUserOrdersPage(_ context.Context, userID int64, filter models.OrderFilter) ([]*models.Order, error) {
+	return s.selectOrders(func(o *models.Order) bool {
+		return matchOrder(o, userID, filter) && beforeCursor(o.UploadedAt, o.ID, filter.After)
+	}, filter.Limit), nil
+}
+
+// ExportUserOrders передает в fn заказы пользователя с учетом фильтров.
+// Позиция и размер страницы в фильтре не учитываются
+func (s *MemOrderStorage) ExportUserOrders(_ context.Context, userID int64, filter models.OrderFilter, fn func(*models.Order) error) error {
+	// Выборка копируется до вызова fn, чтобы не держать блокировку во время записи ответа
+	orders := s.selectOrders(func(o *models.Order) bool {
+		return matchOrder(o, userID, filter)
+	}, 0)
+
+	for _, order := range orders {
+		if err := fn(order); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UpdateOrderStatus обновляет статус заказа
+func (s *MemOrderStorage) UpdateOrderStatus(_ context.Context, orderID int64, status string, accrual float64) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if o, ok := s.db.orders[orderID]; ok {
+		o.Status = status
+		o.Accrual = accrual
+		o.ProcessedAt = time.Now()
+	}
+	return nil
+}
+
+// GetOrdersByStatuses возвращает заказы с указанными статусами
+func (s *MemOrderStorage) GetOrdersByStatuses(_ context.Context, statuses []string) ([]*models.Order, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	var orders []*models.Order
+	for _, o := range s.db.orders {
+		if slices.Contains(statuses, o.Status) {
+			orders = append(orders, cloneOrder(o))
+		}
+	}
+
+	sort.Slice(orders, func(i, j int) bool {
+		if !orders[i].UploadedAt.Equal(orders[j].UploadedAt) {
+			return orders[i].UploadedAt.Before(orders[j].UploadedAt)
+		}
+		return orders[i].ID < orders[j].ID
+	})
+
+	return orders, nil
+}
+
+// CreateOrderStatusChange сохраняет результат проверки заказа в истории обработки
+func (s *MemOrderStorage) CreateOrderStatusChange(_ context.Context, change *models.OrderStatusChange) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.orders[change.OrderID]; !ok {
+		return foreignKeyViolation("order_status_history_order_id_fkey")
+	}
+
+	c := *change
+	c.ID = s.db.nextID("order_status_history")
+	s.db.orderHistory = append(s.db.orderHistory, &c)
+
+	change.ID = c.ID
+	return nil
+}
+
+// ApplyOrderStatusChange атомарно обновляет статус заказа, зачисляет change.Credited на баланс владельца
+// и сохраняет запись в истории, если проверка на что-то повлияла. Возвращает false, если статус заказа уже отличается от change.PreviousStatus
+func (s *MemOrderStorage) ApplyOrderStatusChange(_ context.Context, change *models.OrderStatusChange) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	o, ok := s.db.orders[change.OrderID]
+	if !ok || o.Status != change.PreviousStatus {
+		return false, nil
+	}
+
+	o.Status = change.Status
+	o.Accrual = change.Accrual
+	o.ProcessedAt = time.Now()
+
+	if u, ok := s.db.users[o.UserID]; ok && change.Credited > 0 {
+		u.Balance += change.Credited
+	}
+
+	if change.HasEffect() {
+		c := *change
+		c.ID = s.db.nextID("order_status_history")
+		s.db.orderHistory = append(s.db.orderHistory, &c)
+		change.ID = c.ID
+	}
+
+	return true, nil
+}
+
+// GetOrderStatusHistory возвращает историю обработки заказа в хронологическом порядке
+func (s *MemOrderStorage) GetOrderStatusHistory(_ context.Context, orderID int64) ([]*models.OrderStatusChange, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	var history []*models.OrderStatusChange
+	for _, c := range s.db.orderHistory {
+		if c.OrderID == orderID {
+			v := *c
+			history = append(history, &v)
+		}
+	}
+
+	sort.Slice(history, func(i, j int) bool {
+		if !history[i].CheckedAt.Equal(history[j].CheckedAt) {
+			return history[i].CheckedAt.Before(history[j].CheckedAt)
+		}
+		return history[i].ID < history[j].ID
+	})
+
+	return history, nil
+}
+
+// selectOrders возвращает копии подходящих заказов от новых к старым, limit 0 снимает ограничение
+func (s *MemOrderStorage) selectOrders(match func(*models.Order) bool, limit int) []*models.Order {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	var orders []*models.Order
+	for _, o := range s.db.orders {
+		if match(o) {
+			orders = append(orders, cloneOrder(o))
+		}
+	}
+
+	sort.Slice(orders, func(i, j int) bool {
+		return newestFirst(orders[i].UploadedAt, orders[i].ID, orders[j].UploadedAt, orders[j].ID) < 0
+	})
+	if limit > 0 && len(orders) > limit {
+		orders = orders[:limit]
+	}
+
+	return orders
+}
+
+// matchOrder проверяет заказ на соответствие пользователю и фильтрам списка
+func matchOrder(o *models.Order, userID int64, filter models.OrderFilter) bool {
+	if o.UserID != userID {
+		return false
+	}
+	if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, o.Status) {
+		return false
+	}
+	return inRange(o.UploadedAt, filter.From, filter.To)
+}
+
+// cloneOrder копирует заказ, чтобы вызывающий не мог изменить хранимую запись
+func cloneOrder(o *models.Order) *models.Order {
+	if o == nil {
+		return nil
+	}
+	c := *o
+	return &c
+}
@@ -0,0 +1,114 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/models"
+)
+
+// MemSessionStorage представляет хранилище сессий в памяти
+type MemSessionStorage struct {
+	db *DB
+}
+
+// NewMemSessionStorage создает новый экземпляр хранилища в памяти
+func NewMemSessionStorage(db *DB) *MemSessionStorage {
+	return &MemSessionStorage{
+		db: db,
+	}
+}
+
+// CreateSession создает новую сессию
+func (s *MemSessionStorage) CreateSession(_ context.Context, session *models.Session) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[session.UserID]; !ok {
+		return foreignKeyViolation("sessions_user_id_fkey")
+	}
+
+	c := cloneSession(session)
+	c.ID = s.db.nextID("sessions")
+	c.RevokedAt = nil
+	s.db.sessions[c.ID] = c
+
+	session.ID = c.ID
+	return nil
+}
+
+// GetSessionByID возвращает сессию по ID
+func (s *MemSessionStorage) GetSessionByID(_ context.Context, id int64) (*models.Session, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return cloneSession(s.db.sessions[id]), nil
+}
+
+// GetUserSessions возвращает активные сессии пользователя
+func (s *MemSessionStorage) GetUserSessions(_ context.Context, userID int64, now time.Time) ([]*models.Session, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	var sessions []*models.Session
+	for _, session := range s.db.sessions {
+		if session.UserID == userID && session.IsActive(now) {
+			sessions = append(sessions, cloneSession(session))
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return newestFirst(sessions[i].LastSeenAt, sessions[i].ID, sessions[j].LastSeenAt, sessions[j].ID) < 0
+	})
+
+	return sessions, nil
+}
+
+// TouchSession обновляет время последней активности сессии
+func (s *MemSessionStorage) TouchSession(_ context.Context, id int64, lastSeenAt time.Time) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if session, ok := s.db.sessions[id]; ok {
+		session.LastSeenAt = lastSeenAt
+	}
+	return nil
+}
+
+// RevokeSession отзывает сессию пользователя, возвращает false, если активная сессия не найдена
+func (s *MemSessionStorage) RevokeSession(_ context.Context, userID, id int64, revokedAt time.Time) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	session, ok := s.db.sessions[id]
+	if !ok || session.UserID != userID || session.RevokedAt != nil {
+		return false, nil
+	}
+
+	session.RevokedAt = cloneTime(&revokedAt)
+	return true, nil
+}
+
+// RevokeOtherSessions отзывает все сессии пользователя, кроме указанной
+func (s *MemSessionStorage) RevokeOtherSessions(_ context.Context, userID, exceptID int64, revokedAt time.Time) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	for _, session := range s.db.sessions {
+		if session.UserID == userID && session.ID != exceptID && session.RevokedAt == nil {
+			session.RevokedAt = cloneTime(&revokedAt)
+		}
+	}
+	return nil
+}
+
+// cloneSession копирует сессию, чтобы вызывающий не мог изменить хранимую запись
+func cloneSession(s *models.Session) *models.Session {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	c.RevokedAt = cloneTime(s.RevokedAt)
+	return &c
+}
@@ -0,0 +1,298 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/models"
+)
+
+// MemUserStorage представляет хранилище пользователей в памяти
+type MemUserStorage struct {
+	db *DB
+}
+
+// NewMemUserStorage создает новый экземпляр хранилища в памяти
+func NewMemUserStorage(db *DB) *MemUserStorage {
+	return &MemUserStorage{
+		db: db,
+	}
+}
+
+// CreateUser создает нового пользователя
+func (s *MemUserStorage) CreateUser(_ context.Context, user *models.User) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if s.db.userByLogin(user.Login) != nil {
+		return uniqueViolation("users_login_key")
+	}
+
+	u := *user
+	u.ID = s.db.nextID("users")
+	u.TokenVersion = 0
+	u.TOTPSecret = ""
+	u.TOTPEnabled = false
+	u.TOTPLastStep = 0
+	u.DeletedAt = nil
+	s.db.users[u.ID] = &u
+
+	user.ID = u.ID
+	return nil
+}
+
+// GetUserByLogin возвращает пользователя по логину
+func (s *MemUserStorage) GetUserByLogin(_ context.Context, login string) (*models.User, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return cloneUser(s.db.userByLogin(login)), nil
+}
+
+// GetUserByID возвращает пользователя по ID
+func (s *MemUserStorage) GetUserByID(_ context.Context, id int64) (*models.User, error) {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	return cloneUser(s.db.users[id]), nil
+}
+
+// UpdateBalance обновляет баланс пользователя
+func (s *MemUserStorage) UpdateBalance(_ context.Context, userID int64, delta float64) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if u, ok := s.db.users[userID]; ok {
+		u.Balance += delta
+	}
+	return nil
+}
+
+// UpdatePasswordHash заменяет хеш пароля без отзыва выданных токенов
+func (s *MemUserStorage) UpdatePasswordHash(_ context.Context, userID int64, passwordHash string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if u, ok := s.db.users[userID]; ok {
+		u.PasswordHash = passwordHash
+	}
+	return nil
+}
+
+// ChangePassword заменяет хеш пароля и увеличивает версию токенов, возвращая новую версию
+func (s *MemUserStorage) ChangePassword(_ context.Context, userID int64, passwordHash string) (int64, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	u, ok := s.db.users[userID]
+	if !ok {
+		return 0, fmt.Errorf("failed to change password: user %d not found", userID)
+	}
+
+	u.PasswordHash = passwordHash
+	u.TokenVersion++
+
+	return u.TokenVersion, nil
+}
+
+// SearchUsers возвращает пользователей, логин которых соответствует шаблону ILIKE
+func (s *MemUserStorage) SearchUsers(_ context.Context, loginPattern string, limit int) ([]*models.User, error) {
+	re, err := likePattern(loginPattern)
+	if err != nil {
+		return nil, err
+	}
+
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	var users []*models.User
+	for _, u := range s.db.users {
+		if re.MatchString(u.Login) {
+			users = append(users, cloneUser(u))
+		}
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].Login < users[j].Login
+	})
+	if len(users) > limit {
+		users = users[:limit]
+	}
+
+	return users, nil
+}
+
+// UpdateUserRole изменяет роль пользователя
+func (s *MemUserStorage) UpdateUserRole(_ context.Context, userID int64, role string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if u, ok := s.db.users[userID]; ok {
+		u.Role = role
+	}
+	return nil
+}
+
+// SetTOTPSecret сохраняет секрет TOTP, ожидающий подтверждения
+func (s *MemUserStorage) SetTOTPSecret(_ context.Context, userID int64, secret string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if u, ok := s.db.users[userID]; ok && !u.TOTPEnabled {
+		u.TOTPSecret = secret
+	}
+	return nil
+}
+
+// EnableTOTP включает двухфакторную аутентификацию и заменяет коды восстановления.
+// Возвращает false, если секрет не задан или аутентификация уже включена
+func (s *MemUserStorage) EnableTOTP(_ context.Context, userID int64, lastStep int64, codeHashes []string) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	u, ok := s.db.users[userID]
+	if !ok || u.TOTPSecret == "" || u.TOTPEnabled {
+		return false, nil
+	}
+
+	u.TOTPEnabled = true
+	u.TOTPLastStep = lastStep
+
+	s.db.deleteRecoveryCodes(userID)
+	for _, hash := range codeHashes {
+		s.db.recoveryCodes = append(s.db.recoveryCodes, &recoveryCode{userID: userID, codeHash: hash})
+	}
+	return true, nil
+}
+
+// DisableTOTP отключает двухфакторную аутентификацию и удаляет коды восстановления
+func (s *MemUserStorage) DisableTOTP(_ context.Context, userID int64) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	s.db.deleteRecoveryCodes(userID)
+
+	if u, ok := s.db.users[userID]; ok {
+		u.TOTPSecret = ""
+		u.TOTPEnabled = false
+		u.TOTPLastStep = 0
+	}
+	return nil
+}
+
+// UpdateTOTPLastStep запоминает использованный период TOTP, возвращает false, если период уже использован
+func (s *MemUserStorage) UpdateTOTPLastStep(_ context.Context, userID int64, step int64) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	u, ok := s.db.users[userID]
+	if !ok || u.TOTPLastStep >= step {
+		return false, nil
+	}
+
+	u.TOTPLastStep = step
+	return true, nil
+}
+
+// ReplaceRecoveryCodes заменяет коды восстановления пользователя
+func (s *MemUserStorage) ReplaceRecoveryCodes(_ context.Context, userID int64, codeHashes []string) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[userID]; !ok && len(codeHashes) > 0 {
+		return foreignKeyViolation("recovery_codes_user_id_fkey")
+	}
+
+	s.db.deleteRecoveryCodes(userID)
+	for _, hash := range codeHashes {
+		s.db.recoveryCodes = append(s.db.recoveryCodes, &recoveryCode{userID: userID, codeHash: hash})
+	}
+	return nil
+}
+
+// UseRecoveryCode погашает код восстановления, возвращает false, если код не найден или уже использован
+func (s *MemUserStorage) UseRecoveryCode(_ context.Context, userID int64, codeHash string, usedAt time.Time) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	used := false
+	for _, c := range s.db.recoveryCodes {
+		if c.userID == userID && c.codeHash == codeHash && c.usedAt == nil {
+			c.usedAt = cloneTime(&usedAt)
+			used = true
+		}
+	}
+	return used, nil
+}
+
+// ConsumePreAuthToken погашает токен первого шага входа, выпущенный в issuedAt.
+// Возвращает false, если уже обменян этот или более поздний токен
+func (s *MemUserStorage) ConsumePreAuthToken(_ context.Context, userID int64, issuedAt int64) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[userID]; !ok || s.db.lastPreAuthAt[userID] >= issuedAt {
+		return false, nil
+	}
+
+	s.db.lastPreAuthAt[userID] = issuedAt
+	return true, nil
+}
+
+// AnonymizeUser обезличивает пользователя, отзывает сессии и учетные данные.
+// Возвращает false, если пользователь не найден или уже удален
+func (s *MemUserStorage) AnonymizeUser(_ context.Context, userID int64, login string, deletedAt time.Time) (bool, error) {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	u, ok := s.db.users[userID]
+	active := ok && u.DeletedAt == nil
+	if other := s.db.userByLogin(login); active && other != nil && other.ID != userID {
+		return false, uniqueViolation("users_login_key")
+	}
+
+	// Сессии и коды восстановления обрабатываются независимо от результата обновления пользователя
+	for _, session := range s.db.sessions {
+		if session.UserID == userID && session.RevokedAt == nil {
+			session.RevokedAt = cloneTime(&deletedAt)
+		}
+	}
+	s.db.deleteRecoveryCodes(userID)
+
+	if !active {
+		return false, nil
+	}
+
+	u.Login = login
+	u.PasswordHash = ""
+	u.TOTPSecret = ""
+	u.TOTPEnabled = false
+	u.TOTPLastStep = 0
+	u.TokenVersion++
+	u.DeletedAt = cloneTime(&deletedAt)
+
+	return true, nil
+}
+
+// deleteRecoveryCodes удаляет коды восстановления пользователя, вызывается под блокировкой на запись
+func (db *DB) deleteRecoveryCodes(userID int64) {
+	codes := db.recoveryCodes[:0]
+	for _, c := range db.recoveryCodes {
+		if c.userID != userID {
+			codes = append(codes, c)
+		}
+	}
+	db.recoveryCodes = codes
+}
+
+// cloneUser копирует пользователя, чтобы вызывающий не мог изменить хранимую запись
+func cloneUser(u *models.User) *models.User {
+	if u == nil {
+		return nil
+	}
+	c := *u
+	c.DeletedAt = cloneTime(u.DeletedAt)
+	return &c
+}
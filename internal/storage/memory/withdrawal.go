@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/gitslim/gophermart/internal/models"
+	"github.com/gitslim/gophermart/internal/storage"
+)
+
+// MemWithdrawalStorage представляет хранилище операций списания в памяти
+type MemWithdrawalStorage struct {
+	db *DB
+}
+
+// NewMemWithdrawalStorage создает новый экземпляр хранилища в памяти
+func NewMemWithdrawalStorage(db *DB) *MemWithdrawalStorage {
+	return &MemWithdrawalStorage{
+		db: db,
+	}
+}
+
+// CreateWithdrawal создает новую операцию списания
+func (s *MemWithdrawalStorage) CreateWithdrawal(_ context.Context, withdrawal *models.Withdrawal) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	if _, ok := s.db.users[withdrawal.UserID]; !ok {
+		return foreignKeyViolation("withdrawals_user_id_fkey")
+	}
+
+	w := *withdrawal
+	w.ID = s.db.nextID("withdrawals")
+	s.db.withdrawals = append(s.db.withdrawals, &w)
+
+	return nil
+}
+
+// Withdraw атомарно списывает сумму с баланса пользователя и сохраняет операцию списания.
+// Возвращает storage.ErrInsufficientFunds, если баланса недостаточно
+func (s *MemWithdrawalStorage) Withdraw(_ context.Context, withdrawal *models.Withdrawal) error {
+	s.db.mu.Lock()
+	defer s.db.mu.Unlock()
+
+	user, ok := s.db.users[withdrawal.UserID]
+	if !ok || user.Balance < withdrawal.Sum {
+		return storage.ErrInsufficientFunds
+	}
+
+	user.Balance -= withdrawal.Sum
+
+	w := *withdrawal
+	w.ID = s.db.nextID("withdrawals")
+	s.db.withdrawals = append(s.db.withdrawals, &w)
+
+	withdrawal.ID = w.ID
+	return nil
+}
+
+// GetUserWithdrawals возвращает все операции списания пользователя
+func (s *MemWithdrawalStorage) GetUserWithdrawals(_ context.Context, userID int64) ([]*models.Withdrawal, error) {
+	return s.selectWithdrawals(func(w *models.Withdrawal) bool {
+		return w.UserID == userID
+	}, 0), nil
+}
+
+// GetUserWithdrawalsPage возвращает страницу списаний пользователя с учетом фильтров
+func (s *MemWithdrawalStorage) GetUserWithdrawalsPage(_ context.Context, userID int64, filter models.WithdrawalFilter) ([]*models.Withdrawal, error) {
+	return s.selectWithdrawals(func(w *models.Withdrawal) bool {
+		return w.UserID == userID && inRange(w.ProcessedAt, filter.From, filter.To) && beforeCursor(w.ProcessedAt, w.ID, filter.After)
+	}, filter.Limit), nil
+}
+
+// ExportUserWithdrawals передает в fn списания пользователя с учетом фильтров.
+// Позиция и размер страницы в фильтре не учитываются
+func (s *MemWithdrawalStorage) ExportUserWithdrawals(_ context.Context, userID int64, filter models.WithdrawalFilter, fn func(*models.Withdrawal) error) error {
+	withdrawals := s.selectWithdrawals(func(w *models.Withdrawal) bool {
+		return w.UserID == userID && inRange(w.ProcessedAt, filter.From, filter.To)
+	}, 0)
+
+	for _, withdrawal := range withdrawals {
+		if err := fn(withdrawal); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// selectWithdrawals возвращает копии подходящих списаний от новых к старым, limit 0 снимает ограничение
+func (s *MemWithdrawalStorage) selectWithdrawals(match func(*models.Withdrawal) bool, limit int) []*models.Withdrawal {
+	s.db.mu.RLock()
+	defer s.db.mu.RUnlock()
+
+	var withdrawals []*models.Withdrawal
+	for _, w := range s.db.withdrawals {
+		if match(w) {
+			c := *w
+			withdrawals = append(withdrawals, &c)
+		}
+	}
+
+	sort.Slice(withdrawals, func(i, j int) bool {
+		return newestFirst(withdrawals[i].ProcessedAt, withdrawals[i].ID, withdrawals[j].ProcessedAt, withdrawals[j].ID) < 0
+	})
+	if limit > 0 && len(withdrawals) > limit {
+		withdrawals = withdrawals[:limit]
+	}
+
+	return withdrawals
+}
@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gitslim/gophermart/internal/models"
+	"github.com/gitslim/gophermart/internal/storage"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	CreateBalanceAdjustmentQuery   string
+	GetUserBalanceAdjustmentsQuery string
+)
+
+func init() {
+	queries := map[string]*string{
+		"create_balance_adjustment.sql":    &CreateBalanceAdjustmentQuery,
+		"get_user_balance_adjustments.sql": &GetUserBalanceAdjustmentsQuery,
+	}
+
+	loadQueries(queries)
+}
+
+// PgBalanceAdjustmentStorage представляет хранилище ручных корректировок баланса
+type PgBalanceAdjustmentStorage struct {
+	db *pgxpool.Pool
+}
+
+// NewPgBalanceAdjustmentStorage создает новый экземпляр хранилища PostgreSQL
+func NewPgBalanceAdjustmentStorage(db *pgxpool.Pool) *PgBalanceAdjustmentStorage {
+	return &PgBalanceAdjustmentStorage{
+		db: db,
+	}
+}
+
+// CreateBalanceAdjustment изменяет баланс пользователя и сохраняет корректировку одним запросом
+func (s *PgBalanceAdjustmentStorage) CreateBalanceAdjustment(ctx context.Context, adjustment *models.BalanceAdjustment) error {
+	err := s.db.QueryRow(ctx, CreateBalanceAdjustmentQuery,
+		adjustment.UserID,
+		adjustment.AdminID,
+		adjustment.Amount,
+		adjustment.Reason,
+		adjustment.CreatedAt,
+	).Scan(&adjustment.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return storage.ErrInsufficientFunds
+	}
+	return err
+}
+
+// GetUserBalanceAdjustments возвращает все корректировки баланса пользователя
+func (s *PgBalanceAdjustmentStorage) GetUserBalanceAdjustments(ctx context.Context, userID int64) ([]*models.BalanceAdjustment, error) {
+	return selectAll[models.BalanceAdjustment](ctx, s.db, GetUserBalanceAdjustmentsQuery, userID)
+}
@@ -0,0 +1,133 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+)
+
+// benchOrders - число заказов пользователя в данных для бенчмарков
+const benchOrders = 100
+
+// Бенчмарки сравнивают хранилища на pgxpool с прежней реализацией на database/sql и sqlx
+// на одних и тех же запросах из sql/*.sql:
+//
+//	TEST_DATABASE_URI=... go test -run '^$' -bench . -benchmem ./internal/storage/postgres
+
+func BenchmarkGetUserByID(b *testing.B) {
+	pool, db, userID := benchSetup(b)
+	ctx := context.Background()
+
+	b.Run("pgxpool", func(b *testing.B) {
+		s := NewPgUserStorage(pool)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := s.GetUserByID(ctx, userID); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("sqlx", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var user models.User
+			if err := db.GetContext(ctx, &user, GetUserByIDQuery, userID); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkGetUserOrders(b *testing.B) {
+	pool, db, userID := benchSetup(b)
+	ctx := context.Background()
+
+	b.Run("pgxpool", func(b *testing.B) {
+		s := NewPgOrderStorage(pool)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := s.GetUserOrders(ctx, userID); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("sqlx", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var orders []*models.Order
+			if err := db.SelectContext(ctx, &orders, GetUserOrdersQuery, userID); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkGetOrdersByStatuses(b *testing.B) {
+	pool, db, _ := benchSetup(b)
+	ctx := context.Background()
+	statuses := []string{models.OrderStatusNew, models.OrderStatusProcessing}
+
+	b.Run("pgxpool", func(b *testing.B) {
+		s := NewPgOrderStorage(pool)
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := s.GetOrdersByStatuses(ctx, statuses); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("sqlx", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var orders []*models.Order
+			if err := db.SelectContext(ctx, &orders, GetOrdersByStatuses, statuses); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// benchSetup очищает базу, создает пользователя с benchOrders заказами в разных статусах
+// и возвращает пул pgxpool и подключение sqlx с такими же ограничениями
+func benchSetup(b *testing.B) (*pgxpool.Pool, *sqlx.DB, int64) {
+	b.Helper()
+
+	pool := testPool(b)
+	ctx := context.Background()
+
+	_, err := pool.Exec(ctx, truncateQuery)
+	require.NoError(b, err)
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	user := &models.User{Login: "bench", PasswordHash: "hash", Role: models.RoleUser, CreatedAt: now}
+	require.NoError(b, NewPgUserStorage(pool).CreateUser(ctx, user))
+
+	statuses := []string{models.OrderStatusNew, models.OrderStatusProcessing, models.OrderStatusInvalid, models.OrderStatusProcessed}
+	orders := NewPgOrderStorage(pool)
+	for i := 0; i < benchOrders; i++ {
+		require.NoError(b, orders.CreateOrder(ctx, &models.Order{
+			Number:     fmt.Sprintf("bench-%d", i),
+			UserID:     user.ID,
+			Status:     statuses[i%len(statuses)],
+			Accrual:    float64(i),
+			UploadedAt: now.Add(time.Duration(i) * time.Second),
+		}))
+	}
+
+	db, err := sqlx.Connect("pgx", testDatabaseURI(b))
+	require.NoError(b, err)
+	db.SetMaxOpenConns(10)
+	b.Cleanup(func() { db.Close() })
+
+	return pool, db, user.ID
+}
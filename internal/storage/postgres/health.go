@@ -0,0 +1,43 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gitslim/gophermart/internal/health"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// healthChecker проверяет доступность базы данных
+type healthChecker struct {
+	pool *pgxpool.Pool
+}
+
+// NewHealthChecker создает проверку доступности базы данных
+func NewHealthChecker(pool *pgxpool.Pool) health.Checker {
+	return &healthChecker{pool: pool}
+}
+
+func (c *healthChecker) Name() string {
+	return "database"
+}
+
+func (c *healthChecker) Critical() bool {
+	return true
+}
+
+func (c *healthChecker) Check(ctx context.Context) (map[string]interface{}, error) {
+	stats := c.pool.Stat()
+	details := map[string]interface{}{
+		"open_connections": stats.TotalConns(),
+		"in_use":           stats.AcquiredConns(),
+		"idle":             stats.IdleConns(),
+		"max_connections":  stats.MaxConns(),
+	}
+
+	if err := c.pool.Ping(ctx); err != nil {
+		return details, fmt.Errorf("ping failed: %w", err)
+	}
+
+	return details, nil
+}
@@ -0,0 +1,121 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	CreateMerchantQuery     string
+	GetMerchantByIDQuery    string
+	GetMerchantByNameQuery  string
+	GetMerchantsQuery       string
+	CreateAPIKeyQuery       string
+	GetAPIKeyByIDQuery      string
+	GetAPIKeyByPrefixQuery  string
+	GetMerchantAPIKeysQuery string
+	ExpireAPIKeyQuery       string
+	RevokeAPIKeyQuery       string
+	TouchAPIKeyQuery        string
+)
+
+func init() {
+	queries := map[string]*string{
+		"create_merchant.sql":       &CreateMerchantQuery,
+		"get_merchant_by_id.sql":    &GetMerchantByIDQuery,
+		"get_merchant_by_name.sql":  &GetMerchantByNameQuery,
+		"get_merchants.sql":         &GetMerchantsQuery,
+		"create_api_key.sql":        &CreateAPIKeyQuery,
+		"get_api_key_by_id.sql":     &GetAPIKeyByIDQuery,
+		"get_api_key_by_prefix.sql": &GetAPIKeyByPrefixQuery,
+		"get_merchant_api_keys.sql": &GetMerchantAPIKeysQuery,
+		"expire_api_key.sql":        &ExpireAPIKeyQuery,
+		"revoke_api_key.sql":        &RevokeAPIKeyQuery,
+		"touch_api_key.sql":         &TouchAPIKeyQuery,
+	}
+
+	loadQueries(queries)
+}
+
+// PgMerchantStorage представляет хранилище магазинов и ключей доступа в PostgreSQL
+type PgMerchantStorage struct {
+	db *pgxpool.Pool
+}
+
+// NewPgMerchantStorage создает новый экземпляр хранилища PostgreSQL
+func NewPgMerchantStorage(db *pgxpool.Pool) *PgMerchantStorage {
+	return &PgMerchantStorage{
+		db: db,
+	}
+}
+
+// CreateMerchant создает новый магазин
+func (s *PgMerchantStorage) CreateMerchant(ctx context.Context, merchant *models.Merchant) error {
+	return s.db.QueryRow(ctx, CreateMerchantQuery,
+		merchant.Name,
+		merchant.CreatedAt,
+	).Scan(&merchant.ID)
+}
+
+// GetMerchantByID возвращает магазин по ID
+func (s *PgMerchantStorage) GetMerchantByID(ctx context.Context, id int64) (*models.Merchant, error) {
+	return getOne[models.Merchant](ctx, s.db, GetMerchantByIDQuery, id)
+}
+
+// GetMerchantByName возвращает магазин по названию
+func (s *PgMerchantStorage) GetMerchantByName(ctx context.Context, name string) (*models.Merchant, error) {
+	return getOne[models.Merchant](ctx, s.db, GetMerchantByNameQuery, name)
+}
+
+// GetMerchants возвращает все магазины
+func (s *PgMerchantStorage) GetMerchants(ctx context.Context) ([]*models.Merchant, error) {
+	return selectAll[models.Merchant](ctx, s.db, GetMerchantsQuery)
+}
+
+// CreateAPIKey сохраняет новый ключ доступа
+func (s *PgMerchantStorage) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	return s.db.QueryRow(ctx, CreateAPIKeyQuery,
+		key.MerchantID,
+		key.Prefix,
+		key.KeyHash,
+		key.Scopes,
+		key.CreatedAt,
+		key.ExpiresAt,
+	).Scan(&key.ID)
+}
+
+// GetAPIKeyByID возвращает ключ доступа по ID
+func (s *PgMerchantStorage) GetAPIKeyByID(ctx context.Context, id int64) (*models.APIKey, error) {
+	return getOne[models.APIKey](ctx, s.db, GetAPIKeyByIDQuery, id)
+}
+
+// GetAPIKeyByPrefix возвращает ключ доступа по префиксу
+func (s *PgMerchantStorage) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	return getOne[models.APIKey](ctx, s.db, GetAPIKeyByPrefixQuery, prefix)
+}
+
+// GetMerchantAPIKeys возвращает все ключи доступа магазина
+func (s *PgMerchantStorage) GetMerchantAPIKeys(ctx context.Context, merchantID int64) ([]*models.APIKey, error) {
+	return selectAll[models.APIKey](ctx, s.db, GetMerchantAPIKeysQuery, merchantID)
+}
+
+// ExpireAPIKey ограничивает срок действия ключа, не продлевая уже истекающий
+func (s *PgMerchantStorage) ExpireAPIKey(ctx context.Context, id int64, expiresAt time.Time) error {
+	_, err := s.db.Exec(ctx, ExpireAPIKeyQuery, id, expiresAt)
+	return err
+}
+
+// RevokeAPIKey немедленно отзывает ключ
+func (s *PgMerchantStorage) RevokeAPIKey(ctx context.Context, id int64, revokedAt time.Time) error {
+	_, err := s.db.Exec(ctx, RevokeAPIKeyQuery, id, revokedAt)
+	return err
+}
+
+// TouchAPIKey обновляет время последнего использования ключа
+func (s *PgMerchantStorage) TouchAPIKey(ctx context.Context, id int64, usedAt time.Time) error {
+	_, err := s.db.Exec(ctx, TouchAPIKeyQuery, id, usedAt)
+	return err
+}
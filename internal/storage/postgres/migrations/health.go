@@ -0,0 +1,89 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/gitslim/gophermart/internal/health"
+	schema "github.com/gitslim/gophermart/migrations"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// healthChecker сверяет примененную версию схемы с последней доступной миграцией
+type healthChecker struct {
+	pool *pgxpool.Pool
+}
+
+// NewHealthChecker создает проверку состояния миграций
+func NewHealthChecker(pool *pgxpool.Pool) health.Checker {
+	return &healthChecker{pool: pool}
+}
+
+func (c *healthChecker) Name() string {
+	return "migrations"
+}
+
+func (c *healthChecker) Critical() bool {
+	return true
+}
+
+func (c *healthChecker) Check(ctx context.Context) (map[string]interface{}, error) {
+	latest, err := latestVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	var state struct {
+		Version uint
+		Dirty   bool
+	}
+	err = c.pool.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&state.Version, &state.Dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return map[string]interface{}{"latest": latest}, errors.New("no migrations applied")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schema version: %w", err)
+	}
+
+	details := map[string]interface{}{
+		"version": state.Version,
+		"latest":  latest,
+		"dirty":   state.Dirty,
+	}
+	if state.Dirty {
+		return details, fmt.Errorf("migration %d failed and left the schema dirty", state.Version)
+	}
+	if state.Version < latest {
+		return details, fmt.Errorf("schema version %d is behind %d", state.Version, latest)
+	}
+
+	return details, nil
+}
+
+// latestVersion возвращает версию последней встроенной миграции
+func latestVersion() (uint, error) {
+	src, err := schema.Postgres()
+	if err != nil {
+		return 0, fmt.Errorf("failed to open migrations: %w", err)
+	}
+	defer src.Close()
+
+	version, err := src.First()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	for {
+		next, err := src.Next(version)
+		if errors.Is(err, os.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read migrations: %w", err)
+		}
+		version = next
+	}
+}
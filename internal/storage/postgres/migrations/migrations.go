@@ -1,23 +1,67 @@
 package migrations
 
 import (
+	"context"
 	"errors"
 	"fmt"
 
 	"github.com/gitslim/gophermart/internal/conf"
+	"github.com/gitslim/gophermart/internal/logging"
+	schema "github.com/gitslim/gophermart/migrations"
 	"github.com/golang-migrate/migrate/v4"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-// RunMigrations запускает миграции базы данных
-func RunMigrations(config *conf.Config) error {
-	m, err := migrate.New(
-		"file://migrations",
-		config.DatabaseURI,
-	)
+// lockKey - ключ advisory-блокировки, которая сериализует запуски миграций при старте реплик целиком
+const lockKey int64 = 0x6d696772617465 // "migrate"
+
+// New создает экземпляр migrate со встроенными миграциями PostgreSQL
+func New(config *conf.Config) (*migrate.Migrate, error) {
+	src, err := schema.Postgres()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open migrations: %w", err)
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", src, config.DatabaseURI)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migrate instance: %w", err)
+	}
+
+	return m, nil
+}
+
+// RunMigrations применяет миграции при старте, если это разрешено в конфигурации.
+// Advisory-блокировка удерживается на весь запуск migrate, поэтому реплики, стартующие одновременно,
+// выполняют его по очереди: первая применяет миграции, остальные после нее получают ErrNoChange.
+// Подкоманда migrate эту блокировку не берет, одновременные изменения схемы исключает
+// собственная блокировка golang-migrate
+func RunMigrations(config *conf.Config, log logging.Logger, pool *pgxpool.Pool) error {
+	if !config.MigrateOnStart {
+		log.Infof("Migrations on start are disabled")
+		return nil
+	}
+
+	ctx := context.Background()
+
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		return fmt.Errorf("failed to acquire migrations lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.Exec(ctx, "SELECT pg_advisory_unlock($1)", lockKey); err != nil {
+			log.Warnf("Failed to release migrations lock: %v", err)
+		}
+	}()
+
+	m, err := New(config)
 	if err != nil {
-		return fmt.Errorf("failed to create migrate instance: %w", err)
+		return err
 	}
 	defer m.Close()
 
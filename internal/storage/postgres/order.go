@@ -2,29 +2,41 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
-	"errors"
+	"time"
 
 	"github.com/gitslim/gophermart/internal/models"
-	_ "github.com/jackc/pgx/v5/stdlib"
-	"github.com/jmoiron/sqlx"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 var (
-	CreateOrderQuery      string
-	GetOrderByNumberQuery string
-	GetUserOrdersQuery    string
-	UpdateOrderStatus     string
-	GetOrdersByStatuses   string
+	CreateOrderQuery       string
+	CreateOrdersQuery      string
+	GetOrderByNumberQuery  string
+	GetUserOrdersQuery     string
+	UpdateOrderStatus      string
+	GetOrdersByStatuses    string
+	GetUserOrdersPageQuery string
+	ExportUserOrdersQuery  string
+
+	CreateOrderStatusChangeQuery string
+	ApplyOrderStatusChangeQuery  string
+	GetOrderStatusHistoryQuery   string
 )
 
 func init() {
 	queries := map[string]*string{
 		"create_order.sql":           &CreateOrderQuery,
+		"create_orders.sql":          &CreateOrdersQuery,
 		"get_order_by_number.sql":    &GetOrderByNumberQuery,
 		"get_user_orders.sql":        &GetUserOrdersQuery,
 		"update_order_status.sql":    &UpdateOrderStatus,
 		"get_orders_by_statuses.sql": &GetOrdersByStatuses,
+		"get_user_orders_page.sql":   &GetUserOrdersPageQuery,
+		"export_user_orders.sql":     &ExportUserOrdersQuery,
+
+		"create_order_status_change.sql": &CreateOrderStatusChangeQuery,
+		"apply_order_status_change.sql":  &ApplyOrderStatusChangeQuery,
+		"get_order_status_history.sql":   &GetOrderStatusHistoryQuery,
 	}
 
 	loadQueries(queries)
@@ -32,11 +44,11 @@ func init() {
 
 // PgOrderStorage представляет хранилище заказов в PostgreSQL
 type PgOrderStorage struct {
-	db *sqlx.DB
+	db *pgxpool.Pool
 }
 
 // NewPgOrderStorage создает новый экземпляр хранилища PostgreSQL
-func NewPgOrderStorage(db *sqlx.DB) *PgOrderStorage {
+func NewPgOrderStorage(db *pgxpool.Pool) *PgOrderStorage {
 	return &PgOrderStorage{
 		db: db,
 	}
@@ -44,7 +56,7 @@ func NewPgOrderStorage(db *sqlx.DB) *PgOrderStorage {
 
 // CreateOrder создает новый заказ
 func (s *PgOrderStorage) CreateOrder(ctx context.Context, order *models.Order) error {
-	_, err := s.db.ExecContext(ctx, CreateOrderQuery,
+	_, err := s.db.Exec(ctx, CreateOrderQuery,
 		order.Number,
 		order.UserID,
 		order.Status,
@@ -56,32 +68,98 @@ func (s *PgOrderStorage) CreateOrder(ctx context.Context, order *models.Order) e
 	return err
 }
 
+// CreateOrders создает новые заказы пакетом за один запрос и возвращает результат по каждому номеру
+func (s *PgOrderStorage) CreateOrders(ctx context.Context, userID int64, numbers []string, uploadedAt time.Time) ([]*models.OrderUploadResult, error) {
+	// Обновление без изменений при конфликте блокирует существующую строку и возвращает ее владельца,
+	// в том числе если номер вставила параллельная транзакция, которую не видит снимок запроса.
+	// Вставленные строки отличаются нулевым xmax.
+	// Начисление и время обработки заполняются нулевыми значениями так же, как в CreateOrder
+	return selectAll[models.OrderUploadResult](ctx, s.db, CreateOrdersQuery,
+		userID,
+		numbers,
+		models.OrderStatusNew,
+		0,
+		uploadedAt,
+		time.Time{},
+	)
+}
+
 // GetOrderByNumber возвращает заказ по номеру
 func (s *PgOrderStorage) GetOrderByNumber(ctx context.Context, number string) (*models.Order, error) {
-	var order models.Order
-	err := s.db.GetContext(ctx, &order, GetOrderByNumberQuery, number)
-	if errors.Is(err, sql.ErrNoRows) {
-		return nil, nil
-	}
-	return &order, err
+	return getOne[models.Order](ctx, s.db, GetOrderByNumberQuery, number)
 }
 
 // GetUserOrders возвращает все заказы пользователя
 func (s *PgOrderStorage) GetUserOrders(ctx context.Context, userID int64) ([]*models.Order, error) {
-	var orders []*models.Order
-	err := s.db.SelectContext(ctx, &orders, GetUserOrdersQuery, userID)
-	return orders, err
+	return selectAll[models.Order](ctx, s.db, GetUserOrdersQuery, userID)
+}
+
+// GetUserOrdersPage возвращает страницу заказов пользователя с учетом фильтров
+func (s *PgOrderStorage) GetUserOrdersPage(ctx context.Context, userID int64, filter models.OrderFilter) ([]*models.Order, error) {
+	var afterAt *time.Time
+	var afterID int64
+	if filter.After != nil {
+		afterAt = &filter.After.At
+		afterID = filter.After.ID
+	}
+
+	return selectAll[models.Order](ctx, s.db, GetUserOrdersPageQuery,
+		userID,
+		filter.Statuses,
+		filter.From,
+		filter.To,
+		afterAt,
+		afterID,
+		filter.Limit,
+	)
+}
+
+// ExportUserOrders построчно передает в fn заказы пользователя с учетом фильтров, не загружая их в память целиком.
+// Позиция и размер страницы в фильтре не учитываются.
+func (s *PgOrderStorage) ExportUserOrders(ctx context.Context, userID int64, filter models.OrderFilter, fn func(*models.Order) error) error {
+	return forEach(ctx, s.db, fn, ExportUserOrdersQuery, userID, filter.Statuses, filter.From, filter.To)
 }
 
 // UpdateOrderStatus обновляет статус заказа
 func (s *PgOrderStorage) UpdateOrderStatus(ctx context.Context, orderID int64, status string, accrual float64) error {
-	_, err := s.db.ExecContext(ctx, UpdateOrderStatus, orderID, status, accrual)
+	_, err := s.db.Exec(ctx, UpdateOrderStatus, orderID, status, accrual)
 	return err
 }
 
 // GetOrdersByStatuses возвращает заказы с указанными статусами
 func (s *PgOrderStorage) GetOrdersByStatuses(ctx context.Context, statuses []string) ([]*models.Order, error) {
-	var orders []*models.Order
-	err := s.db.SelectContext(ctx, &orders, GetOrdersByStatuses, statuses)
-	return orders, err
+	return selectAll[models.Order](ctx, s.db, GetOrdersByStatuses, statuses)
+}
+
+// CreateOrderStatusChange сохраняет результат проверки заказа в истории обработки
+func (s *PgOrderStorage) CreateOrderStatusChange(ctx context.Context, change *models.OrderStatusChange) error {
+	return s.db.QueryRow(ctx, CreateOrderStatusChangeQuery,
+		change.OrderID,
+		change.PreviousStatus,
+		change.Status,
+		change.Accrual,
+		change.Credited,
+		change.CheckedAt,
+	).Scan(&change.ID)
+}
+
+// ApplyOrderStatusChange одним запросом обновляет статус заказа, зачисляет change.Credited на баланс владельца
+// и сохраняет запись в истории, если проверка на что-то повлияла. Возвращает false, если статус заказа уже отличается от change.PreviousStatus
+func (s *PgOrderStorage) ApplyOrderStatusChange(ctx context.Context, change *models.OrderStatusChange) (bool, error) {
+	var applied bool
+	err := s.db.QueryRow(ctx, ApplyOrderStatusChangeQuery,
+		change.OrderID,
+		change.PreviousStatus,
+		change.Status,
+		change.Accrual,
+		change.Credited,
+		change.CheckedAt,
+	).Scan(&applied)
+
+	return applied, err
+}
+
+// GetOrderStatusHistory возвращает историю обработки заказа в хронологическом порядке
+func (s *PgOrderStorage) GetOrderStatusHistory(ctx context.Context, orderID int64) ([]*models.OrderStatusChange, error) {
+	return selectAll[models.OrderStatusChange](ctx, s.db, GetOrderStatusHistoryQuery, orderID)
 }
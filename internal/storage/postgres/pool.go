@@ -3,41 +3,50 @@ package postgres
 import (
 	"context"
 	"fmt"
-	"time"
 
 	"github.com/gitslim/gophermart/internal/conf"
 	"github.com/gitslim/gophermart/internal/logging"
-	"github.com/jmoiron/sqlx"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"go.uber.org/fx"
 )
 
-func NewConnPool(config *conf.Config) (*sqlx.DB, error) {
-	// Создаем подключение через sqlx
-	db, err := sqlx.Connect("pgx", config.DatabaseURI)
+// NewConnPool создает пул подключений с ограничениями из конфигурации.
+// Подключения устанавливаются по мере необходимости, доступность базы проверяется при старте приложения.
+// Медленные и завершившиеся ошибкой запросы пишутся в журнал запроса из контекста
+func NewConnPool(config *conf.Config, log logging.Logger) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(config.DatabaseURI)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
+		return nil, fmt.Errorf("failed to parse database URI: %w", err)
 	}
 
 	// Настраиваем пул
-	db.SetMaxOpenConns(10)
-	db.SetMaxIdleConns(2)
-	db.SetConnMaxIdleTime(5 * time.Minute)
+	poolConfig.MaxConns = config.DatabaseMaxConns
+	poolConfig.MinConns = config.DatabaseMinConns
+	poolConfig.MaxConnLifetime = config.DatabaseMaxConnLifetime
+	poolConfig.MaxConnIdleTime = config.DatabaseMaxConnIdleTime
+	poolConfig.HealthCheckPeriod = config.DatabaseHealthCheckPeriod
+	poolConfig.ConnConfig.Tracer = &queryTracer{log: log}
 
-	return db, nil
+	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create connection pool: %w", err)
+	}
+
+	return pool, nil
 }
 
-func RegisterPoolHooks(lc fx.Lifecycle, cfg *conf.Config, log logging.Logger, db *sqlx.DB) {
+func RegisterPoolHooks(lc fx.Lifecycle, cfg *conf.Config, log logging.Logger, pool *pgxpool.Pool) {
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
 			// пингуем бд
-			if err := db.PingContext(context.Background()); err != nil {
+			if err := pool.Ping(ctx); err != nil {
 				return fmt.Errorf("postgres connection error: %w", err)
 			}
 			return nil
 		},
 		OnStop: func(ctx context.Context) error {
-			// закрываем соединение
-			db.Close()
+			// закрываем подключения пула
+			pool.Close()
 			return nil
 		},
 	})
@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/conf"
+	"github.com/gitslim/gophermart/internal/logging/sugared"
+	"github.com/gitslim/gophermart/internal/storage/postgres/migrations"
+	"github.com/gitslim/gophermart/internal/storage/storagetest"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+)
+
+// truncateQuery очищает все таблицы между проверками
+const truncateQuery = `TRUNCATE users, recovery_codes, sessions, orders, order_status_history, withdrawals,
+	balance_adjustments, merchants, merchant_api_keys RESTART IDENTITY CASCADE`
+
+// TestConformance проверяет хранилища на базе из TEST_DATABASE_URI, данные базы удаляются
+func TestConformance(t *testing.T) {
+	pool := testPool(t)
+
+	storagetest.Run(t, func(t *testing.T) storagetest.Storages {
+		_, err := pool.Exec(context.Background(), truncateQuery)
+		require.NoError(t, err)
+
+		return storagetest.Storages{
+			Users:              NewPgUserStorage(pool),
+			Orders:             NewPgOrderStorage(pool),
+			Withdrawals:        NewPgWithdrawalStorage(pool),
+			BalanceAdjustments: NewPgBalanceAdjustmentStorage(pool),
+			Merchants:          NewPgMerchantStorage(pool),
+			Sessions:           NewPgSessionStorage(pool),
+		}
+	})
+}
+
+// testPool применяет миграции к базе из TEST_DATABASE_URI и подключается к ней,
+// без TEST_DATABASE_URI тест пропускается
+func testPool(tb testing.TB) *pgxpool.Pool {
+	tb.Helper()
+
+	uri := testDatabaseURI(tb)
+
+	config := &conf.Config{
+		DatabaseURI:               uri,
+		MigrateOnStart:            true,
+		DatabaseMaxConns:          10,
+		DatabaseMaxConnLifetime:   time.Hour,
+		DatabaseMaxConnIdleTime:   time.Minute,
+		DatabaseHealthCheckPeriod: time.Minute,
+	}
+
+	log, err := sugared.NewLogger()
+	require.NoError(tb, err)
+
+	pool, err := NewConnPool(config, log)
+	require.NoError(tb, err)
+	tb.Cleanup(pool.Close)
+
+	require.NoError(tb, migrations.RunMigrations(config, log, pool))
+
+	return pool
+}
+
+// testDatabaseURI возвращает адрес тестовой базы данных
+func testDatabaseURI(tb testing.TB) string {
+	uri := os.Getenv("TEST_DATABASE_URI")
+	if uri == "" {
+		tb.Skip("TEST_DATABASE_URI is not set")
+	}
+	return uri
+}
@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var (
+	CreateSessionQuery       string
+	GetSessionByIDQuery      string
+	GetUserSessionsQuery     string
+	TouchSessionQuery        string
+	RevokeSessionQuery       string
+	RevokeOtherSessionsQuery string
+)
+
+func init() {
+	queries := map[string]*string{
+		"create_session.sql":        &CreateSessionQuery,
+		"get_session_by_id.sql":     &GetSessionByIDQuery,
+		"get_user_sessions.sql":     &GetUserSessionsQuery,
+		"touch_session.sql":         &TouchSessionQuery,
+		"revoke_session.sql":        &RevokeSessionQuery,
+		"revoke_other_sessions.sql": &RevokeOtherSessionsQuery,
+	}
+
+	loadQueries(queries)
+}
+
+// PgSessionStorage представляет хранилище сессий в PostgreSQL
+type PgSessionStorage struct {
+	db *pgxpool.Pool
+}
+
+// NewPgSessionStorage создает новый экземпляр хранилища PostgreSQL
+func NewPgSessionStorage(db *pgxpool.Pool) *PgSessionStorage {
+	return &PgSessionStorage{
+		db: db,
+	}
+}
+
+// CreateSession создает новую сессию
+func (s *PgSessionStorage) CreateSession(ctx context.Context, session *models.Session) error {
+	return s.db.QueryRow(ctx, CreateSessionQuery,
+		session.UserID,
+		session.UserAgent,
+		session.IP,
+		session.CreatedAt,
+		session.LastSeenAt,
+		session.ExpiresAt,
+	).Scan(&session.ID)
+}
+
+// GetSessionByID возвращает сессию по ID
+func (s *PgSessionStorage) GetSessionByID(ctx context.Context, id int64) (*models.Session, error) {
+	return getOne[models.Session](ctx, s.db, GetSessionByIDQuery, id)
+}
+
+// GetUserSessions возвращает активные сессии пользователя
+func (s *PgSessionStorage) GetUserSessions(ctx context.Context, userID int64, now time.Time) ([]*models.Session, error) {
+	return selectAll[models.Session](ctx, s.db, GetUserSessionsQuery, userID, now)
+}
+
+// TouchSession обновляет время последней активности сессии
+func (s *PgSessionStorage) TouchSession(ctx context.Context, id int64, lastSeenAt time.Time) error {
+	_, err := s.db.Exec(ctx, TouchSessionQuery, id, lastSeenAt)
+	return err
+}
+
+// RevokeSession отзывает сессию пользователя, возвращает false, если активная сессия не найдена
+func (s *PgSessionStorage) RevokeSession(ctx context.Context, userID, id int64, revokedAt time.Time) (bool, error) {
+	tag, err := s.db.Exec(ctx, RevokeSessionQuery, id, userID, revokedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// RevokeOtherSessions отзывает все сессии пользователя, кроме указанной
+func (s *PgSessionStorage) RevokeOtherSessions(ctx context.Context, userID, exceptID int64, revokedAt time.Time) error {
+	_, err := s.db.Exec(ctx, RevokeOtherSessionsQuery, userID, exceptID, revokedAt)
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,17 @@
+WITH revoked AS (
+    UPDATE sessions
+    SET revoked_at = $3
+    WHERE user_id = $1 AND revoked_at IS NULL
+), deleted_codes AS (
+    DELETE FROM recovery_codes
+    WHERE user_id = $1
+)
+UPDATE users
+SET login = $2,
+    password_hash = '',
+    totp_secret = '',
+    totp_enabled = FALSE,
+    totp_last_step = 0,
+    token_version = token_version + 1,
+    deleted_at = $3
+WHERE id = $1 AND deleted_at IS NULL
@@ -0,0 +1,20 @@
+WITH updated AS (
+    UPDATE orders
+    SET status = $3, accrual = $4, processed_at = CURRENT_TIMESTAMP
+    WHERE id = $1 AND status = $2
+    RETURNING id, user_id
+),
+credited AS (
+    UPDATE users
+    SET balance = balance + $5
+    FROM updated
+    WHERE users.id = updated.user_id AND $5::decimal > 0
+),
+history AS (
+    INSERT INTO order_status_history (order_id, previous_status, status, accrual, credited, checked_at)
+    SELECT id, $2, $3, $4, $5, $6
+    FROM updated
+    WHERE $2 <> $3 OR $5::decimal > 0
+)
+SELECT count(*) > 0
+FROM updated
\ No newline at end of file
@@ -0,0 +1,3 @@
+UPDATE users
+SET last_pre_auth_at = $2
+WHERE id = $1 AND last_pre_auth_at < $2
\ No newline at end of file
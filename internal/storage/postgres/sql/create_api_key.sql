@@ -0,0 +1,3 @@
+INSERT INTO merchant_api_keys (merchant_id, prefix, key_hash, scopes, created_at, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id
\ No newline at end of file
@@ -0,0 +1,10 @@
+WITH updated AS (
+    UPDATE users
+    SET balance = balance + $3
+    WHERE id = $1 AND balance + $3 >= 0
+    RETURNING id
+)
+INSERT INTO balance_adjustments (user_id, admin_id, amount, reason, created_at)
+SELECT id, $2, $3, $4, $5
+FROM updated
+RETURNING id
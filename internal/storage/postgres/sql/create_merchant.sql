@@ -0,0 +1,3 @@
+INSERT INTO merchants (name, created_at)
+VALUES ($1, $2)
+RETURNING id
\ No newline at end of file
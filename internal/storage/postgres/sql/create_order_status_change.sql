@@ -0,0 +1,3 @@
+INSERT INTO order_status_history (order_id, previous_status, status, accrual, credited, checked_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id
\ No newline at end of file
@@ -0,0 +1,14 @@
+WITH input AS (
+    SELECT DISTINCT number
+    FROM unnest($2::text[]) AS t(number)
+)
+INSERT INTO orders (number, user_id, status, accrual, uploaded_at, processed_at)
+SELECT number, $1::bigint, $3::varchar, $4::decimal, $5::timestamp, $6::timestamp
+FROM input
+ON CONFLICT (number) DO UPDATE SET user_id = orders.user_id
+RETURNING number,
+          CASE
+              WHEN xmax = 0 THEN 'accepted'
+              WHEN user_id = $1 THEN 'already_uploaded'
+              ELSE 'conflict'
+          END AS result
\ No newline at end of file
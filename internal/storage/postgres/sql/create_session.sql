@@ -0,0 +1,3 @@
+INSERT INTO sessions (user_id, user_agent, ip, created_at, last_seen_at, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id
\ No newline at end of file
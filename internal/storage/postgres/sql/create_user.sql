@@ -1,3 +1,3 @@
-INSERT INTO users (login, password_hash, balance, created_at)
-VALUES ($1, $2, $3, $4)
+INSERT INTO users (login, password_hash, balance, role, created_at)
+VALUES ($1, $2, $3, $4, $5)
 RETURNING id
\ No newline at end of file
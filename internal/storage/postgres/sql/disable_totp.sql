@@ -0,0 +1,7 @@
+WITH deleted AS (
+    DELETE FROM recovery_codes
+    WHERE user_id = $1
+)
+UPDATE users
+SET totp_secret = '', totp_enabled = FALSE, totp_last_step = 0
+WHERE id = $1
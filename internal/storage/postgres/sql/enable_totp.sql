@@ -0,0 +1,13 @@
+WITH enabled AS (
+    UPDATE users
+    SET totp_enabled = TRUE, totp_last_step = $2
+    WHERE id = $1 AND totp_secret <> '' AND NOT totp_enabled
+    RETURNING id
+), deleted AS (
+    DELETE FROM recovery_codes
+    WHERE user_id IN (SELECT id FROM enabled)
+), created AS (
+    INSERT INTO recovery_codes (user_id, code_hash)
+    SELECT enabled.id, unnest($3::text[]) FROM enabled
+)
+SELECT count(*) FROM enabled
\ No newline at end of file
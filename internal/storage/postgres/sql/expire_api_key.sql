@@ -0,0 +1,3 @@
+UPDATE merchant_api_keys
+SET expires_at = $2
+WHERE id = $1 AND (expires_at IS NULL OR expires_at > $2)
\ No newline at end of file
@@ -0,0 +1,7 @@
+SELECT id, number, user_id, status, accrual, uploaded_at, processed_at
+FROM orders
+WHERE user_id = $1
+  AND (COALESCE(cardinality($2::text[]), 0) = 0 OR status = ANY($2))
+  AND ($3::timestamp IS NULL OR uploaded_at >= $3)
+  AND ($4::timestamp IS NULL OR uploaded_at < $4)
+ORDER BY uploaded_at DESC, id DESC
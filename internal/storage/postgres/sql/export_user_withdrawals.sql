@@ -0,0 +1,6 @@
+SELECT id, user_id, order_number, sum, processed_at
+FROM withdrawals
+WHERE user_id = $1
+  AND ($2::timestamp IS NULL OR processed_at >= $2)
+  AND ($3::timestamp IS NULL OR processed_at < $3)
+ORDER BY processed_at DESC, id DESC
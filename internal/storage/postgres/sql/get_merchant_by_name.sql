@@ -0,0 +1,3 @@
+SELECT id, name, created_at
+FROM merchants
+WHERE name = $1
\ No newline at end of file
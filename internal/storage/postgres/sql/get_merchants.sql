@@ -0,0 +1,3 @@
+SELECT id, name, created_at
+FROM merchants
+ORDER BY name ASC
\ No newline at end of file
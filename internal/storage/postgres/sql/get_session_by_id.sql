@@ -0,0 +1,3 @@
+SELECT id, user_id, user_agent, ip, created_at, last_seen_at, expires_at, revoked_at
+FROM sessions
+WHERE id = $1
\ No newline at end of file
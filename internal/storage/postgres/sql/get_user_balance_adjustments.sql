@@ -0,0 +1,4 @@
+SELECT id, user_id, admin_id, amount, reason, created_at
+FROM balance_adjustments
+WHERE user_id = $1
+ORDER BY created_at DESC
\ No newline at end of file
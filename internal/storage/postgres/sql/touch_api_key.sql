@@ -0,0 +1,3 @@
+UPDATE merchant_api_keys
+SET last_used_at = $2
+WHERE id = $1
\ No newline at end of file
@@ -0,0 +1,10 @@
+WITH updated AS (
+    UPDATE users
+    SET balance = balance - $3
+    WHERE id = $1 AND balance >= $3
+    RETURNING id
+)
+INSERT INTO withdrawals (user_id, order_number, sum, processed_at)
+SELECT id, $2, $3, $4
+FROM updated
+RETURNING id
\ No newline at end of file
@@ -1,11 +1,14 @@
 package postgres
 
 import (
+	"context"
 	"embed"
+	"errors"
 	"log"
 	"path/filepath"
 
-	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 //go:embed sql/*.sql
@@ -21,3 +24,49 @@ func loadQueries(queries map[string]*string) {
 		*qPtr = string(data)
 	}
 }
+
+// getOne возвращает первую строку результата запроса, поля структуры сопоставляются с колонками по тегу db.
+// Возвращает nil без ошибки, если запрос не вернул строк
+func getOne[T any](ctx context.Context, pool *pgxpool.Pool, query string, args ...any) (*T, error) {
+	rows, err := pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := pgx.CollectOneRow(rows, pgx.RowToAddrOfStructByNameLax[T])
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return v, err
+}
+
+// selectAll возвращает все строки результата запроса, для пустого результата возвращается nil
+func selectAll[T any](ctx context.Context, pool *pgxpool.Pool, query string, args ...any) ([]*T, error) {
+	rows, err := pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.AppendRows([]*T(nil), rows, pgx.RowToAddrOfStructByNameLax[T])
+}
+
+// forEach построчно передает в fn строки результата запроса, не загружая их в память целиком
+func forEach[T any](ctx context.Context, pool *pgxpool.Pool, fn func(*T) error, query string, args ...any) error {
+	rows, err := pool.Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		v, err := pgx.RowToAddrOfStructByNameLax[T](rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,37 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/logging"
+	"github.com/gitslim/gophermart/internal/storage"
+	"github.com/jackc/pgx/v5"
+)
+
+// queryStartKey - ключ контекста с началом запроса
+type queryStartKey struct{}
+
+// queryStart описывает выполняемый запрос
+type queryStart struct {
+	sql  string
+	time time.Time
+}
+
+// queryTracer пишет медленные и завершившиеся ошибкой запросы в журнал из контекста,
+// поэтому записи содержат идентификатор HTTP запроса или задачи воркера
+type queryTracer struct {
+	log logging.Logger
+}
+
+func (t *queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryStartKey{}, queryStart{sql: data.SQL, time: time.Now()})
+}
+
+func (t *queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	start, ok := ctx.Value(queryStartKey{}).(queryStart)
+	if !ok {
+		return
+	}
+	storage.LogQuery(logging.FromContext(ctx, t.log), start.sql, time.Since(start.time), data.Err)
+}
@@ -2,39 +2,61 @@ package postgres
 
 import (
 	"context"
-	"database/sql"
-	"errors"
 	"fmt"
+	"time"
 
 	"github.com/gitslim/gophermart/internal/models"
-	_ "github.com/jackc/pgx/v5/stdlib"
-	"github.com/jmoiron/sqlx"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 var (
-	CreateUserQuery     string
-	GetUserByLoginQuery string
-	GetUserByIDQuery    string
-	UpdateBalanceQuery  string
+	CreateUserQuery           string
+	GetUserByLoginQuery       string
+	GetUserByIDQuery          string
+	UpdateBalanceQuery        string
+	UpdatePasswordHashQuery   string
+	ChangePasswordQuery       string
+	SearchUsersQuery          string
+	UpdateUserRoleQuery       string
+	SetTOTPSecretQuery        string
+	EnableTOTPQuery           string
+	DisableTOTPQuery          string
+	UpdateTOTPLastStepQuery   string
+	ReplaceRecoveryCodesQuery string
+	UseRecoveryCodeQuery      string
+	ConsumePreAuthTokenQuery  string
+	AnonymizeUserQuery        string
 )
 
 func init() {
 	queries := map[string]*string{
-		"create_user.sql":       &CreateUserQuery,
-		"get_user_by_login.sql": &GetUserByLoginQuery,
-		"get_user_by_id.sql":    &GetUserByIDQuery,
-		"update_balance.sql":    &UpdateBalanceQuery,
+		"create_user.sql":            &CreateUserQuery,
+		"get_user_by_login.sql":      &GetUserByLoginQuery,
+		"get_user_by_id.sql":         &GetUserByIDQuery,
+		"update_balance.sql":         &UpdateBalanceQuery,
+		"update_password_hash.sql":   &UpdatePasswordHashQuery,
+		"change_password.sql":        &ChangePasswordQuery,
+		"search_users.sql":           &SearchUsersQuery,
+		"update_user_role.sql":       &UpdateUserRoleQuery,
+		"set_totp_secret.sql":        &SetTOTPSecretQuery,
+		"enable_totp.sql":            &EnableTOTPQuery,
+		"disable_totp.sql":           &DisableTOTPQuery,
+		"update_totp_last_step.sql":  &UpdateTOTPLastStepQuery,
+		"replace_recovery_codes.sql": &ReplaceRecoveryCodesQuery,
+		"use_recovery_code.sql":      &UseRecoveryCodeQuery,
+		"consume_pre_auth_token.sql": &ConsumePreAuthTokenQuery,
+		"anonymize_user.sql":         &AnonymizeUserQuery,
 	}
 	loadQueries(queries)
 }
 
 // PgUserStorage представляет хранилище пользователей PostgreSQL
 type PgUserStorage struct {
-	db *sqlx.DB
+	db *pgxpool.Pool
 }
 
 // NewPgUserStorage создает новый экземпляр хранилища PostgreSQL
-func NewPgUserStorage(db *sqlx.DB) *PgUserStorage {
+func NewPgUserStorage(db *pgxpool.Pool) *PgUserStorage {
 	return &PgUserStorage{
 		db: db,
 	}
@@ -42,41 +64,152 @@ func NewPgUserStorage(db *sqlx.DB) *PgUserStorage {
 
 // CreateUser создает нового пользователя
 func (s *PgUserStorage) CreateUser(ctx context.Context, user *models.User) error {
-	err := s.db.GetContext(ctx, &user.ID, CreateUserQuery,
+	err := s.db.QueryRow(ctx, CreateUserQuery,
 		user.Login,
 		user.PasswordHash,
 		user.Balance,
+		user.Role,
 		user.CreatedAt,
-	)
+	).Scan(&user.ID)
 	return err
 }
 
 // GetUserByLogin возвращает пользователя по логину
 func (s *PgUserStorage) GetUserByLogin(ctx context.Context, login string) (*models.User, error) {
-	var user models.User
-	err := s.db.GetContext(ctx, &user, GetUserByLoginQuery, login)
-	if errors.Is(err, sql.ErrNoRows) {
-		return nil, nil
-	}
-	return &user, err
+	return getOne[models.User](ctx, s.db, GetUserByLoginQuery, login)
 }
 
 // GetUserByID возвращает пользователя по ID
 func (s *PgUserStorage) GetUserByID(ctx context.Context, id int64) (*models.User, error) {
-	var user models.User
-	err := s.db.GetContext(ctx, &user, GetUserByIDQuery, id)
-	if errors.Is(err, sql.ErrNoRows) {
-		return nil, nil
-	}
-	return &user, err
+	return getOne[models.User](ctx, s.db, GetUserByIDQuery, id)
 }
 
 // UpdateBalance обновляет баланс пользователя
 func (s *PgUserStorage) UpdateBalance(ctx context.Context, userID int64, delta float64) error {
-	_, err := s.db.ExecContext(ctx, UpdateBalanceQuery, userID, delta)
+	_, err := s.db.Exec(ctx, UpdateBalanceQuery, userID, delta)
 	if err != nil {
 		return fmt.Errorf("failed to update balance: %w", err)
 	}
 
 	return nil
 }
+
+// UpdatePasswordHash заменяет хеш пароля без отзыва выданных токенов
+func (s *PgUserStorage) UpdatePasswordHash(ctx context.Context, userID int64, passwordHash string) error {
+	_, err := s.db.Exec(ctx, UpdatePasswordHashQuery, userID, passwordHash)
+	if err != nil {
+		return fmt.Errorf("failed to update password hash: %w", err)
+	}
+
+	return nil
+}
+
+// ChangePassword заменяет хеш пароля и увеличивает версию токенов, возвращая новую версию
+func (s *PgUserStorage) ChangePassword(ctx context.Context, userID int64, passwordHash string) (int64, error) {
+	var tokenVersion int64
+	err := s.db.QueryRow(ctx, ChangePasswordQuery, userID, passwordHash).Scan(&tokenVersion)
+	if err != nil {
+		return 0, fmt.Errorf("failed to change password: %w", err)
+	}
+
+	return tokenVersion, nil
+}
+
+// SearchUsers возвращает пользователей, логин которых соответствует шаблону ILIKE
+func (s *PgUserStorage) SearchUsers(ctx context.Context, loginPattern string, limit int) ([]*models.User, error) {
+	return selectAll[models.User](ctx, s.db, SearchUsersQuery, loginPattern, limit)
+}
+
+// UpdateUserRole изменяет роль пользователя
+func (s *PgUserStorage) UpdateUserRole(ctx context.Context, userID int64, role string) error {
+	_, err := s.db.Exec(ctx, UpdateUserRoleQuery, userID, role)
+	if err != nil {
+		return fmt.Errorf("failed to update user role: %w", err)
+	}
+
+	return nil
+}
+
+// SetTOTPSecret сохраняет секрет TOTP, ожидающий подтверждения
+func (s *PgUserStorage) SetTOTPSecret(ctx context.Context, userID int64, secret string) error {
+	_, err := s.db.Exec(ctx, SetTOTPSecretQuery, userID, secret)
+	if err != nil {
+		return fmt.Errorf("failed to set totp secret: %w", err)
+	}
+
+	return nil
+}
+
+// EnableTOTP включает двухфакторную аутентификацию и заменяет коды восстановления одним запросом.
+// Возвращает false, если секрет не задан или аутентификация уже включена
+func (s *PgUserStorage) EnableTOTP(ctx context.Context, userID int64, lastStep int64, codeHashes []string) (bool, error) {
+	var enabled int
+	err := s.db.QueryRow(ctx, EnableTOTPQuery, userID, lastStep, codeHashes).Scan(&enabled)
+	if err != nil {
+		return false, fmt.Errorf("failed to enable totp: %w", err)
+	}
+
+	return enabled > 0, nil
+}
+
+// DisableTOTP отключает двухфакторную аутентификацию и удаляет коды восстановления
+func (s *PgUserStorage) DisableTOTP(ctx context.Context, userID int64) error {
+	_, err := s.db.Exec(ctx, DisableTOTPQuery, userID)
+	if err != nil {
+		return fmt.Errorf("failed to disable totp: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateTOTPLastStep запоминает использованный период TOTP, возвращает false, если период уже использован
+func (s *PgUserStorage) UpdateTOTPLastStep(ctx context.Context, userID int64, step int64) (bool, error) {
+	tag, err := s.db.Exec(ctx, UpdateTOTPLastStepQuery, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to update totp last step: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// ReplaceRecoveryCodes заменяет коды восстановления пользователя
+func (s *PgUserStorage) ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error {
+	_, err := s.db.Exec(ctx, ReplaceRecoveryCodesQuery, userID, codeHashes)
+	if err != nil {
+		return fmt.Errorf("failed to replace recovery codes: %w", err)
+	}
+
+	return nil
+}
+
+// UseRecoveryCode погашает код восстановления, возвращает false, если код не найден или уже использован
+func (s *PgUserStorage) UseRecoveryCode(ctx context.Context, userID int64, codeHash string, usedAt time.Time) (bool, error) {
+	tag, err := s.db.Exec(ctx, UseRecoveryCodeQuery, userID, codeHash, usedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// ConsumePreAuthToken погашает токен первого шага входа, выпущенный в issuedAt.
+// Возвращает false, если уже обменян этот или более поздний токен
+func (s *PgUserStorage) ConsumePreAuthToken(ctx context.Context, userID int64, issuedAt int64) (bool, error) {
+	tag, err := s.db.Exec(ctx, ConsumePreAuthTokenQuery, userID, issuedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to consume pre-auth token: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// AnonymizeUser обезличивает пользователя, отзывает сессии и учетные данные.
+// Возвращает false, если пользователь не найден или уже удален
+func (s *PgUserStorage) AnonymizeUser(ctx context.Context, userID int64, login string, deletedAt time.Time) (bool, error) {
+	tag, err := s.db.Exec(ctx, AnonymizeUserQuery, userID, login, deletedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to anonymize user: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
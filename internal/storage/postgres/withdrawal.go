@@ -2,21 +2,30 @@ package postgres
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/gitslim/gophermart/internal/models"
-	_ "github.com/jackc/pgx/v5/stdlib"
-	"github.com/jmoiron/sqlx"
+	"github.com/gitslim/gophermart/internal/storage"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 var (
-	CreateWithdrawalQuery string
-	GetUserWithdrawals    string
+	CreateWithdrawalQuery       string
+	WithdrawQuery               string
+	GetUserWithdrawals          string
+	GetUserWithdrawalsPageQuery string
+	ExportUserWithdrawalsQuery  string
 )
 
 func init() {
 	queries := map[string]*string{
-		"create_withdrawal.sql":    &CreateWithdrawalQuery,
-		"get_user_withdrawals.sql": &GetUserWithdrawals,
+		"create_withdrawal.sql":         &CreateWithdrawalQuery,
+		"withdraw.sql":                  &WithdrawQuery,
+		"get_user_withdrawals.sql":      &GetUserWithdrawals,
+		"get_user_withdrawals_page.sql": &GetUserWithdrawalsPageQuery,
+		"export_user_withdrawals.sql":   &ExportUserWithdrawalsQuery,
 	}
 
 	loadQueries(queries)
@@ -24,11 +33,11 @@ func init() {
 
 // PgWithdrawalStorage представляет хранилище операций списания
 type PgWithdrawalStorage struct {
-	db *sqlx.DB
+	db *pgxpool.Pool
 }
 
 // NewPgWithdrawalStorage создает новый экземпляр хранилища PostgreSQL
-func NewPgWithdrawalStorage(db *sqlx.DB) *PgWithdrawalStorage {
+func NewPgWithdrawalStorage(db *pgxpool.Pool) *PgWithdrawalStorage {
 	return &PgWithdrawalStorage{
 		db: db,
 	}
@@ -36,7 +45,7 @@ func NewPgWithdrawalStorage(db *sqlx.DB) *PgWithdrawalStorage {
 
 // CreateWithdrawal создает новую операцию списания
 func (s *PgWithdrawalStorage) CreateWithdrawal(ctx context.Context, withdrawal *models.Withdrawal) error {
-	_, err := s.db.ExecContext(ctx, CreateWithdrawalQuery,
+	_, err := s.db.Exec(ctx, CreateWithdrawalQuery,
 		withdrawal.UserID,
 		withdrawal.Order,
 		withdrawal.Sum,
@@ -45,9 +54,47 @@ func (s *PgWithdrawalStorage) CreateWithdrawal(ctx context.Context, withdrawal *
 	return err
 }
 
+// Withdraw списывает сумму с баланса пользователя и сохраняет операцию списания одним запросом.
+// Возвращает storage.ErrInsufficientFunds, если баланса недостаточно
+func (s *PgWithdrawalStorage) Withdraw(ctx context.Context, withdrawal *models.Withdrawal) error {
+	err := s.db.QueryRow(ctx, WithdrawQuery,
+		withdrawal.UserID,
+		withdrawal.Order,
+		withdrawal.Sum,
+		withdrawal.ProcessedAt,
+	).Scan(&withdrawal.ID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return storage.ErrInsufficientFunds
+	}
+	return err
+}
+
 // GetUserWithdrawals возвращает все операции списания пользователя
 func (s *PgWithdrawalStorage) GetUserWithdrawals(ctx context.Context, userID int64) ([]*models.Withdrawal, error) {
-	var withdrawals []*models.Withdrawal
-	err := s.db.SelectContext(ctx, &withdrawals, GetUserWithdrawals, userID)
-	return withdrawals, err
+	return selectAll[models.Withdrawal](ctx, s.db, GetUserWithdrawals, userID)
+}
+
+// GetUserWithdrawalsPage возвращает страницу списаний пользователя с учетом фильтров
+func (s *PgWithdrawalStorage) GetUserWithdrawalsPage(ctx context.Context, userID int64, filter models.WithdrawalFilter) ([]*models.Withdrawal, error) {
+	var afterAt *time.Time
+	var afterID int64
+	if filter.After != nil {
+		afterAt = &filter.After.At
+		afterID = filter.After.ID
+	}
+
+	return selectAll[models.Withdrawal](ctx, s.db, GetUserWithdrawalsPageQuery,
+		userID,
+		filter.From,
+		filter.To,
+		afterAt,
+		afterID,
+		filter.Limit,
+	)
+}
+
+// ExportUserWithdrawals построчно передает в fn списания пользователя с учетом фильтров, не загружая их в память целиком.
+// Позиция и размер страницы в фильтре не учитываются.
+func (s *PgWithdrawalStorage) ExportUserWithdrawals(ctx context.Context, userID int64, filter models.WithdrawalFilter, fn func(*models.Withdrawal) error) error {
+	return forEach(ctx, s.db, fn, ExportUserWithdrawalsQuery, userID, filter.From, filter.To)
 }
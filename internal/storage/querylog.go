@@ -0,0 +1,27 @@
+package storage
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/logging"
+)
+
+// SlowQueryThreshold - длительность запроса к базе данных, начиная с которой он считается медленным
+const SlowQueryThreshold = 500 * time.Millisecond
+
+// LogQuery записывает медленный или завершившийся ошибкой запрос в журнал запроса из контекста.
+// Ошибки пишутся на уровне debug: хранилище возвращает их, и сервис сам решает, как их обработать
+func LogQuery(log logging.Logger, query string, duration time.Duration, err error) {
+	if err == nil && duration < SlowQueryThreshold {
+		return
+	}
+
+	log = log.With("duration", duration.String())
+	query = strings.Join(strings.Fields(query), " ")
+	if err != nil {
+		log.Debugf("Query failed: %s: %v", query, err)
+		return
+	}
+	log.Warnf("Slow query: %s", query)
+}
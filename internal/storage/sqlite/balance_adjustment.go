@@ -0,0 +1,71 @@
+package sqlite
+
+import (
+	"context"
+
+	"github.com/gitslim/gophermart/internal/models"
+	"github.com/gitslim/gophermart/internal/storage"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	AdjustBalanceQuery             string
+	CreateBalanceAdjustmentQuery   string
+	GetUserBalanceAdjustmentsQuery string
+)
+
+func init() {
+	queries := map[string]*string{
+		"adjust_balance.sql":               &AdjustBalanceQuery,
+		"create_balance_adjustment.sql":    &CreateBalanceAdjustmentQuery,
+		"get_user_balance_adjustments.sql": &GetUserBalanceAdjustmentsQuery,
+	}
+
+	loadQueries(queries)
+}
+
+// SQLiteBalanceAdjustmentStorage представляет хранилище ручных корректировок баланса в SQLite
+type SQLiteBalanceAdjustmentStorage struct {
+	db *sqlx.DB
+}
+
+// NewSQLiteBalanceAdjustmentStorage создает новый экземпляр хранилища SQLite
+func NewSQLiteBalanceAdjustmentStorage(db *sqlx.DB) *SQLiteBalanceAdjustmentStorage {
+	return &SQLiteBalanceAdjustmentStorage{
+		db: db,
+	}
+}
+
+// CreateBalanceAdjustment изменяет баланс пользователя и сохраняет корректировку в одной транзакции.
+// Транзакция захватывает блокировку на запись при начале, поэтому баланс не меняется между проверкой и записью
+func (s *SQLiteBalanceAdjustmentStorage) CreateBalanceAdjustment(ctx context.Context, adjustment *models.BalanceAdjustment) error {
+	return withTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx, AdjustBalanceQuery, adjustment.UserID, adjustment.Amount)
+		if err != nil {
+			return err
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return storage.ErrInsufficientFunds
+		}
+
+		return tx.GetContext(ctx, &adjustment.ID, CreateBalanceAdjustmentQuery,
+			adjustment.UserID,
+			adjustment.AdminID,
+			adjustment.Amount,
+			adjustment.Reason,
+			utc(adjustment.CreatedAt),
+		)
+	})
+}
+
+// GetUserBalanceAdjustments возвращает все корректировки баланса пользователя
+func (s *SQLiteBalanceAdjustmentStorage) GetUserBalanceAdjustments(ctx context.Context, userID int64) ([]*models.BalanceAdjustment, error) {
+	var adjustments []*models.BalanceAdjustment
+	err := s.db.SelectContext(ctx, &adjustments, GetUserBalanceAdjustmentsQuery, userID)
+	return adjustments, err
+}
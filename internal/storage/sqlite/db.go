@@ -0,0 +1,111 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/conf"
+	"github.com/gitslim/gophermart/internal/logging"
+	"github.com/jmoiron/sqlx"
+	"go.uber.org/fx"
+	"modernc.org/sqlite"
+)
+
+// connParams - параметры каждого подключения. WAL позволяет читать во время записи,
+// busy_timeout заставляет ждать блокировку вместо ошибки SQLITE_BUSY, а транзакции
+// начинаются с BEGIN IMMEDIATE и сразу захватывают блокировку на запись, поэтому
+// проверка и изменение баланса в одной транзакции (списание, корректировка, зачисление
+// за заказ) не пересекаются с другими записями.
+// Время записывается в формате, который драйвер читает обратно в time.Time
+const connParams = "_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=synchronous(NORMAL)" +
+	"&_pragma=foreign_keys(1)&_txlock=immediate&_time_format=sqlite"
+
+// NewDB открывает базу данных SQLite по адресу вида sqlite://путь/к/файлу.db.
+// Файл создается при первом подключении. Медленные и завершившиеся ошибкой запросы
+// пишутся в журнал запроса из контекста
+func NewDB(config *conf.Config, log logging.Logger) (*sqlx.DB, error) {
+	db := sqlx.NewDb(sql.OpenDB(&tracingConnector{
+		dsn:    dsn(config.DatabaseURI),
+		driver: &sqlite.Driver{},
+		log:    log,
+	}), "sqlite")
+
+	// Настраиваем пул
+	db.SetMaxOpenConns(int(config.DatabaseMaxConns))
+	db.SetMaxIdleConns(int(config.DatabaseMinConns))
+	db.SetConnMaxLifetime(config.DatabaseMaxConnLifetime)
+	db.SetConnMaxIdleTime(config.DatabaseMaxConnIdleTime)
+
+	return db, nil
+}
+
+func RegisterDBHooks(lc fx.Lifecycle, log logging.Logger, db *sqlx.DB) {
+	lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			// проверяем, что файл базы открывается
+			if err := db.PingContext(ctx); err != nil {
+				return fmt.Errorf("sqlite connection error: %w", err)
+			}
+			return nil
+		},
+		OnStop: func(ctx context.Context) error {
+			// переносим журнал WAL в файл базы, чтобы копия файла была полной
+			if _, err := db.ExecContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+				log.Warnf("Failed to checkpoint sqlite WAL: %v", err)
+			}
+			return db.Close()
+		},
+	})
+}
+
+// dsn преобразует адрес базы данных в строку подключения драйвера
+func dsn(uri string) string {
+	path := strings.TrimPrefix(uri, conf.SQLiteScheme)
+	if strings.Contains(path, "?") {
+		return path + "&" + connParams
+	}
+	return path + "?" + connParams
+}
+
+// withTx выполняет fn в транзакции, транзакция откатывается, если fn вернула ошибку
+func withTx(ctx context.Context, db *sqlx.DB, fn func(tx *sqlx.Tx) error) error {
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// utc приводит время к UTC: время хранится текстом и сравнивается как строка,
+// поэтому у всех значений должно быть одно смещение
+func utc(t time.Time) time.Time {
+	return t.UTC()
+}
+
+// utcPtr приводит к UTC необязательное время
+func utcPtr(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
+
+// jsonArray передает список строк в запрос как JSON-массив для json_each
+func jsonArray(values []string) string {
+	if len(values) == 0 {
+		return "[]"
+	}
+	data, _ := json.Marshal(values)
+	return string(data)
+}
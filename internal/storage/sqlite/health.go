@@ -0,0 +1,42 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gitslim/gophermart/internal/health"
+	"github.com/jmoiron/sqlx"
+)
+
+// healthChecker проверяет доступность базы данных
+type healthChecker struct {
+	db *sqlx.DB
+}
+
+// NewHealthChecker создает проверку доступности базы данных
+func NewHealthChecker(db *sqlx.DB) health.Checker {
+	return &healthChecker{db: db}
+}
+
+func (c *healthChecker) Name() string {
+	return "database"
+}
+
+func (c *healthChecker) Critical() bool {
+	return true
+}
+
+func (c *healthChecker) Check(ctx context.Context) (map[string]interface{}, error) {
+	stats := c.db.Stats()
+	details := map[string]interface{}{
+		"open_connections": stats.OpenConnections,
+		"in_use":           stats.InUse,
+		"idle":             stats.Idle,
+	}
+
+	if err := c.db.PingContext(ctx); err != nil {
+		return details, fmt.Errorf("ping failed: %w", err)
+	}
+
+	return details, nil
+}
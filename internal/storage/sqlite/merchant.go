@@ -0,0 +1,147 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	CreateMerchantQuery     string
+	GetMerchantByIDQuery    string
+	GetMerchantByNameQuery  string
+	GetMerchantsQuery       string
+	CreateAPIKeyQuery       string
+	GetAPIKeyByIDQuery      string
+	GetAPIKeyByPrefixQuery  string
+	GetMerchantAPIKeysQuery string
+	ExpireAPIKeyQuery       string
+	RevokeAPIKeyQuery       string
+	TouchAPIKeyQuery        string
+)
+
+func init() {
+	queries := map[string]*string{
+		"create_merchant.sql":       &CreateMerchantQuery,
+		"get_merchant_by_id.sql":    &GetMerchantByIDQuery,
+		"get_merchant_by_name.sql":  &GetMerchantByNameQuery,
+		"get_merchants.sql":         &GetMerchantsQuery,
+		"create_api_key.sql":        &CreateAPIKeyQuery,
+		"get_api_key_by_id.sql":     &GetAPIKeyByIDQuery,
+		"get_api_key_by_prefix.sql": &GetAPIKeyByPrefixQuery,
+		"get_merchant_api_keys.sql": &GetMerchantAPIKeysQuery,
+		"expire_api_key.sql":        &ExpireAPIKeyQuery,
+		"revoke_api_key.sql":        &RevokeAPIKeyQuery,
+		"touch_api_key.sql":         &TouchAPIKeyQuery,
+	}
+
+	loadQueries(queries)
+}
+
+// SQLiteMerchantStorage представляет хранилище магазинов и ключей доступа в SQLite
+type SQLiteMerchantStorage struct {
+	db *sqlx.DB
+}
+
+// NewSQLiteMerchantStorage создает новый экземпляр хранилища SQLite
+func NewSQLiteMerchantStorage(db *sqlx.DB) *SQLiteMerchantStorage {
+	return &SQLiteMerchantStorage{
+		db: db,
+	}
+}
+
+// CreateMerchant создает новый магазин
+func (s *SQLiteMerchantStorage) CreateMerchant(ctx context.Context, merchant *models.Merchant) error {
+	return s.db.GetContext(ctx, &merchant.ID, CreateMerchantQuery,
+		merchant.Name,
+		utc(merchant.CreatedAt),
+	)
+}
+
+// GetMerchantByID возвращает магазин по ID
+func (s *SQLiteMerchantStorage) GetMerchantByID(ctx context.Context, id int64) (*models.Merchant, error) {
+	var merchant models.Merchant
+	err := s.db.GetContext(ctx, &merchant, GetMerchantByIDQuery, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return &merchant, err
+}
+
+// GetMerchantByName возвращает магазин по названию
+func (s *SQLiteMerchantStorage) GetMerchantByName(ctx context.Context, name string) (*models.Merchant, error) {
+	var merchant models.Merchant
+	err := s.db.GetContext(ctx, &merchant, GetMerchantByNameQuery, name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return &merchant, err
+}
+
+// GetMerchants возвращает все магазины
+func (s *SQLiteMerchantStorage) GetMerchants(ctx context.Context) ([]*models.Merchant, error) {
+	var merchants []*models.Merchant
+	err := s.db.SelectContext(ctx, &merchants, GetMerchantsQuery)
+	return merchants, err
+}
+
+// CreateAPIKey сохраняет новый ключ доступа
+func (s *SQLiteMerchantStorage) CreateAPIKey(ctx context.Context, key *models.APIKey) error {
+	return s.db.GetContext(ctx, &key.ID, CreateAPIKeyQuery,
+		key.MerchantID,
+		key.Prefix,
+		key.KeyHash,
+		key.Scopes,
+		utc(key.CreatedAt),
+		utcPtr(key.ExpiresAt),
+	)
+}
+
+// GetAPIKeyByID возвращает ключ доступа по ID
+func (s *SQLiteMerchantStorage) GetAPIKeyByID(ctx context.Context, id int64) (*models.APIKey, error) {
+	var key models.APIKey
+	err := s.db.GetContext(ctx, &key, GetAPIKeyByIDQuery, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return &key, err
+}
+
+// GetAPIKeyByPrefix возвращает ключ доступа по префиксу
+func (s *SQLiteMerchantStorage) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := s.db.GetContext(ctx, &key, GetAPIKeyByPrefixQuery, prefix)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return &key, err
+}
+
+// GetMerchantAPIKeys возвращает все ключи доступа магазина
+func (s *SQLiteMerchantStorage) GetMerchantAPIKeys(ctx context.Context, merchantID int64) ([]*models.APIKey, error) {
+	var keys []*models.APIKey
+	err := s.db.SelectContext(ctx, &keys, GetMerchantAPIKeysQuery, merchantID)
+	return keys, err
+}
+
+// ExpireAPIKey ограничивает срок действия ключа, не продлевая уже истекающий
+func (s *SQLiteMerchantStorage) ExpireAPIKey(ctx context.Context, id int64, expiresAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, ExpireAPIKeyQuery, id, utc(expiresAt))
+	return err
+}
+
+// RevokeAPIKey немедленно отзывает ключ
+func (s *SQLiteMerchantStorage) RevokeAPIKey(ctx context.Context, id int64, revokedAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, RevokeAPIKeyQuery, id, utc(revokedAt))
+	return err
+}
+
+// TouchAPIKey обновляет время последнего использования ключа
+func (s *SQLiteMerchantStorage) TouchAPIKey(ctx context.Context, id int64, usedAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, TouchAPIKeyQuery, id, utc(usedAt))
+	return err
+}
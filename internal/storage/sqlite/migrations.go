@@ -0,0 +1,49 @@
+package sqlite
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gitslim/gophermart/internal/conf"
+	"github.com/gitslim/gophermart/internal/logging"
+	schema "github.com/gitslim/gophermart/migrations"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite"
+)
+
+// NewMigrate создает экземпляр migrate со встроенными миграциями SQLite
+func NewMigrate(config *conf.Config) (*migrate.Migrate, error) {
+	src, err := schema.SQLite()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open migrations: %w", err)
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", src, config.DatabaseURI)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migrate instance: %w", err)
+	}
+
+	return m, nil
+}
+
+// RunMigrations применяет миграции при старте, если это разрешено в конфигурации
+func RunMigrations(config *conf.Config, log logging.Logger) error {
+	if !config.MigrateOnStart {
+		log.Infof("Migrations on start are disabled")
+		return nil
+	}
+
+	m, err := NewMigrate(config)
+	if err != nil {
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil {
+		if !errors.Is(err, migrate.ErrNoChange) {
+			return fmt.Errorf("failed to run migrations: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,263 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	CreateOrderQuery         string
+	CreateOrderIfAbsentQuery string
+	GetOrderByNumberQuery    string
+	GetUserOrdersQuery       string
+	UpdateOrderStatus        string
+	GetOrdersByStatuses      string
+	GetUserOrdersPageQuery   string
+	ExportUserOrdersQuery    string
+
+	CreateOrderStatusChangeQuery string
+	ApplyOrderStatusQuery        string
+	CreditOrderAccrualQuery      string
+	GetOrderStatusHistoryQuery   string
+)
+
+func init() {
+	queries := map[string]*string{
+		"create_order.sql":           &CreateOrderQuery,
+		"create_order_if_absent.sql": &CreateOrderIfAbsentQuery,
+		"get_order_by_number.sql":    &GetOrderByNumberQuery,
+		"get_user_orders.sql":        &GetUserOrdersQuery,
+		"update_order_status.sql":    &UpdateOrderStatus,
+		"get_orders_by_statuses.sql": &GetOrdersByStatuses,
+		"get_user_orders_page.sql":   &GetUserOrdersPageQuery,
+		"export_user_orders.sql":     &ExportUserOrdersQuery,
+
+		"create_order_status_change.sql": &CreateOrderStatusChangeQuery,
+		"apply_order_status.sql":         &ApplyOrderStatusQuery,
+		"credit_order_accrual.sql":       &CreditOrderAccrualQuery,
+		"get_order_status_history.sql":   &GetOrderStatusHistoryQuery,
+	}
+
+	loadQueries(queries)
+}
+
+// SQLiteOrderStorage представляет хранилище заказов в SQLite
+type SQLiteOrderStorage struct {
+	db *sqlx.DB
+}
+
+// NewSQLiteOrderStorage создает новый экземпляр хранилища SQLite
+func NewSQLiteOrderStorage(db *sqlx.DB) *SQLiteOrderStorage {
+	return &SQLiteOrderStorage{
+		db: db,
+	}
+}
+
+// CreateOrder создает новый заказ
+func (s *SQLiteOrderStorage) CreateOrder(ctx context.Context, order *models.Order) error {
+	_, err := s.db.ExecContext(ctx, CreateOrderQuery,
+		order.Number,
+		order.UserID,
+		order.Status,
+		order.Accrual,
+		utc(order.UploadedAt),
+		utc(order.ProcessedAt),
+	)
+
+	return err
+}
+
+// CreateOrders создает новые заказы пакетом в одной транзакции и возвращает результат по каждому номеру
+func (s *SQLiteOrderStorage) CreateOrders(ctx context.Context, userID int64, numbers []string, uploadedAt time.Time) ([]*models.OrderUploadResult, error) {
+	var results []*models.OrderUploadResult
+	err := withTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		// Повторы номера внутри пакета дают один результат, как SELECT DISTINCT в PostgreSQL
+		seen := make(map[string]bool, len(numbers))
+		for _, number := range numbers {
+			if seen[number] {
+				continue
+			}
+			seen[number] = true
+
+			// Начисление и время обработки заполняются нулевыми значениями так же, как в CreateOrder
+			res, err := tx.ExecContext(ctx, CreateOrderIfAbsentQuery,
+				number,
+				userID,
+				models.OrderStatusNew,
+				0,
+				utc(uploadedAt),
+				utc(time.Time{}),
+			)
+			if err != nil {
+				return err
+			}
+
+			n, err := res.RowsAffected()
+			if err != nil {
+				return err
+			}
+
+			result := &models.OrderUploadResult{Number: number, Result: models.OrderUploadAccepted}
+			if n == 0 {
+				var existing models.Order
+				if err := tx.GetContext(ctx, &existing, GetOrderByNumberQuery, number); err != nil {
+					return err
+				}
+				result.Result = models.OrderUploadConflict
+				if existing.UserID == userID {
+					result.Result = models.OrderUploadAlreadyUploaded
+				}
+			}
+			results = append(results, result)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// GetOrderByNumber возвращает заказ по номеру
+func (s *SQLiteOrderStorage) GetOrderByNumber(ctx context.Context, number string) (*models.Order, error) {
+	var order models.Order
+	err := s.db.GetContext(ctx, &order, GetOrderByNumberQuery, number)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return &order, err
+}
+
+// GetUserOrders возвращает все заказы пользователя
+func (s *SQLiteOrderStorage) GetUserOrders(ctx context.Context, userID int64) ([]*models.Order, error) {
+	var orders []*models.Order
+	err := s.db.SelectContext(ctx, &orders, GetUserOrdersQuery, userID)
+	return orders, err
+}
+
+// GetUserOrdersPage возвращает страницу заказов пользователя с учетом фильтров
+func (s *SQLiteOrderStorage) GetUserOrdersPage(ctx context.Context, userID int64, filter models.OrderFilter) ([]*models.Order, error) {
+	var afterAt *time.Time
+	var afterID int64
+	if filter.After != nil {
+		afterAt = &filter.After.At
+		afterID = filter.After.ID
+	}
+
+	var orders []*models.Order
+	err := s.db.SelectContext(ctx, &orders, GetUserOrdersPageQuery,
+		userID,
+		jsonArray(filter.Statuses),
+		utcPtr(filter.From),
+		utcPtr(filter.To),
+		utcPtr(afterAt),
+		afterID,
+		filter.Limit,
+	)
+	return orders, err
+}
+
+// ExportUserOrders построчно передает в fn заказы пользователя с учетом фильтров, не загружая их в память целиком.
+// Позиция и размер страницы в фильтре не учитываются.
+func (s *SQLiteOrderStorage) ExportUserOrders(ctx context.Context, userID int64, filter models.OrderFilter, fn func(*models.Order) error) error {
+	rows, err := s.db.QueryxContext(ctx, ExportUserOrdersQuery, userID, jsonArray(filter.Statuses), utcPtr(filter.From), utcPtr(filter.To))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var order models.Order
+		if err := rows.StructScan(&order); err != nil {
+			return err
+		}
+		if err := fn(&order); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// UpdateOrderStatus обновляет статус заказа
+func (s *SQLiteOrderStorage) UpdateOrderStatus(ctx context.Context, orderID int64, status string, accrual float64) error {
+	_, err := s.db.ExecContext(ctx, UpdateOrderStatus, orderID, status, accrual)
+	return err
+}
+
+// GetOrdersByStatuses возвращает заказы с указанными статусами
+func (s *SQLiteOrderStorage) GetOrdersByStatuses(ctx context.Context, statuses []string) ([]*models.Order, error) {
+	var orders []*models.Order
+	err := s.db.SelectContext(ctx, &orders, GetOrdersByStatuses, jsonArray(statuses))
+	return orders, err
+}
+
+// CreateOrderStatusChange сохраняет результат проверки заказа в истории обработки
+func (s *SQLiteOrderStorage) CreateOrderStatusChange(ctx context.Context, change *models.OrderStatusChange) error {
+	return s.db.GetContext(ctx, &change.ID, CreateOrderStatusChangeQuery,
+		change.OrderID,
+		change.PreviousStatus,
+		change.Status,
+		change.Accrual,
+		change.Credited,
+		utc(change.CheckedAt),
+	)
+}
+
+// ApplyOrderStatusChange в одной транзакции обновляет статус заказа, зачисляет change.Credited на баланс владельца
+// и сохраняет запись в истории, если проверка на что-то повлияла. Возвращает false, если статус заказа уже отличается от change.PreviousStatus
+func (s *SQLiteOrderStorage) ApplyOrderStatusChange(ctx context.Context, change *models.OrderStatusChange) (bool, error) {
+	var applied bool
+	err := withTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx, ApplyOrderStatusQuery, change.OrderID, change.PreviousStatus, change.Status, change.Accrual)
+		if err != nil {
+			return err
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+
+		if change.Credited > 0 {
+			if _, err := tx.ExecContext(ctx, CreditOrderAccrualQuery, change.OrderID, change.Credited); err != nil {
+				return err
+			}
+		}
+
+		applied = true
+		if !change.HasEffect() {
+			return nil
+		}
+
+		return tx.GetContext(ctx, &change.ID, CreateOrderStatusChangeQuery,
+			change.OrderID,
+			change.PreviousStatus,
+			change.Status,
+			change.Accrual,
+			change.Credited,
+			utc(change.CheckedAt),
+		)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return applied, nil
+}
+
+// GetOrderStatusHistory возвращает историю обработки заказа в хронологическом порядке
+func (s *SQLiteOrderStorage) GetOrderStatusHistory(ctx context.Context, orderID int64) ([]*models.OrderStatusChange, error) {
+	var history []*models.OrderStatusChange
+	err := s.db.SelectContext(ctx, &history, GetOrderStatusHistoryQuery, orderID)
+	return history, err
+}
@@ -0,0 +1,106 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	CreateSessionQuery       string
+	GetSessionByIDQuery      string
+	GetUserSessionsQuery     string
+	TouchSessionQuery        string
+	RevokeSessionQuery       string
+	RevokeOtherSessionsQuery string
+)
+
+func init() {
+	queries := map[string]*string{
+		"create_session.sql":        &CreateSessionQuery,
+		"get_session_by_id.sql":     &GetSessionByIDQuery,
+		"get_user_sessions.sql":     &GetUserSessionsQuery,
+		"touch_session.sql":         &TouchSessionQuery,
+		"revoke_session.sql":        &RevokeSessionQuery,
+		"revoke_other_sessions.sql": &RevokeOtherSessionsQuery,
+	}
+
+	loadQueries(queries)
+}
+
+// SQLiteSessionStorage представляет хранилище сессий в SQLite
+type SQLiteSessionStorage struct {
+	db *sqlx.DB
+}
+
+// NewSQLiteSessionStorage создает новый экземпляр хранилища SQLite
+func NewSQLiteSessionStorage(db *sqlx.DB) *SQLiteSessionStorage {
+	return &SQLiteSessionStorage{
+		db: db,
+	}
+}
+
+// CreateSession создает новую сессию
+func (s *SQLiteSessionStorage) CreateSession(ctx context.Context, session *models.Session) error {
+	return s.db.GetContext(ctx, &session.ID, CreateSessionQuery,
+		session.UserID,
+		session.UserAgent,
+		session.IP,
+		utc(session.CreatedAt),
+		utc(session.LastSeenAt),
+		utc(session.ExpiresAt),
+	)
+}
+
+// GetSessionByID возвращает сессию по ID
+func (s *SQLiteSessionStorage) GetSessionByID(ctx context.Context, id int64) (*models.Session, error) {
+	var session models.Session
+	err := s.db.GetContext(ctx, &session, GetSessionByIDQuery, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return &session, err
+}
+
+// GetUserSessions возвращает активные сессии пользователя
+func (s *SQLiteSessionStorage) GetUserSessions(ctx context.Context, userID int64, now time.Time) ([]*models.Session, error) {
+	var sessions []*models.Session
+	err := s.db.SelectContext(ctx, &sessions, GetUserSessionsQuery, userID, utc(now))
+	return sessions, err
+}
+
+// TouchSession обновляет время последней активности сессии
+func (s *SQLiteSessionStorage) TouchSession(ctx context.Context, id int64, lastSeenAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, TouchSessionQuery, id, utc(lastSeenAt))
+	return err
+}
+
+// RevokeSession отзывает сессию пользователя, возвращает false, если активная сессия не найдена
+func (s *SQLiteSessionStorage) RevokeSession(ctx context.Context, userID, id int64, revokedAt time.Time) (bool, error) {
+	res, err := s.db.ExecContext(ctx, RevokeSessionQuery, id, userID, utc(revokedAt))
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	return n > 0, nil
+}
+
+// RevokeOtherSessions отзывает все сессии пользователя, кроме указанной
+func (s *SQLiteSessionStorage) RevokeOtherSessions(ctx context.Context, userID, exceptID int64, revokedAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, RevokeOtherSessionsQuery, userID, exceptID, utc(revokedAt))
+	if err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return nil
+}
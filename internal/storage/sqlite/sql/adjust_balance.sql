@@ -0,0 +1,3 @@
+UPDATE users
+SET balance = ROUND(balance + ?2, 2)
+WHERE id = ?1 AND ROUND(balance + ?2, 2) >= 0
\ No newline at end of file
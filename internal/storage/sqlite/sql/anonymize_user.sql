@@ -0,0 +1,9 @@
+UPDATE users
+SET login = ?2,
+    password_hash = '',
+    totp_secret = '',
+    totp_enabled = FALSE,
+    totp_last_step = 0,
+    token_version = token_version + 1,
+    deleted_at = ?3
+WHERE id = ?1 AND deleted_at IS NULL
\ No newline at end of file
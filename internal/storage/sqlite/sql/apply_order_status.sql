@@ -0,0 +1,3 @@
+UPDATE orders
+SET status = ?3, accrual = ROUND(?4, 2), processed_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+WHERE id = ?1 AND status = ?2
\ No newline at end of file
@@ -0,0 +1,4 @@
+UPDATE users
+SET password_hash = ?2, token_version = token_version + 1
+WHERE id = ?1
+RETURNING token_version
\ No newline at end of file
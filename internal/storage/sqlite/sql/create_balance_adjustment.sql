@@ -0,0 +1,3 @@
+INSERT INTO balance_adjustments (user_id, admin_id, amount, reason, created_at)
+VALUES (?1, ?2, ROUND(?3, 2), ?4, ?5)
+RETURNING id
\ No newline at end of file
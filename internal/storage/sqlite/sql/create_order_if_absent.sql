@@ -0,0 +1,3 @@
+INSERT INTO orders (number, user_id, status, accrual, uploaded_at, processed_at)
+VALUES (?1, ?2, ?3, ROUND(?4, 2), ?5, ?6)
+ON CONFLICT (number) DO NOTHING
\ No newline at end of file
@@ -0,0 +1,2 @@
+INSERT INTO recovery_codes (user_id, code_hash)
+VALUES (?1, ?2)
\ No newline at end of file
@@ -0,0 +1,3 @@
+INSERT INTO users (login, password_hash, balance, role, created_at)
+VALUES (?1, ?2, ROUND(?3, 2), ?4, ?5)
+RETURNING id
\ No newline at end of file
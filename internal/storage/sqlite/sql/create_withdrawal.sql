@@ -0,0 +1,3 @@
+INSERT INTO withdrawals (user_id, order_number, sum, processed_at)
+VALUES (?1, ?2, ROUND(?3, 2), ?4)
+RETURNING id
\ No newline at end of file
@@ -0,0 +1,3 @@
+UPDATE users
+SET balance = ROUND(balance + ?2, 2)
+WHERE id = (SELECT user_id FROM orders WHERE id = ?1)
\ No newline at end of file
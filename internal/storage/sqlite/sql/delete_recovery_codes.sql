@@ -0,0 +1,2 @@
+DELETE FROM recovery_codes
+WHERE user_id = ?1
\ No newline at end of file
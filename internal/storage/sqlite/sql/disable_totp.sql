@@ -0,0 +1,3 @@
+UPDATE users
+SET totp_secret = '', totp_enabled = FALSE, totp_last_step = 0
+WHERE id = ?1
\ No newline at end of file
@@ -0,0 +1,3 @@
+UPDATE users
+SET totp_enabled = TRUE, totp_last_step = ?2
+WHERE id = ?1 AND totp_secret <> '' AND NOT totp_enabled
\ No newline at end of file
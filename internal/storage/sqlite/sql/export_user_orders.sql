@@ -0,0 +1,7 @@
+SELECT id, number, user_id, status, accrual, uploaded_at, processed_at
+FROM orders
+WHERE user_id = ?1
+  AND (json_array_length(?2) = 0 OR status IN (SELECT value FROM json_each(?2)))
+  AND (?3 IS NULL OR uploaded_at >= ?3)
+  AND (?4 IS NULL OR uploaded_at < ?4)
+ORDER BY uploaded_at DESC, id DESC
\ No newline at end of file
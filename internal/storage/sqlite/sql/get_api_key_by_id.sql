@@ -0,0 +1,3 @@
+SELECT id, merchant_id, prefix, key_hash, scopes, created_at, expires_at, revoked_at, last_used_at
+FROM merchant_api_keys
+WHERE id = ?1
\ No newline at end of file
@@ -0,0 +1,4 @@
+SELECT id, merchant_id, prefix, key_hash, scopes, created_at, expires_at, revoked_at, last_used_at
+FROM merchant_api_keys
+WHERE merchant_id = ?1
+ORDER BY created_at DESC
\ No newline at end of file
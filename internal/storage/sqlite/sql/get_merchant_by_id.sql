@@ -0,0 +1,3 @@
+SELECT id, name, created_at
+FROM merchants
+WHERE id = ?1
\ No newline at end of file
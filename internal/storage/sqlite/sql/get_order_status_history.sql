@@ -0,0 +1,4 @@
+SELECT id, order_id, previous_status, status, accrual, credited, checked_at
+FROM order_status_history
+WHERE order_id = ?1
+ORDER BY checked_at, id
\ No newline at end of file
@@ -0,0 +1,4 @@
+SELECT id, number, user_id, status, accrual, uploaded_at, processed_at
+FROM orders
+WHERE status IN (SELECT value FROM json_each(?1))
+ORDER BY uploaded_at ASC
\ No newline at end of file
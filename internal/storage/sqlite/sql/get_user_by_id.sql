@@ -0,0 +1,3 @@
+SELECT id, login, password_hash, balance, role, token_version, totp_secret, totp_enabled, totp_last_step, created_at, deleted_at
+FROM users
+WHERE id = ?1
\ No newline at end of file
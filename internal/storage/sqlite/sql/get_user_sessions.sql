@@ -0,0 +1,4 @@
+SELECT id, user_id, user_agent, ip, created_at, last_seen_at, expires_at, revoked_at
+FROM sessions
+WHERE user_id = ?1 AND revoked_at IS NULL AND expires_at > ?2
+ORDER BY last_seen_at DESC
\ No newline at end of file
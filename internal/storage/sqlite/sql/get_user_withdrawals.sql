@@ -0,0 +1,4 @@
+SELECT id, user_id, order_number, sum, processed_at
+FROM withdrawals
+WHERE user_id = ?1
+ORDER BY processed_at DESC
\ No newline at end of file
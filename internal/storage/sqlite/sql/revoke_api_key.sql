@@ -0,0 +1,3 @@
+UPDATE merchant_api_keys
+SET revoked_at = ?2
+WHERE id = ?1 AND revoked_at IS NULL
\ No newline at end of file
@@ -0,0 +1,3 @@
+UPDATE sessions
+SET revoked_at = ?2
+WHERE user_id = ?1 AND revoked_at IS NULL
\ No newline at end of file
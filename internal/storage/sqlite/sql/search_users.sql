@@ -0,0 +1,5 @@
+SELECT id, login, password_hash, balance, role, token_version, totp_secret, totp_enabled, totp_last_step, created_at, deleted_at
+FROM users
+WHERE login LIKE ?1 ESCAPE '\'
+ORDER BY login ASC
+LIMIT ?2
\ No newline at end of file
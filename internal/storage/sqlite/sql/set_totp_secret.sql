@@ -0,0 +1,3 @@
+UPDATE users
+SET totp_secret = ?2, totp_enabled = FALSE
+WHERE id = ?1 AND totp_enabled = FALSE
\ No newline at end of file
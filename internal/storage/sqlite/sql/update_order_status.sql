@@ -0,0 +1,3 @@
+UPDATE orders
+SET status = ?2, accrual = ROUND(?3, 2), processed_at = strftime('%Y-%m-%d %H:%M:%f+00:00', 'now')
+WHERE id = ?1
\ No newline at end of file
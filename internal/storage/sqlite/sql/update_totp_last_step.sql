@@ -0,0 +1,3 @@
+UPDATE users
+SET totp_last_step = ?2
+WHERE id = ?1 AND totp_last_step < ?2
\ No newline at end of file
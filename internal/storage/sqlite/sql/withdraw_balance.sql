@@ -0,0 +1,3 @@
+UPDATE users
+SET balance = ROUND(balance - ?2, 2)
+WHERE id = ?1 AND balance >= ROUND(?2, 2)
\ No newline at end of file
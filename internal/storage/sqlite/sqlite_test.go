@@ -0,0 +1,119 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/conf"
+	"github.com/gitslim/gophermart/internal/logging"
+	"github.com/gitslim/gophermart/internal/logging/sugared"
+	"github.com/gitslim/gophermart/internal/storage/storagetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConformance проверяет хранилища на новой базе во временном каталоге для каждой проверки
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storagetest.Storages {
+		config := &conf.Config{
+			DatabaseURI:             conf.SQLiteScheme + filepath.Join(t.TempDir(), "gophermart.db"),
+			DatabaseMaxConns:        4,
+			DatabaseMaxConnLifetime: time.Hour,
+			DatabaseMaxConnIdleTime: time.Minute,
+		}
+
+		m, err := NewMigrate(config)
+		require.NoError(t, err)
+		require.NoError(t, m.Up())
+		m.Close()
+
+		log, err := sugared.NewLogger()
+		require.NoError(t, err)
+
+		db, err := NewDB(config, log)
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		return storagetest.Storages{
+			Users:              NewSQLiteUserStorage(db),
+			Orders:             NewSQLiteOrderStorage(db),
+			Withdrawals:        NewSQLiteWithdrawalStorage(db),
+			BalanceAdjustments: NewSQLiteBalanceAdjustmentStorage(db),
+			Merchants:          NewSQLiteMerchantStorage(db),
+			Sessions:           NewSQLiteSessionStorage(db),
+		}
+	})
+}
+
+func TestHealthChecker(t *testing.T) {
+	log, err := sugared.NewLogger()
+	require.NoError(t, err)
+
+	db, err := NewDB(&conf.Config{
+		DatabaseURI:             conf.SQLiteScheme + filepath.Join(t.TempDir(), "gophermart.db"),
+		DatabaseMaxConns:        4,
+		DatabaseMaxConnLifetime: time.Hour,
+		DatabaseMaxConnIdleTime: time.Minute,
+	}, log)
+	require.NoError(t, err)
+
+	checker := NewHealthChecker(db)
+	assert.True(t, checker.Critical())
+
+	details, err := checker.Check(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, details, "open_connections")
+
+	// Закрытая база считается недоступной
+	require.NoError(t, db.Close())
+	_, err = checker.Check(context.Background())
+	assert.Error(t, err)
+}
+
+// recordingLogger запоминает записи уровня debug и warn
+type recordingLogger struct {
+	logging.Logger
+	entries []string
+}
+
+func (l *recordingLogger) Debugf(msg string, args ...interface{}) {
+	l.entries = append(l.entries, fmt.Sprintf(msg, args...))
+}
+
+func (l *recordingLogger) Warnf(msg string, args ...interface{}) {
+	l.entries = append(l.entries, fmt.Sprintf(msg, args...))
+}
+
+func (l *recordingLogger) With(_ ...interface{}) logging.Logger {
+	return l
+}
+
+func TestQueryLogUsesContextLogger(t *testing.T) {
+	fallback := &recordingLogger{}
+	db, err := NewDB(&conf.Config{
+		DatabaseURI:             conf.SQLiteScheme + filepath.Join(t.TempDir(), "gophermart.db"),
+		DatabaseMaxConns:        1,
+		DatabaseMaxConnLifetime: time.Hour,
+		DatabaseMaxConnIdleTime: time.Minute,
+	}, fallback)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	requestLog := &recordingLogger{}
+	ctx := logging.WithContext(context.Background(), requestLog)
+
+	// Успешный быстрый запрос не пишется в журнал
+	_, err = db.ExecContext(ctx, "SELECT 1")
+	require.NoError(t, err)
+	assert.Empty(t, requestLog.entries)
+
+	// Ошибка попадает в журнал запроса, а не в общий журнал
+	_, err = db.ExecContext(ctx, "SELECT * FROM missing")
+	require.Error(t, err)
+	require.Len(t, requestLog.entries, 1)
+	assert.Contains(t, requestLog.entries[0], "SELECT * FROM missing")
+	assert.Empty(t, fallback.entries)
+}
@@ -0,0 +1,79 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql/driver"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/logging"
+	"github.com/gitslim/gophermart/internal/storage"
+)
+
+// tracingConnector открывает подключения, которые пишут медленные и завершившиеся ошибкой запросы
+// в журнал из контекста, поэтому записи содержат идентификатор HTTP запроса или задачи воркера.
+// Подключение драйвера SQLite реализует все интерфейсы с контекстом, которые использует database/sql
+type tracingConnector struct {
+	dsn    string
+	driver driver.Driver
+	log    logging.Logger
+}
+
+func (c *tracingConnector) Connect(_ context.Context) (driver.Conn, error) {
+	conn, err := c.driver.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &tracingConn{Conn: conn, log: c.log}, nil
+}
+
+func (c *tracingConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// tracingConn передает вызовы подключению драйвера и отслеживает выполнение запросов
+type tracingConn struct {
+	driver.Conn
+	log logging.Logger
+}
+
+func (c *tracingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	result, err := c.Conn.(driver.ExecerContext).ExecContext(ctx, query, args)
+	c.trace(ctx, query, start, err)
+	return result, err
+}
+
+func (c *tracingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	rows, err := c.Conn.(driver.QueryerContext).QueryContext(ctx, query, args)
+	c.trace(ctx, query, start, err)
+	return rows, err
+}
+
+func (c *tracingConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return c.Conn.(driver.ConnPrepareContext).PrepareContext(ctx, query)
+}
+
+func (c *tracingConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return c.Conn.(driver.ConnBeginTx).BeginTx(ctx, opts)
+}
+
+func (c *tracingConn) Ping(ctx context.Context) error {
+	return c.Conn.(driver.Pinger).Ping(ctx)
+}
+
+func (c *tracingConn) ResetSession(ctx context.Context) error {
+	return c.Conn.(driver.SessionResetter).ResetSession(ctx)
+}
+
+func (c *tracingConn) IsValid() bool {
+	return c.Conn.(driver.Validator).IsValid()
+}
+
+// trace пишет запрос в журнал, если он выполнялся долго или завершился ошибкой
+func (c *tracingConn) trace(ctx context.Context, query string, start time.Time, err error) {
+	if err == driver.ErrSkip {
+		return
+	}
+	storage.LogQuery(logging.FromContext(ctx, c.log), query, time.Since(start), err)
+}
@@ -0,0 +1,307 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	CreateUserQuery          string
+	GetUserByLoginQuery      string
+	GetUserByIDQuery         string
+	UpdateBalanceQuery       string
+	UpdatePasswordHashQuery  string
+	ChangePasswordQuery      string
+	SearchUsersQuery         string
+	UpdateUserRoleQuery      string
+	SetTOTPSecretQuery       string
+	EnableTOTPQuery          string
+	DisableTOTPQuery         string
+	UpdateTOTPLastStepQuery  string
+	DeleteRecoveryCodesQuery string
+	CreateRecoveryCodeQuery  string
+	UseRecoveryCodeQuery     string
+	ConsumePreAuthTokenQuery string
+	AnonymizeUserQuery       string
+	RevokeUserSessionsQuery  string
+)
+
+func init() {
+	queries := map[string]*string{
+		"create_user.sql":            &CreateUserQuery,
+		"get_user_by_login.sql":      &GetUserByLoginQuery,
+		"get_user_by_id.sql":         &GetUserByIDQuery,
+		"update_balance.sql":         &UpdateBalanceQuery,
+		"update_password_hash.sql":   &UpdatePasswordHashQuery,
+		"change_password.sql":        &ChangePasswordQuery,
+		"search_users.sql":           &SearchUsersQuery,
+		"update_user_role.sql":       &UpdateUserRoleQuery,
+		"set_totp_secret.sql":        &SetTOTPSecretQuery,
+		"enable_totp.sql":            &EnableTOTPQuery,
+		"disable_totp.sql":           &DisableTOTPQuery,
+		"update_totp_last_step.sql":  &UpdateTOTPLastStepQuery,
+		"delete_recovery_codes.sql":  &DeleteRecoveryCodesQuery,
+		"create_recovery_code.sql":   &CreateRecoveryCodeQuery,
+		"use_recovery_code.sql":      &UseRecoveryCodeQuery,
+		"consume_pre_auth_token.sql": &ConsumePreAuthTokenQuery,
+		"anonymize_user.sql":         &AnonymizeUserQuery,
+		"revoke_user_sessions.sql":   &RevokeUserSessionsQuery,
+	}
+	loadQueries(queries)
+}
+
+// SQLiteUserStorage представляет хранилище пользователей SQLite
+type SQLiteUserStorage struct {
+	db *sqlx.DB
+}
+
+// NewSQLiteUserStorage создает новый экземпляр хранилища SQLite
+func NewSQLiteUserStorage(db *sqlx.DB) *SQLiteUserStorage {
+	return &SQLiteUserStorage{
+		db: db,
+	}
+}
+
+// CreateUser создает нового пользователя
+func (s *SQLiteUserStorage) CreateUser(ctx context.Context, user *models.User) error {
+	return s.db.GetContext(ctx, &user.ID, CreateUserQuery,
+		user.Login,
+		user.PasswordHash,
+		user.Balance,
+		user.Role,
+		utc(user.CreatedAt),
+	)
+}
+
+// GetUserByLogin возвращает пользователя по логину
+func (s *SQLiteUserStorage) GetUserByLogin(ctx context.Context, login string) (*models.User, error) {
+	var user models.User
+	err := s.db.GetContext(ctx, &user, GetUserByLoginQuery, login)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return &user, err
+}
+
+// GetUserByID возвращает пользователя по ID
+func (s *SQLiteUserStorage) GetUserByID(ctx context.Context, id int64) (*models.User, error) {
+	var user models.User
+	err := s.db.GetContext(ctx, &user, GetUserByIDQuery, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return &user, err
+}
+
+// UpdateBalance обновляет баланс пользователя
+func (s *SQLiteUserStorage) UpdateBalance(ctx context.Context, userID int64, delta float64) error {
+	_, err := s.db.ExecContext(ctx, UpdateBalanceQuery, userID, delta)
+	if err != nil {
+		return fmt.Errorf("failed to update balance: %w", err)
+	}
+
+	return nil
+}
+
+// UpdatePasswordHash заменяет хеш пароля без отзыва выданных токенов
+func (s *SQLiteUserStorage) UpdatePasswordHash(ctx context.Context, userID int64, passwordHash string) error {
+	_, err := s.db.ExecContext(ctx, UpdatePasswordHashQuery, userID, passwordHash)
+	if err != nil {
+		return fmt.Errorf("failed to update password hash: %w", err)
+	}
+
+	return nil
+}
+
+// ChangePassword заменяет хеш пароля и увеличивает версию токенов, возвращая новую версию
+func (s *SQLiteUserStorage) ChangePassword(ctx context.Context, userID int64, passwordHash string) (int64, error) {
+	var tokenVersion int64
+	err := s.db.GetContext(ctx, &tokenVersion, ChangePasswordQuery, userID, passwordHash)
+	if err != nil {
+		return 0, fmt.Errorf("failed to change password: %w", err)
+	}
+
+	return tokenVersion, nil
+}
+
+// SearchUsers возвращает пользователей, логин которых соответствует шаблону ILIKE.
+// LIKE в SQLite не различает регистр латинских букв, обратная косая черта экранирует символы шаблона
+func (s *SQLiteUserStorage) SearchUsers(ctx context.Context, loginPattern string, limit int) ([]*models.User, error) {
+	var users []*models.User
+	err := s.db.SelectContext(ctx, &users, SearchUsersQuery, loginPattern, limit)
+	return users, err
+}
+
+// UpdateUserRole изменяет роль пользователя
+func (s *SQLiteUserStorage) UpdateUserRole(ctx context.Context, userID int64, role string) error {
+	_, err := s.db.ExecContext(ctx, UpdateUserRoleQuery, userID, role)
+	if err != nil {
+		return fmt.Errorf("failed to update user role: %w", err)
+	}
+
+	return nil
+}
+
+// SetTOTPSecret сохраняет секрет TOTP, ожидающий подтверждения
+func (s *SQLiteUserStorage) SetTOTPSecret(ctx context.Context, userID int64, secret string) error {
+	_, err := s.db.ExecContext(ctx, SetTOTPSecretQuery, userID, secret)
+	if err != nil {
+		return fmt.Errorf("failed to set totp secret: %w", err)
+	}
+
+	return nil
+}
+
+// EnableTOTP включает двухфакторную аутентификацию и заменяет коды восстановления в одной транзакции.
+// Возвращает false, если секрет не задан или аутентификация уже включена
+func (s *SQLiteUserStorage) EnableTOTP(ctx context.Context, userID int64, lastStep int64, codeHashes []string) (bool, error) {
+	var enabled bool
+	err := withTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx, EnableTOTPQuery, userID, lastStep)
+		if err != nil {
+			return err
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+
+		if _, err := tx.ExecContext(ctx, DeleteRecoveryCodesQuery, userID); err != nil {
+			return err
+		}
+		for _, codeHash := range codeHashes {
+			if _, err := tx.ExecContext(ctx, CreateRecoveryCodeQuery, userID, codeHash); err != nil {
+				return err
+			}
+		}
+
+		enabled = true
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to enable totp: %w", err)
+	}
+
+	return enabled, nil
+}
+
+// DisableTOTP отключает двухфакторную аутентификацию и удаляет коды восстановления
+func (s *SQLiteUserStorage) DisableTOTP(ctx context.Context, userID int64) error {
+	err := withTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, DeleteRecoveryCodesQuery, userID); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, DisableTOTPQuery, userID)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to disable totp: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateTOTPLastStep запоминает использованный период TOTP, возвращает false, если период уже использован
+func (s *SQLiteUserStorage) UpdateTOTPLastStep(ctx context.Context, userID int64, step int64) (bool, error) {
+	res, err := s.db.ExecContext(ctx, UpdateTOTPLastStepQuery, userID, step)
+	if err != nil {
+		return false, fmt.Errorf("failed to update totp last step: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to update totp last step: %w", err)
+	}
+
+	return n > 0, nil
+}
+
+// ReplaceRecoveryCodes заменяет коды восстановления пользователя
+func (s *SQLiteUserStorage) ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error {
+	err := withTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, DeleteRecoveryCodesQuery, userID); err != nil {
+			return err
+		}
+		for _, codeHash := range codeHashes {
+			if _, err := tx.ExecContext(ctx, CreateRecoveryCodeQuery, userID, codeHash); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to replace recovery codes: %w", err)
+	}
+
+	return nil
+}
+
+// UseRecoveryCode погашает код восстановления, возвращает false, если код не найден или уже использован
+func (s *SQLiteUserStorage) UseRecoveryCode(ctx context.Context, userID int64, codeHash string, usedAt time.Time) (bool, error) {
+	res, err := s.db.ExecContext(ctx, UseRecoveryCodeQuery, userID, codeHash, utc(usedAt))
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+
+	return n > 0, nil
+}
+
+// ConsumePreAuthToken погашает токен первого шага входа, выпущенный в issuedAt.
+// Возвращает false, если уже обменян этот или более поздний токен
+func (s *SQLiteUserStorage) ConsumePreAuthToken(ctx context.Context, userID int64, issuedAt int64) (bool, error) {
+	res, err := s.db.ExecContext(ctx, ConsumePreAuthTokenQuery, userID, issuedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to consume pre-auth token: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to consume pre-auth token: %w", err)
+	}
+
+	return n > 0, nil
+}
+
+// AnonymizeUser обезличивает пользователя, отзывает сессии и учетные данные.
+// Возвращает false, если пользователь не найден или уже удален
+func (s *SQLiteUserStorage) AnonymizeUser(ctx context.Context, userID int64, login string, deletedAt time.Time) (bool, error) {
+	var anonymized bool
+	err := withTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx, AnonymizeUserQuery, userID, login, utc(deletedAt))
+		if err != nil {
+			return err
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil || n == 0 {
+			return err
+		}
+		anonymized = true
+
+		if _, err := tx.ExecContext(ctx, RevokeUserSessionsQuery, userID, utc(deletedAt)); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, DeleteRecoveryCodesQuery, userID)
+		return err
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to anonymize user: %w", err)
+	}
+
+	return anonymized, nil
+}
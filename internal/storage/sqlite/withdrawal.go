@@ -0,0 +1,129 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/models"
+	"github.com/gitslim/gophermart/internal/storage"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	CreateWithdrawalQuery       string
+	WithdrawBalanceQuery        string
+	GetUserWithdrawals          string
+	GetUserWithdrawalsPageQuery string
+	ExportUserWithdrawalsQuery  string
+)
+
+func init() {
+	queries := map[string]*string{
+		"create_withdrawal.sql":         &CreateWithdrawalQuery,
+		"withdraw_balance.sql":          &WithdrawBalanceQuery,
+		"get_user_withdrawals.sql":      &GetUserWithdrawals,
+		"get_user_withdrawals_page.sql": &GetUserWithdrawalsPageQuery,
+		"export_user_withdrawals.sql":   &ExportUserWithdrawalsQuery,
+	}
+
+	loadQueries(queries)
+}
+
+// SQLiteWithdrawalStorage представляет хранилище операций списания в SQLite
+type SQLiteWithdrawalStorage struct {
+	db *sqlx.DB
+}
+
+// NewSQLiteWithdrawalStorage создает новый экземпляр хранилища SQLite
+func NewSQLiteWithdrawalStorage(db *sqlx.DB) *SQLiteWithdrawalStorage {
+	return &SQLiteWithdrawalStorage{
+		db: db,
+	}
+}
+
+// CreateWithdrawal создает новую операцию списания
+func (s *SQLiteWithdrawalStorage) CreateWithdrawal(ctx context.Context, withdrawal *models.Withdrawal) error {
+	_, err := s.db.ExecContext(ctx, CreateWithdrawalQuery,
+		withdrawal.UserID,
+		withdrawal.Order,
+		withdrawal.Sum,
+		utc(withdrawal.ProcessedAt),
+	)
+	return err
+}
+
+// Withdraw списывает сумму с баланса пользователя и сохраняет операцию списания в одной транзакции.
+// Возвращает storage.ErrInsufficientFunds, если баланса недостаточно
+func (s *SQLiteWithdrawalStorage) Withdraw(ctx context.Context, withdrawal *models.Withdrawal) error {
+	return withTx(ctx, s.db, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx, WithdrawBalanceQuery, withdrawal.UserID, withdrawal.Sum)
+		if err != nil {
+			return err
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return storage.ErrInsufficientFunds
+		}
+
+		return tx.GetContext(ctx, &withdrawal.ID, CreateWithdrawalQuery,
+			withdrawal.UserID,
+			withdrawal.Order,
+			withdrawal.Sum,
+			utc(withdrawal.ProcessedAt),
+		)
+	})
+}
+
+// GetUserWithdrawals возвращает все операции списания пользователя
+func (s *SQLiteWithdrawalStorage) GetUserWithdrawals(ctx context.Context, userID int64) ([]*models.Withdrawal, error) {
+	var withdrawals []*models.Withdrawal
+	err := s.db.SelectContext(ctx, &withdrawals, GetUserWithdrawals, userID)
+	return withdrawals, err
+}
+
+// GetUserWithdrawalsPage возвращает страницу списаний пользователя с учетом фильтров
+func (s *SQLiteWithdrawalStorage) GetUserWithdrawalsPage(ctx context.Context, userID int64, filter models.WithdrawalFilter) ([]*models.Withdrawal, error) {
+	var afterAt *time.Time
+	var afterID int64
+	if filter.After != nil {
+		afterAt = &filter.After.At
+		afterID = filter.After.ID
+	}
+
+	var withdrawals []*models.Withdrawal
+	err := s.db.SelectContext(ctx, &withdrawals, GetUserWithdrawalsPageQuery,
+		userID,
+		utcPtr(filter.From),
+		utcPtr(filter.To),
+		utcPtr(afterAt),
+		afterID,
+		filter.Limit,
+	)
+	return withdrawals, err
+}
+
+// ExportUserWithdrawals построчно передает в fn списания пользователя с учетом фильтров, не загружая их в память целиком.
+// Позиция и размер страницы в фильтре не учитываются.
+func (s *SQLiteWithdrawalStorage) ExportUserWithdrawals(ctx context.Context, userID int64, filter models.WithdrawalFilter, fn func(*models.Withdrawal) error) error {
+	rows, err := s.db.QueryxContext(ctx, ExportUserWithdrawalsQuery, userID, utcPtr(filter.From), utcPtr(filter.To))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var withdrawal models.Withdrawal
+		if err := rows.StructScan(&withdrawal); err != nil {
+			return err
+		}
+		if err := fn(&withdrawal); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
@@ -2,29 +2,86 @@ package storage
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/gitslim/gophermart/internal/models"
 )
 
+// ErrInsufficientFunds возвращается, если операция привела бы к отрицательному балансу
+var ErrInsufficientFunds = errors.New("insufficient funds")
+
 // UserStorage определяет интерфейс для работы с пользователями
 type UserStorage interface {
 	CreateUser(ctx context.Context, user *models.User) error
 	GetUserByLogin(ctx context.Context, login string) (*models.User, error)
 	GetUserByID(ctx context.Context, id int64) (*models.User, error)
 	UpdateBalance(ctx context.Context, userID int64, delta float64) error
+	UpdatePasswordHash(ctx context.Context, userID int64, passwordHash string) error
+	ChangePassword(ctx context.Context, userID int64, passwordHash string) (int64, error)
+	SearchUsers(ctx context.Context, loginPattern string, limit int) ([]*models.User, error)
+	UpdateUserRole(ctx context.Context, userID int64, role string) error
+	SetTOTPSecret(ctx context.Context, userID int64, secret string) error
+	EnableTOTP(ctx context.Context, userID int64, lastStep int64, codeHashes []string) (bool, error)
+	DisableTOTP(ctx context.Context, userID int64) error
+	UpdateTOTPLastStep(ctx context.Context, userID int64, step int64) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID int64, codeHashes []string) error
+	UseRecoveryCode(ctx context.Context, userID int64, codeHash string, usedAt time.Time) (bool, error)
+	ConsumePreAuthToken(ctx context.Context, userID int64, issuedAt int64) (bool, error)
+	AnonymizeUser(ctx context.Context, userID int64, login string, deletedAt time.Time) (bool, error)
 }
 
 // OrderStorage определяет интерфейс для работы с заказами
 type OrderStorage interface {
 	CreateOrder(ctx context.Context, order *models.Order) error
+	CreateOrders(ctx context.Context, userID int64, numbers []string, uploadedAt time.Time) ([]*models.OrderUploadResult, error)
 	GetOrderByNumber(ctx context.Context, number string) (*models.Order, error)
 	GetUserOrders(ctx context.Context, userID int64) ([]*models.Order, error)
+	GetUserOrdersPage(ctx context.Context, userID int64, filter models.OrderFilter) ([]*models.Order, error)
+	ExportUserOrders(ctx context.Context, userID int64, filter models.OrderFilter, fn func(*models.Order) error) error
 	UpdateOrderStatus(ctx context.Context, orderID int64, status string, accrual float64) error
 	GetOrdersByStatuses(ctx context.Context, statuses []string) ([]*models.Order, error)
+	CreateOrderStatusChange(ctx context.Context, change *models.OrderStatusChange) error
+	ApplyOrderStatusChange(ctx context.Context, change *models.OrderStatusChange) (bool, error)
+	GetOrderStatusHistory(ctx context.Context, orderID int64) ([]*models.OrderStatusChange, error)
 }
 
 // WithdrawalStorage определяет интерфейс для работы со списаниями
 type WithdrawalStorage interface {
 	CreateWithdrawal(ctx context.Context, withdrawal *models.Withdrawal) error
+	Withdraw(ctx context.Context, withdrawal *models.Withdrawal) error
 	GetUserWithdrawals(ctx context.Context, userID int64) ([]*models.Withdrawal, error)
+	GetUserWithdrawalsPage(ctx context.Context, userID int64, filter models.WithdrawalFilter) ([]*models.Withdrawal, error)
+	ExportUserWithdrawals(ctx context.Context, userID int64, filter models.WithdrawalFilter, fn func(*models.Withdrawal) error) error
+}
+
+// BalanceAdjustmentStorage определяет интерфейс для работы с ручными корректировками баланса
+type BalanceAdjustmentStorage interface {
+	CreateBalanceAdjustment(ctx context.Context, adjustment *models.BalanceAdjustment) error
+	GetUserBalanceAdjustments(ctx context.Context, userID int64) ([]*models.BalanceAdjustment, error)
+}
+
+// MerchantStorage определяет интерфейс для работы с магазинами и их ключами доступа
+type MerchantStorage interface {
+	CreateMerchant(ctx context.Context, merchant *models.Merchant) error
+	GetMerchantByID(ctx context.Context, id int64) (*models.Merchant, error)
+	GetMerchantByName(ctx context.Context, name string) (*models.Merchant, error)
+	GetMerchants(ctx context.Context) ([]*models.Merchant, error)
+	CreateAPIKey(ctx context.Context, key *models.APIKey) error
+	GetAPIKeyByID(ctx context.Context, id int64) (*models.APIKey, error)
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, error)
+	GetMerchantAPIKeys(ctx context.Context, merchantID int64) ([]*models.APIKey, error)
+	ExpireAPIKey(ctx context.Context, id int64, expiresAt time.Time) error
+	RevokeAPIKey(ctx context.Context, id int64, revokedAt time.Time) error
+	TouchAPIKey(ctx context.Context, id int64, usedAt time.Time) error
+}
+
+// SessionStorage определяет интерфейс для работы с сессиями пользователей
+type SessionStorage interface {
+	CreateSession(ctx context.Context, session *models.Session) error
+	GetSessionByID(ctx context.Context, id int64) (*models.Session, error)
+	GetUserSessions(ctx context.Context, userID int64, now time.Time) ([]*models.Session, error)
+	TouchSession(ctx context.Context, id int64, lastSeenAt time.Time) error
+	RevokeSession(ctx context.Context, userID, id int64, revokedAt time.Time) (bool, error)
+	RevokeOtherSessions(ctx context.Context, userID, exceptID int64, revokedAt time.Time) error
 }
@@ -0,0 +1,891 @@
+// Package storagetest содержит общий набор проверок реализаций хранилищ.
+// Набор фиксирует поведение SQL-запросов PostgreSQL: уникальность, порядок выборок и граничные случаи,
+// на которые опираются сервисы
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/models"
+	"github.com/gitslim/gophermart/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Storages объединяет проверяемые хранилища, работающие с одними данными
+type Storages struct {
+	Users              storage.UserStorage
+	Orders             storage.OrderStorage
+	Withdrawals        storage.WithdrawalStorage
+	BalanceAdjustments storage.BalanceAdjustmentStorage
+	Merchants          storage.MerchantStorage
+	Sessions           storage.SessionStorage
+}
+
+// Factory создает хранилища с пустыми данными для одной проверки
+type Factory func(t *testing.T) Storages
+
+// base - время отсчета проверок. Время задается в UTC и с точностью до микросекунд,
+// чтобы значения совпадали после сохранения в TIMESTAMP
+var base = time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+// Run запускает все проверки для хранилищ, созданных newStorages
+func Run(t *testing.T, newStorages Factory) {
+	t.Run("Users", func(t *testing.T) { testUsers(t, newStorages) })
+	t.Run("TOTP", func(t *testing.T) { testTOTP(t, newStorages) })
+	t.Run("PreAuthToken", func(t *testing.T) { testPreAuthToken(t, newStorages) })
+	t.Run("AnonymizeUser", func(t *testing.T) { testAnonymizeUser(t, newStorages) })
+	t.Run("Orders", func(t *testing.T) { testOrders(t, newStorages) })
+	t.Run("OrdersPage", func(t *testing.T) { testOrdersPage(t, newStorages) })
+	t.Run("OrderStatusHistory", func(t *testing.T) { testOrderStatusHistory(t, newStorages) })
+	t.Run("ApplyOrderStatusChange", func(t *testing.T) { testApplyOrderStatusChange(t, newStorages) })
+	t.Run("Withdrawals", func(t *testing.T) { testWithdrawals(t, newStorages) })
+	t.Run("Withdraw", func(t *testing.T) { testWithdraw(t, newStorages) })
+	t.Run("BalanceAdjustments", func(t *testing.T) { testBalanceAdjustments(t, newStorages) })
+	t.Run("Merchants", func(t *testing.T) { testMerchants(t, newStorages) })
+	t.Run("Sessions", func(t *testing.T) { testSessions(t, newStorages) })
+}
+
+// createUser создает пользователя с указанным логином и балансом
+func createUser(t *testing.T, s Storages, login string, balance float64) *models.User {
+	t.Helper()
+
+	user := &models.User{
+		Login:        login,
+		PasswordHash: "hash-" + login,
+		Balance:      balance,
+		Role:         models.RoleUser,
+		CreatedAt:    base,
+	}
+	require.NoError(t, s.Users.CreateUser(context.Background(), user))
+	require.NotZero(t, user.ID)
+
+	return user
+}
+
+// createOrder создает заказ пользователя, загруженный через offset после base
+func createOrder(t *testing.T, s Storages, userID int64, number, status string, offset time.Duration) *models.Order {
+	t.Helper()
+	ctx := context.Background()
+
+	require.NoError(t, s.Orders.CreateOrder(ctx, &models.Order{
+		Number:     number,
+		UserID:     userID,
+		Status:     status,
+		UploadedAt: base.Add(offset),
+	}))
+
+	order, err := s.Orders.GetOrderByNumber(ctx, number)
+	require.NoError(t, err)
+	require.NotNil(t, order)
+
+	return order
+}
+
+func orderNumbers(orders []*models.Order) []string {
+	numbers := make([]string, 0, len(orders))
+	for _, o := range orders {
+		numbers = append(numbers, o.Number)
+	}
+	return numbers
+}
+
+func testUsers(t *testing.T, newStorages Factory) {
+	ctx := context.Background()
+	s := newStorages(t)
+
+	alice := createUser(t, s, "alice", 10)
+	bob := createUser(t, s, "bob", 0)
+	assert.NotEqual(t, alice.ID, bob.ID)
+
+	t.Run("get", func(t *testing.T) {
+		byLogin, err := s.Users.GetUserByLogin(ctx, "alice")
+		require.NoError(t, err)
+		require.NotNil(t, byLogin)
+		assert.Equal(t, alice.ID, byLogin.ID)
+		assert.Equal(t, "hash-alice", byLogin.PasswordHash)
+		assert.Equal(t, models.RoleUser, byLogin.Role)
+		assert.EqualValues(t, 10, byLogin.Balance)
+		assert.Zero(t, byLogin.TokenVersion)
+		assert.Nil(t, byLogin.DeletedAt)
+		assert.True(t, base.Equal(byLogin.CreatedAt))
+
+		byID, err := s.Users.GetUserByID(ctx, bob.ID)
+		require.NoError(t, err)
+		require.NotNil(t, byID)
+		assert.Equal(t, "bob", byID.Login)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		user, err := s.Users.GetUserByLogin(ctx, "nobody")
+		require.NoError(t, err)
+		assert.Nil(t, user)
+
+		user, err = s.Users.GetUserByID(ctx, -1)
+		require.NoError(t, err)
+		assert.Nil(t, user)
+	})
+
+	t.Run("duplicate login", func(t *testing.T) {
+		err := s.Users.CreateUser(ctx, &models.User{Login: "alice", Role: models.RoleUser, CreatedAt: base})
+		assert.Error(t, err)
+	})
+
+	t.Run("balance", func(t *testing.T) {
+		require.NoError(t, s.Users.UpdateBalance(ctx, alice.ID, 5.5))
+		require.NoError(t, s.Users.UpdateBalance(ctx, alice.ID, -3.25))
+
+		user, err := s.Users.GetUserByID(ctx, alice.ID)
+		require.NoError(t, err)
+		assert.InDelta(t, 12.25, user.Balance, 0.001)
+	})
+
+	t.Run("password", func(t *testing.T) {
+		require.NoError(t, s.Users.UpdatePasswordHash(ctx, bob.ID, "rehashed"))
+
+		user, err := s.Users.GetUserByID(ctx, bob.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "rehashed", user.PasswordHash)
+		assert.Zero(t, user.TokenVersion)
+
+		version, err := s.Users.ChangePassword(ctx, bob.ID, "changed")
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, version)
+
+		version, err = s.Users.ChangePassword(ctx, bob.ID, "changed again")
+		require.NoError(t, err)
+		assert.EqualValues(t, 2, version)
+
+		user, err = s.Users.GetUserByID(ctx, bob.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "changed again", user.PasswordHash)
+		assert.EqualValues(t, 2, user.TokenVersion)
+	})
+
+	t.Run("role", func(t *testing.T) {
+		require.NoError(t, s.Users.UpdateUserRole(ctx, bob.ID, models.RoleSupport))
+
+		user, err := s.Users.GetUserByID(ctx, bob.ID)
+		require.NoError(t, err)
+		assert.Equal(t, models.RoleSupport, user.Role)
+	})
+
+	t.Run("search", func(t *testing.T) {
+		createUser(t, s, "Alina", 0)
+		createUser(t, s, "albert", 0)
+		createUser(t, s, "x_y", 0)
+		createUser(t, s, "xzy", 0)
+
+		logins := func(users []*models.User) []string {
+			result := make([]string, 0, len(users))
+			for _, u := range users {
+				result = append(result, u.Login)
+			}
+			return result
+		}
+
+		// Поиск не зависит от регистра
+		users, err := s.Users.SearchUsers(ctx, "%ALI%", 10)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"alice", "Alina"}, logins(users))
+
+		// Логины упорядочены по возрастанию, limit ограничивает выборку
+		users, err = s.Users.SearchUsers(ctx, "al%e%", 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"albert", "alice"}, logins(users))
+
+		users, err = s.Users.SearchUsers(ctx, "al%e%", 1)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"albert"}, logins(users))
+
+		// Экранированный символ подчеркивания совпадает только сам с собой
+		users, err = s.Users.SearchUsers(ctx, `x\_%`, 10)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"x_y"}, logins(users))
+	})
+}
+
+func testTOTP(t *testing.T, newStorages Factory) {
+	ctx := context.Background()
+	s := newStorages(t)
+	user := createUser(t, s, "alice", 0)
+
+	get := func() *models.User {
+		u, err := s.Users.GetUserByID(ctx, user.ID)
+		require.NoError(t, err)
+		return u
+	}
+
+	// Без секрета двухфакторная аутентификация не включается и коды не сохраняются
+	ok, err := s.Users.EnableTOTP(ctx, user.ID, 10, []string{"x"})
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.False(t, get().TOTPEnabled)
+	ok, err = s.Users.UseRecoveryCode(ctx, user.ID, "x", base)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// Включение заменяет коды восстановления, оставшиеся от прежней настройки
+	require.NoError(t, s.Users.ReplaceRecoveryCodes(ctx, user.ID, []string{"stale"}))
+	require.NoError(t, s.Users.SetTOTPSecret(ctx, user.ID, "SECRET"))
+	ok, err = s.Users.EnableTOTP(ctx, user.ID, 10, []string{"first"})
+	require.NoError(t, err)
+	assert.True(t, ok)
+	u := get()
+	assert.True(t, u.TOTPEnabled)
+	assert.Equal(t, "SECRET", u.TOTPSecret)
+	assert.EqualValues(t, 10, u.TOTPLastStep)
+	ok, err = s.Users.UseRecoveryCode(ctx, user.ID, "stale", base)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// Повторное включение не заменяет выданные коды
+	ok, err = s.Users.EnableTOTP(ctx, user.ID, 12, []string{"second"})
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.EqualValues(t, 10, get().TOTPLastStep)
+	ok, err = s.Users.UseRecoveryCode(ctx, user.ID, "second", base)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = s.Users.UseRecoveryCode(ctx, user.ID, "first", base)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// Секрет включенной аутентификации не заменяется
+	require.NoError(t, s.Users.SetTOTPSecret(ctx, user.ID, "OTHER"))
+	assert.Equal(t, "SECRET", get().TOTPSecret)
+
+	// Период можно использовать только один раз и только по возрастанию
+	ok, err = s.Users.UpdateTOTPLastStep(ctx, user.ID, 10)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = s.Users.UpdateTOTPLastStep(ctx, user.ID, 11)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = s.Users.UpdateTOTPLastStep(ctx, user.ID, 9)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// Коды восстановления погашаются один раз, замена удаляет прежние коды
+	require.NoError(t, s.Users.ReplaceRecoveryCodes(ctx, user.ID, []string{"a", "b"}))
+	ok, err = s.Users.UseRecoveryCode(ctx, user.ID, "a", base)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = s.Users.UseRecoveryCode(ctx, user.ID, "a", base)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, s.Users.ReplaceRecoveryCodes(ctx, user.ID, []string{"c"}))
+	ok, err = s.Users.UseRecoveryCode(ctx, user.ID, "b", base)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// Отключение сбрасывает секрет и удаляет коды восстановления
+	require.NoError(t, s.Users.DisableTOTP(ctx, user.ID))
+	u = get()
+	assert.False(t, u.TOTPEnabled)
+	assert.Empty(t, u.TOTPSecret)
+	assert.Zero(t, u.TOTPLastStep)
+
+	ok, err = s.Users.UseRecoveryCode(ctx, user.ID, "c", base)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func testPreAuthToken(t *testing.T, newStorages Factory) {
+	ctx := context.Background()
+	s := newStorages(t)
+	alice := createUser(t, s, "alice", 0)
+	bob := createUser(t, s, "bob", 0)
+
+	consume := func(userID, issuedAt int64) bool {
+		ok, err := s.Users.ConsumePreAuthToken(ctx, userID, issuedAt)
+		require.NoError(t, err)
+		return ok
+	}
+
+	// Токен обменивается один раз, более ранние токены после этого недействительны
+	assert.True(t, consume(alice.ID, 100))
+	assert.False(t, consume(alice.ID, 100))
+	assert.False(t, consume(alice.ID, 50))
+	assert.True(t, consume(alice.ID, 200))
+
+	// Токены разных пользователей независимы
+	assert.True(t, consume(bob.ID, 100))
+
+	assert.False(t, consume(-1, 100))
+}
+
+func testAnonymizeUser(t *testing.T, newStorages Factory) {
+	ctx := context.Background()
+	s := newStorages(t)
+	user := createUser(t, s, "alice", 7)
+
+	session := &models.Session{UserID: user.ID, CreatedAt: base, LastSeenAt: base, ExpiresAt: base.Add(time.Hour)}
+	require.NoError(t, s.Sessions.CreateSession(ctx, session))
+	require.NoError(t, s.Users.ReplaceRecoveryCodes(ctx, user.ID, []string{"code"}))
+
+	deletedAt := base.Add(time.Minute)
+	ok, err := s.Users.AnonymizeUser(ctx, user.ID, "deleted-1", deletedAt)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	u, err := s.Users.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "deleted-1", u.Login)
+	assert.Empty(t, u.PasswordHash)
+	assert.EqualValues(t, 1, u.TokenVersion)
+	assert.EqualValues(t, 7, u.Balance)
+	require.NotNil(t, u.DeletedAt)
+	assert.True(t, deletedAt.Equal(*u.DeletedAt))
+
+	// Старый логин освобождается
+	old, err := s.Users.GetUserByLogin(ctx, "alice")
+	require.NoError(t, err)
+	assert.Nil(t, old)
+
+	sessions, err := s.Sessions.GetUserSessions(ctx, user.ID, base)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+
+	used, err := s.Users.UseRecoveryCode(ctx, user.ID, "code", base)
+	require.NoError(t, err)
+	assert.False(t, used)
+
+	// Повторное удаление не меняет пользователя
+	ok, err = s.Users.AnonymizeUser(ctx, user.ID, "deleted-2", deletedAt)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	ok, err = s.Users.AnonymizeUser(ctx, -1, "deleted-3", deletedAt)
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
+
+func testOrders(t *testing.T, newStorages Factory) {
+	ctx := context.Background()
+	s := newStorages(t)
+	alice := createUser(t, s, "alice", 0)
+	bob := createUser(t, s, "bob", 0)
+
+	first := createOrder(t, s, alice.ID, "100", models.OrderStatusNew, 0)
+	assert.Equal(t, alice.ID, first.UserID)
+	assert.Equal(t, models.OrderStatusNew, first.Status)
+	assert.True(t, base.Equal(first.UploadedAt))
+	createOrder(t, s, alice.ID, "200", models.OrderStatusProcessing, time.Minute)
+	createOrder(t, s, bob.ID, "300", models.OrderStatusNew, 2*time.Minute)
+
+	t.Run("unique number", func(t *testing.T) {
+		err := s.Orders.CreateOrder(ctx, &models.Order{Number: "100", UserID: bob.ID, Status: models.OrderStatusNew, UploadedAt: base})
+		assert.Error(t, err)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		order, err := s.Orders.GetOrderByNumber(ctx, "999")
+		require.NoError(t, err)
+		assert.Nil(t, order)
+	})
+
+	t.Run("user orders newest first", func(t *testing.T) {
+		orders, err := s.Orders.GetUserOrders(ctx, alice.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"200", "100"}, orderNumbers(orders))
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		results, err := s.Orders.CreateOrders(ctx, alice.ID, []string{"400", "100", "300", "400"}, base.Add(3*time.Minute))
+		require.NoError(t, err)
+
+		byNumber := make(map[string]string, len(results))
+		for _, r := range results {
+			byNumber[r.Number] = r.Result
+		}
+		assert.Len(t, results, 3, "duplicate numbers in a batch produce a single result")
+		assert.Equal(t, map[string]string{
+			"400": models.OrderUploadAccepted,
+			"100": models.OrderUploadAlreadyUploaded,
+			"300": models.OrderUploadConflict,
+		}, byNumber)
+
+		order, err := s.Orders.GetOrderByNumber(ctx, "400")
+		require.NoError(t, err)
+		require.NotNil(t, order)
+		assert.Equal(t, alice.ID, order.UserID)
+		assert.Equal(t, models.OrderStatusNew, order.Status)
+
+		conflicted, err := s.Orders.GetOrderByNumber(ctx, "300")
+		require.NoError(t, err)
+		assert.Equal(t, bob.ID, conflicted.UserID)
+	})
+
+	t.Run("status", func(t *testing.T) {
+		require.NoError(t, s.Orders.UpdateOrderStatus(ctx, first.ID, models.OrderStatusProcessed, 42.5))
+
+		order, err := s.Orders.GetOrderByNumber(ctx, "100")
+		require.NoError(t, err)
+		assert.Equal(t, models.OrderStatusProcessed, order.Status)
+		assert.InDelta(t, 42.5, order.Accrual, 0.001)
+		assert.False(t, order.ProcessedAt.IsZero())
+	})
+
+	t.Run("by statuses oldest first", func(t *testing.T) {
+		orders, err := s.Orders.GetOrdersByStatuses(ctx, []string{models.OrderStatusNew, models.OrderStatusProcessing})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"200", "300", "400"}, orderNumbers(orders))
+	})
+
+	t.Run("concurrent batches", func(t *testing.T) {
+		// Номер, вставленный параллельной загрузкой того же пользователя, считается уже загруженным, а не чужим
+		const uploads = 8
+		results := make(chan string, uploads)
+		var wg sync.WaitGroup
+		for i := 0; i < uploads; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				r, err := s.Orders.CreateOrders(ctx, alice.ID, []string{"500"}, base.Add(4*time.Minute))
+				if assert.NoError(t, err) && assert.Len(t, r, 1) {
+					results <- r[0].Result
+				}
+			}()
+		}
+		wg.Wait()
+		close(results)
+
+		counts := make(map[string]int)
+		for r := range results {
+			counts[r]++
+		}
+		assert.Equal(t, map[string]int{
+			models.OrderUploadAccepted:        1,
+			models.OrderUploadAlreadyUploaded: uploads - 1,
+		}, counts)
+	})
+}
+
+func testOrdersPage(t *testing.T, newStorages Factory) {
+	ctx := context.Background()
+	s := newStorages(t)
+	user := createUser(t, s, "alice", 0)
+	other := createUser(t, s, "bob", 0)
+
+	// Два заказа с одинаковым временем загрузки упорядочиваются по ID
+	createOrder(t, s, user.ID, "1", models.OrderStatusNew, 0)
+	createOrder(t, s, user.ID, "2", models.OrderStatusProcessed, time.Minute)
+	createOrder(t, s, user.ID, "3", models.OrderStatusNew, time.Minute)
+	createOrder(t, s, user.ID, "4", models.OrderStatusInvalid, 2*time.Minute)
+	createOrder(t, s, other.ID, "5", models.OrderStatusNew, time.Minute)
+
+	page := func(filter models.OrderFilter) []*models.Order {
+		t.Helper()
+		orders, err := s.Orders.GetUserOrdersPage(ctx, user.ID, filter)
+		require.NoError(t, err)
+		return orders
+	}
+
+	first := page(models.OrderFilter{Limit: 2})
+	assert.Equal(t, []string{"4", "3"}, orderNumbers(first))
+
+	last := first[len(first)-1]
+	second := page(models.OrderFilter{Limit: 2, After: &models.Cursor{At: last.UploadedAt, ID: last.ID}})
+	assert.Equal(t, []string{"2", "1"}, orderNumbers(second))
+
+	assert.Equal(t, []string{"3", "1"}, orderNumbers(page(models.OrderFilter{Limit: 10, Statuses: []string{models.OrderStatusNew}})))
+
+	from, to := base.Add(time.Minute), base.Add(2*time.Minute)
+	assert.Equal(t, []string{"3", "2"}, orderNumbers(page(models.OrderFilter{Limit: 10, From: &from, To: &to})))
+
+	var exported []*models.Order
+	err := s.Orders.ExportUserOrders(ctx, user.ID, models.OrderFilter{From: &from, Limit: 1}, func(o *models.Order) error {
+		exported = append(exported, o)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"4", "3", "2"}, orderNumbers(exported), "export ignores the page size")
+
+	stop := errors.New("stop")
+	calls := 0
+	err = s.Orders.ExportUserOrders(ctx, user.ID, models.OrderFilter{}, func(*models.Order) error {
+		calls++
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls)
+}
+
+func testOrderStatusHistory(t *testing.T, newStorages Factory) {
+	ctx := context.Background()
+	s := newStorages(t)
+	user := createUser(t, s, "alice", 0)
+	order := createOrder(t, s, user.ID, "100", models.OrderStatusNew, 0)
+	other := createOrder(t, s, user.ID, "200", models.OrderStatusNew, 0)
+
+	changes := []*models.OrderStatusChange{
+		{OrderID: order.ID, PreviousStatus: models.OrderStatusProcessing, Status: models.OrderStatusProcessed, Accrual: 10, Credited: 10, CheckedAt: base.Add(2 * time.Second)},
+		{OrderID: order.ID, PreviousStatus: models.OrderStatusNew, Status: models.OrderStatusProcessing, CheckedAt: base.Add(time.Second)},
+		{OrderID: other.ID, PreviousStatus: models.OrderStatusNew, Status: models.OrderStatusInvalid, CheckedAt: base},
+	}
+	for _, c := range changes {
+		require.NoError(t, s.Orders.CreateOrderStatusChange(ctx, c))
+		assert.NotZero(t, c.ID)
+	}
+
+	history, err := s.Orders.GetOrderStatusHistory(ctx, order.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	assert.Equal(t, models.OrderStatusProcessing, history[0].Status)
+	assert.Equal(t, models.OrderStatusProcessed, history[1].Status)
+	assert.InDelta(t, 10, history[1].Credited, 0.001)
+	assert.True(t, base.Add(2*time.Second).Equal(history[1].CheckedAt))
+
+	history, err = s.Orders.GetOrderStatusHistory(ctx, -1)
+	require.NoError(t, err)
+	assert.Empty(t, history)
+}
+
+func testApplyOrderStatusChange(t *testing.T, newStorages Factory) {
+	ctx := context.Background()
+	s := newStorages(t)
+	user := createUser(t, s, "alice", 1)
+	order := createOrder(t, s, user.ID, "100", models.OrderStatusProcessing, 0)
+
+	// Проверка без смены статуса и зачисления применяется, но не попадает в историю
+	applied, err := s.Orders.ApplyOrderStatusChange(ctx, &models.OrderStatusChange{
+		OrderID:        order.ID,
+		PreviousStatus: models.OrderStatusProcessing,
+		Status:         models.OrderStatusProcessing,
+		CheckedAt:      base,
+	})
+	require.NoError(t, err)
+	assert.True(t, applied)
+
+	history, err := s.Orders.GetOrderStatusHistory(ctx, order.ID)
+	require.NoError(t, err)
+	assert.Empty(t, history)
+
+	change := &models.OrderStatusChange{
+		OrderID:        order.ID,
+		PreviousStatus: models.OrderStatusProcessing,
+		Status:         models.OrderStatusProcessed,
+		Accrual:        42.5,
+		Credited:       42.5,
+		CheckedAt:      base.Add(time.Second),
+	}
+	applied, err = s.Orders.ApplyOrderStatusChange(ctx, change)
+	require.NoError(t, err)
+	assert.True(t, applied)
+
+	got, err := s.Orders.GetOrderByNumber(ctx, order.Number)
+	require.NoError(t, err)
+	assert.Equal(t, models.OrderStatusProcessed, got.Status)
+	assert.InDelta(t, 42.5, got.Accrual, 0.001)
+
+	u, err := s.Users.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.InDelta(t, 43.5, u.Balance, 0.001)
+
+	history, err = s.Orders.GetOrderStatusHistory(ctx, order.ID)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.InDelta(t, 42.5, history[0].Credited, 0.001)
+
+	// Повторное применение к уже обработанному заказу не зачисляет начисление второй раз
+	applied, err = s.Orders.ApplyOrderStatusChange(ctx, change)
+	require.NoError(t, err)
+	assert.False(t, applied)
+
+	u, err = s.Users.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.InDelta(t, 43.5, u.Balance, 0.001)
+
+	history, err = s.Orders.GetOrderStatusHistory(ctx, order.ID)
+	require.NoError(t, err)
+	assert.Len(t, history, 1)
+}
+
+func testWithdrawals(t *testing.T, newStorages Factory) {
+	ctx := context.Background()
+	s := newStorages(t)
+	user := createUser(t, s, "alice", 0)
+	other := createUser(t, s, "bob", 0)
+
+	for i, w := range []*models.Withdrawal{
+		{UserID: user.ID, Order: "1", Sum: 1, ProcessedAt: base},
+		{UserID: user.ID, Order: "2", Sum: 2, ProcessedAt: base.Add(time.Minute)},
+		{UserID: user.ID, Order: "3", Sum: 3, ProcessedAt: base.Add(time.Minute)},
+		{UserID: other.ID, Order: "4", Sum: 4, ProcessedAt: base.Add(time.Minute)},
+	} {
+		require.NoError(t, s.Withdrawals.CreateWithdrawal(ctx, w), "withdrawal %d", i)
+	}
+
+	orders := func(withdrawals []*models.Withdrawal) []string {
+		numbers := make([]string, 0, len(withdrawals))
+		for _, w := range withdrawals {
+			numbers = append(numbers, w.Order)
+		}
+		return numbers
+	}
+
+	all, err := s.Withdrawals.GetUserWithdrawals(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, "1", all[2].Order)
+	assert.InDelta(t, 1, all[2].Sum, 0.001)
+
+	first, err := s.Withdrawals.GetUserWithdrawalsPage(ctx, user.ID, models.WithdrawalFilter{Limit: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"3", "2"}, orders(first))
+
+	last := first[len(first)-1]
+	second, err := s.Withdrawals.GetUserWithdrawalsPage(ctx, user.ID, models.WithdrawalFilter{Limit: 2, After: &models.Cursor{At: last.ProcessedAt, ID: last.ID}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1"}, orders(second))
+
+	to := base.Add(time.Minute)
+	var exported []*models.Withdrawal
+	err = s.Withdrawals.ExportUserWithdrawals(ctx, user.ID, models.WithdrawalFilter{To: &to}, func(w *models.Withdrawal) error {
+		exported = append(exported, w)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"1"}, orders(exported))
+}
+
+func testWithdraw(t *testing.T, newStorages Factory) {
+	ctx := context.Background()
+	s := newStorages(t)
+	user := createUser(t, s, "alice", 5)
+
+	withdrawal := &models.Withdrawal{UserID: user.ID, Order: "1", Sum: 5.01, ProcessedAt: base}
+	assert.ErrorIs(t, s.Withdrawals.Withdraw(ctx, withdrawal), storage.ErrInsufficientFunds)
+
+	// Параллельные списания не уводят баланс в минус: проходит ровно столько, сколько покрывает баланс
+	const attempts = 20
+	var wg sync.WaitGroup
+	results := make(chan error, attempts)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results <- s.Withdrawals.Withdraw(ctx, &models.Withdrawal{
+				UserID:      user.ID,
+				Order:       strconv.Itoa(i),
+				Sum:         1,
+				ProcessedAt: base.Add(time.Duration(i) * time.Second),
+			})
+		}(i)
+	}
+	wg.Wait()
+	close(results)
+
+	var succeeded int
+	for err := range results {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		assert.ErrorIs(t, err, storage.ErrInsufficientFunds)
+	}
+	assert.Equal(t, 5, succeeded)
+
+	u, err := s.Users.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.InDelta(t, 0, u.Balance, 0.001)
+
+	withdrawals, err := s.Withdrawals.GetUserWithdrawals(ctx, user.ID)
+	require.NoError(t, err)
+	assert.Len(t, withdrawals, 5)
+}
+
+func testBalanceAdjustments(t *testing.T, newStorages Factory) {
+	ctx := context.Background()
+	s := newStorages(t)
+	user := createUser(t, s, "alice", 5)
+	admin := createUser(t, s, "admin", 0)
+
+	credit := &models.BalanceAdjustment{UserID: user.ID, AdminID: admin.ID, Amount: 10, Reason: "bonus", CreatedAt: base}
+	require.NoError(t, s.BalanceAdjustments.CreateBalanceAdjustment(ctx, credit))
+	assert.NotZero(t, credit.ID)
+
+	debit := &models.BalanceAdjustment{UserID: user.ID, AdminID: admin.ID, Amount: -15, Reason: "correction", CreatedAt: base.Add(time.Minute)}
+	require.NoError(t, s.BalanceAdjustments.CreateBalanceAdjustment(ctx, debit))
+
+	// Корректировка, уводящая баланс в минус, не применяется и не сохраняется
+	overdraft := &models.BalanceAdjustment{UserID: user.ID, AdminID: admin.ID, Amount: -0.01, Reason: "overdraft", CreatedAt: base.Add(2 * time.Minute)}
+	err := s.BalanceAdjustments.CreateBalanceAdjustment(ctx, overdraft)
+	assert.ErrorIs(t, err, storage.ErrInsufficientFunds)
+
+	u, err := s.Users.GetUserByID(ctx, user.ID)
+	require.NoError(t, err)
+	assert.InDelta(t, 0, u.Balance, 0.001)
+
+	adjustments, err := s.BalanceAdjustments.GetUserBalanceAdjustments(ctx, user.ID)
+	require.NoError(t, err)
+	require.Len(t, adjustments, 2)
+	assert.Equal(t, "correction", adjustments[0].Reason)
+	assert.Equal(t, "bonus", adjustments[1].Reason)
+	assert.Equal(t, admin.ID, adjustments[1].AdminID)
+}
+
+func testMerchants(t *testing.T, newStorages Factory) {
+	ctx := context.Background()
+	s := newStorages(t)
+
+	shop := &models.Merchant{Name: "shop", CreatedAt: base}
+	require.NoError(t, s.Merchants.CreateMerchant(ctx, shop))
+	require.NoError(t, s.Merchants.CreateMerchant(ctx, &models.Merchant{Name: "bakery", CreatedAt: base}))
+	assert.Error(t, s.Merchants.CreateMerchant(ctx, &models.Merchant{Name: "shop", CreatedAt: base}))
+
+	byID, err := s.Merchants.GetMerchantByID(ctx, shop.ID)
+	require.NoError(t, err)
+	require.NotNil(t, byID)
+	assert.Equal(t, "shop", byID.Name)
+
+	byName, err := s.Merchants.GetMerchantByName(ctx, "shop")
+	require.NoError(t, err)
+	require.NotNil(t, byName)
+	assert.Equal(t, shop.ID, byName.ID)
+
+	missing, err := s.Merchants.GetMerchantByName(ctx, "nobody")
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	merchants, err := s.Merchants.GetMerchants(ctx)
+	require.NoError(t, err)
+	require.Len(t, merchants, 2)
+	assert.Equal(t, "bakery", merchants[0].Name)
+
+	expiresAt := base.Add(time.Hour)
+	older := &models.APIKey{MerchantID: shop.ID, Prefix: "old", KeyHash: "h1", Scopes: models.ScopeOrdersWrite, CreatedAt: base, ExpiresAt: &expiresAt}
+	newer := &models.APIKey{MerchantID: shop.ID, Prefix: "new", KeyHash: "h2", Scopes: models.ScopeOrdersWrite, CreatedAt: base.Add(time.Minute)}
+	require.NoError(t, s.Merchants.CreateAPIKey(ctx, older))
+	require.NoError(t, s.Merchants.CreateAPIKey(ctx, newer))
+	assert.Error(t, s.Merchants.CreateAPIKey(ctx, &models.APIKey{MerchantID: shop.ID, Prefix: "old", KeyHash: "h3", CreatedAt: base}))
+
+	key, err := s.Merchants.GetAPIKeyByPrefix(ctx, "old")
+	require.NoError(t, err)
+	require.NotNil(t, key)
+	assert.Equal(t, older.ID, key.ID)
+	assert.Equal(t, "h1", key.KeyHash)
+	require.NotNil(t, key.ExpiresAt)
+	assert.Nil(t, key.RevokedAt)
+	assert.Nil(t, key.LastUsedAt)
+
+	keys, err := s.Merchants.GetMerchantAPIKeys(ctx, shop.ID)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, "new", keys[0].Prefix)
+
+	// Срок действия только сокращается
+	require.NoError(t, s.Merchants.ExpireAPIKey(ctx, older.ID, base.Add(2*time.Hour)))
+	require.NoError(t, s.Merchants.ExpireAPIKey(ctx, newer.ID, base.Add(2*time.Hour)))
+	require.NoError(t, s.Merchants.ExpireAPIKey(ctx, newer.ID, base.Add(3*time.Hour)))
+
+	key, err = s.Merchants.GetAPIKeyByID(ctx, older.ID)
+	require.NoError(t, err)
+	assert.True(t, expiresAt.Equal(*key.ExpiresAt))
+	key, err = s.Merchants.GetAPIKeyByID(ctx, newer.ID)
+	require.NoError(t, err)
+	assert.True(t, base.Add(2*time.Hour).Equal(*key.ExpiresAt))
+
+	// Ключ отзывается один раз, время отзыва не переписывается
+	require.NoError(t, s.Merchants.RevokeAPIKey(ctx, newer.ID, base))
+	require.NoError(t, s.Merchants.RevokeAPIKey(ctx, newer.ID, base.Add(time.Minute)))
+	require.NoError(t, s.Merchants.TouchAPIKey(ctx, newer.ID, base.Add(time.Second)))
+
+	key, err = s.Merchants.GetAPIKeyByID(ctx, newer.ID)
+	require.NoError(t, err)
+	require.NotNil(t, key.RevokedAt)
+	assert.True(t, base.Equal(*key.RevokedAt))
+	require.NotNil(t, key.LastUsedAt)
+	assert.True(t, base.Add(time.Second).Equal(*key.LastUsedAt))
+
+	key, err = s.Merchants.GetAPIKeyByID(ctx, -1)
+	require.NoError(t, err)
+	assert.Nil(t, key)
+}
+
+func testSessions(t *testing.T, newStorages Factory) {
+	ctx := context.Background()
+	s := newStorages(t)
+	user := createUser(t, s, "alice", 0)
+	other := createUser(t, s, "bob", 0)
+
+	newSession := func(userID int64, lastSeen, ttl time.Duration) *models.Session {
+		t.Helper()
+		session := &models.Session{
+			UserID:     userID,
+			UserAgent:  "test",
+			IP:         "127.0.0.1",
+			CreatedAt:  base,
+			LastSeenAt: base.Add(lastSeen),
+			ExpiresAt:  base.Add(ttl),
+		}
+		require.NoError(t, s.Sessions.CreateSession(ctx, session))
+		require.NotZero(t, session.ID)
+		return session
+	}
+
+	current := newSession(user.ID, time.Minute, time.Hour)
+	older := newSession(user.ID, 0, time.Hour)
+	expired := newSession(user.ID, 2*time.Minute, time.Second)
+	foreign := newSession(other.ID, 0, time.Hour)
+
+	got, err := s.Sessions.GetSessionByID(ctx, current.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, "127.0.0.1", got.IP)
+	assert.Nil(t, got.RevokedAt)
+
+	missing, err := s.Sessions.GetSessionByID(ctx, -1)
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+
+	ids := func(sessions []*models.Session) []int64 {
+		result := make([]int64, 0, len(sessions))
+		for _, session := range sessions {
+			result = append(result, session.ID)
+		}
+		return result
+	}
+
+	now := base.Add(time.Minute)
+	active, err := s.Sessions.GetUserSessions(ctx, user.ID, now)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{current.ID, older.ID}, ids(active), "active sessions, most recently seen first")
+
+	require.NoError(t, s.Sessions.TouchSession(ctx, older.ID, base.Add(5*time.Minute)))
+	active, err = s.Sessions.GetUserSessions(ctx, user.ID, now)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{older.ID, current.ID}, ids(active))
+
+	// Чужую сессию отозвать нельзя, своя отзывается один раз
+	ok, err := s.Sessions.RevokeSession(ctx, user.ID, foreign.ID, now)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = s.Sessions.RevokeSession(ctx, user.ID, expired.ID, now)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = s.Sessions.RevokeSession(ctx, user.ID, expired.ID, now)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, s.Sessions.RevokeOtherSessions(ctx, user.ID, current.ID, now))
+	active, err = s.Sessions.GetUserSessions(ctx, user.ID, now)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{current.ID}, ids(active))
+
+	got, err = s.Sessions.GetSessionByID(ctx, older.ID)
+	require.NoError(t, err)
+	require.NotNil(t, got.RevokedAt)
+	assert.True(t, now.Equal(*got.RevokedAt))
+
+	active, err = s.Sessions.GetUserSessions(ctx, other.ID, now)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{foreign.ID}, ids(active))
+}
@@ -0,0 +1,78 @@
+package totp
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// cipherInfo отделяет ключ шифрования секретов от других ключей, производных от секретного ключа сервиса
+const cipherInfo = "gophermart totp secret"
+
+// Cipher шифрует секреты TOTP, чтобы утечка базы данных не раскрывала их
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher создает шифр с ключом AES-256, производным от секретного ключа сервиса
+func NewCipher(secretKey string) (*Cipher, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, []byte(secretKey), nil, []byte(cipherInfo)), key); err != nil {
+		return nil, fmt.Errorf("failed to derive totp key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create totp cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create totp cipher: %w", err)
+	}
+
+	return &Cipher{aead: aead}, nil
+}
+
+// Seal шифрует секрет пользователя. Идентификатор пользователя входит в проверяемые данные,
+// поэтому зашифрованный секрет нельзя перенести в запись другого пользователя
+func (c *Cipher) Seal(secret string, userID int64) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, []byte(secret), additionalData(userID))
+	return base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Open расшифровывает секрет пользователя
+func (c *Cipher) Open(sealed string, userID int64) (string, error) {
+	data, err := base64.RawStdEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted totp secret: %w", err)
+	}
+	if len(data) < c.aead.NonceSize() {
+		return "", errors.New("invalid encrypted totp secret: too short")
+	}
+
+	nonce, ciphertext := data[:c.aead.NonceSize()], data[c.aead.NonceSize():]
+	secret, err := c.aead.Open(nil, nonce, ciphertext, additionalData(userID))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt totp secret: %w", err)
+	}
+
+	return string(secret), nil
+}
+
+// additionalData возвращает проверяемые данные для секрета пользователя
+func additionalData(userID int64) []byte {
+	return []byte(strconv.FormatInt(userID, 10))
+}
@@ -0,0 +1,75 @@
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Тестовые векторы RFC 6238 для SHA1 (последние 6 цифр)
+func TestCodeRFC6238(t *testing.T) {
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		code, err := Code(secret, Step(time.Unix(tt.unix, 0)))
+		require.NoError(t, err)
+		require.Equal(t, tt.code, code)
+	}
+}
+
+func TestValidateRejectsReplay(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+
+	now := time.Now()
+	code, err := Code(secret, Step(now))
+	require.NoError(t, err)
+
+	step, ok := Validate(secret, code, now, 0)
+	require.True(t, ok)
+
+	_, ok = Validate(secret, code, now, step)
+	require.False(t, ok)
+}
+
+func TestCipher(t *testing.T) {
+	c, err := NewCipher("secret")
+	require.NoError(t, err)
+
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+
+	sealed, err := c.Seal(secret, 1)
+	require.NoError(t, err)
+	require.NotContains(t, sealed, secret)
+	require.LessOrEqual(t, len(sealed), 128, "encrypted secret must fit the totp_secret column")
+
+	opened, err := c.Open(sealed, 1)
+	require.NoError(t, err)
+	require.Equal(t, secret, opened)
+
+	// Секрет привязан к пользователю и ключу сервиса
+	_, err = c.Open(sealed, 2)
+	require.Error(t, err)
+
+	other, err := NewCipher("other")
+	require.NoError(t, err)
+	_, err = other.Open(sealed, 1)
+	require.Error(t, err)
+
+	_, err = c.Open(secret, 1)
+	require.Error(t, err)
+}
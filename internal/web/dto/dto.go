@@ -1,8 +1,14 @@
 package dto
 
+import (
+	"time"
+
+	"github.com/gitslim/gophermart/internal/models"
+)
+
 // UserRequest представляет запрос для регистрации/входа пользователя
 type UserRequest struct {
-	Login    string `json:"login" binding:"required"`
+	Login    string `json:"login" binding:"required,max=255"`
 	Password string `json:"password" binding:"required"`
 }
 
@@ -14,6 +20,134 @@ type BalanceResponse struct {
 
 // WithdrawRequest представляет запрос на списание средств
 type WithdrawRequest struct {
-	Order string  `json:"order"`
-	Sum   float64 `json:"sum"`
+	Order string  `json:"order" binding:"required,max=64"`
+	Sum   float64 `json:"sum" binding:"required,gt=0"`
+}
+
+// ChangePasswordRequest представляет запрос на смену пароля
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// BalanceAdjustmentRequest представляет запрос на ручную корректировку баланса
+type BalanceAdjustmentRequest struct {
+	Amount float64 `json:"amount" binding:"required"`
+	Reason string  `json:"reason" binding:"required"`
+}
+
+// UserRoleRequest представляет запрос на назначение роли пользователю
+type UserRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// AdminUserResponse представляет пользователя в административном API
+type AdminUserResponse struct {
+	ID        int64     `json:"id"`
+	Login     string    `json:"login"`
+	Role      string    `json:"role"`
+	Balance   float64   `json:"balance"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewAdminUserResponse создает AdminUserResponse из модели пользователя
+func NewAdminUserResponse(user *models.User) AdminUserResponse {
+	return AdminUserResponse{
+		ID:        user.ID,
+		Login:     user.Login,
+		Role:      user.Role,
+		Balance:   user.Balance,
+		CreatedAt: user.CreatedAt,
+	}
+}
+
+// AdminOrderResponse представляет заказ в административном API
+type AdminOrderResponse struct {
+	ID          int64     `json:"id"`
+	Number      string    `json:"number"`
+	UserID      int64     `json:"user_id"`
+	Status      string    `json:"status"`
+	Accrual     float64   `json:"accrual"`
+	UploadedAt  time.Time `json:"uploaded_at"`
+	ProcessedAt time.Time `json:"processed_at"`
+}
+
+// NewAdminOrderResponse создает AdminOrderResponse из модели заказа
+func NewAdminOrderResponse(order *models.Order) AdminOrderResponse {
+	return AdminOrderResponse{
+		ID:          order.ID,
+		Number:      order.Number,
+		UserID:      order.UserID,
+		Status:      order.Status,
+		Accrual:     order.Accrual,
+		UploadedAt:  order.UploadedAt,
+		ProcessedAt: order.ProcessedAt,
+	}
+}
+
+// MerchantRequest представляет запрос на регистрацию магазина
+type MerchantRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// APIKeyRequest представляет запрос на выпуск ключа доступа магазина
+type APIKeyRequest struct {
+	Scopes []string `json:"scopes" binding:"required"`
+}
+
+// IssuedAPIKeyResponse представляет выпущенный ключ, открытый ключ показывается только один раз
+type IssuedAPIKeyResponse struct {
+	*models.APIKey
+	Key string `json:"key"`
+}
+
+// MerchantOrderRequest представляет запрос магазина на загрузку заказа за пользователя
+type MerchantOrderRequest struct {
+	Login string `json:"login" binding:"required"`
+	Order string `json:"order" binding:"required"`
+}
+
+// PreAuthResponse представляет ответ на первый шаг входа с двухфакторной аутентификацией
+type PreAuthResponse struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	PreAuthToken      string `json:"pre_auth_token"`
+}
+
+// SecondFactorRequest представляет запрос второго шага входа
+type SecondFactorRequest struct {
+	PreAuthToken string `json:"pre_auth_token" binding:"required"`
+	Code         string `json:"code" binding:"required"`
+}
+
+// TOTPEnrollResponse представляет секрет TOTP для подключения приложения-аутентификатора
+type TOTPEnrollResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// TOTPCodeRequest представляет запрос с одноразовым кодом
+type TOTPCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TOTPConfirmResponse представляет коды восстановления, показываемые один раз
+type TOTPConfirmResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TOTPDisableRequest представляет запрос на отключение двухфакторной аутентификации
+type TOTPDisableRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// SessionResponse представляет сессию пользователя с отметкой текущей
+type SessionResponse struct {
+	*models.Session
+	Current bool `json:"current"`
+}
+
+// DeleteAccountRequest представляет запрос на удаление аккаунта
+type DeleteAccountRequest struct {
+	Password string `json:"password" binding:"required"`
 }
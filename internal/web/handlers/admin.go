@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitslim/gophermart/internal/errs"
+	"github.com/gitslim/gophermart/internal/logging"
+	"github.com/gitslim/gophermart/internal/service"
+	"github.com/gitslim/gophermart/internal/web/dto"
+)
+
+// AdminHandler содержит обработчики административного API
+type AdminHandler struct {
+	adminService service.AdminService
+	log          logging.Logger
+}
+
+// NewAdminHandler создает новый экземпляр AdminHandler
+func NewAdminHandler(log logging.Logger, adminService service.AdminService) *AdminHandler {
+	return &AdminHandler{
+		adminService: adminService,
+		log:          log,
+	}
+}
+
+// pathUserID возвращает ID пользователя из пути запроса
+func pathUserID(c *gin.Context) (int64, error) {
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || userID <= 0 {
+		return 0, errs.NewAppError(errs.ErrBadRequest, "invalid user id")
+	}
+	return userID, nil
+}
+
+// SearchUsers ищет пользователей по подстроке логина
+func (h *AdminHandler) SearchUsers(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	users, err := h.adminService.SearchUsers(c.Request.Context(), c.Query("login"), limit)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	if len(users) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	response := make([]dto.AdminUserResponse, 0, len(users))
+	for _, u := range users {
+		response = append(response, dto.NewAdminUserResponse(u))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetUser возвращает пользователя по ID
+func (h *AdminHandler) GetUser(c *gin.Context) {
+	userID, err := pathUserID(c)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	user, err := h.adminService.GetUser(c.Request.Context(), userID)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewAdminUserResponse(user))
+}
+
+// SetUserRole назначает роль пользователю
+func (h *AdminHandler) SetUserRole(c *gin.Context) {
+	userID, err := pathUserID(c)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	var req dto.UserRoleRequest
+	err = bindDTO(c, &req)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	err = h.adminService.SetUserRole(c.Request.Context(), userID, req.Role)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// GetUserOrders возвращает заказы пользователя
+func (h *AdminHandler) GetUserOrders(c *gin.Context) {
+	userID, err := pathUserID(c)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	orders, err := h.adminService.GetUserOrders(c.Request.Context(), userID)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	if len(orders) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	response := make([]dto.AdminOrderResponse, 0, len(orders))
+	for _, o := range orders {
+		response = append(response, dto.NewAdminOrderResponse(o))
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetOrder возвращает заказ по номеру
+func (h *AdminHandler) GetOrder(c *gin.Context) {
+	order, err := h.adminService.GetOrder(c.Request.Context(), c.Param("number"))
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.NewAdminOrderResponse(order))
+}
+
+// ReprocessOrder возвращает заказ в очередь обработки
+func (h *AdminHandler) ReprocessOrder(c *gin.Context) {
+	err := h.adminService.ReprocessOrder(c.Request.Context(), c.Param("number"))
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
+
+// AdjustBalance вручную изменяет баланс пользователя
+func (h *AdminHandler) AdjustBalance(c *gin.Context) {
+	adminID, err := getUserID(c)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	userID, err := pathUserID(c)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	var req dto.BalanceAdjustmentRequest
+	err = bindDTO(c, &req)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	adjustment, err := h.adminService.AdjustBalance(c.Request.Context(), adminID, userID, req.Amount, req.Reason)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	h.log.Infof("Balance of user %d adjusted by %v by admin %d: %s", userID, req.Amount, adminID, adjustment.Reason)
+
+	c.JSON(http.StatusOK, adjustment)
+}
+
+// GetBalanceAdjustments возвращает историю ручных корректировок баланса пользователя
+func (h *AdminHandler) GetBalanceAdjustments(c *gin.Context) {
+	userID, err := pathUserID(c)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	adjustments, err := h.adminService.GetBalanceAdjustments(c.Request.Context(), userID)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	if len(adjustments) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	c.JSON(http.StatusOK, adjustments)
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitslim/gophermart/internal/errs"
+	"github.com/gitslim/gophermart/internal/httpconst"
+	"github.com/gitslim/gophermart/internal/models"
+)
+
+// maxBatchOrders - максимальное количество номеров в одном пакете
+const maxBatchOrders = 1000
+
+// UploadOrders обрабатывает пакетную загрузку номеров заказов
+func (h *Handler) UploadOrders(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	numbers, err := parseOrderBatch(c)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	// Номера с неверной контрольной суммой не отправляются в хранилище
+	results := make([]*models.OrderUploadResult, len(numbers))
+	valid := make([]string, 0, len(numbers))
+	for i, number := range numbers {
+		if err := validateOrderLuhn(number); err != nil {
+			results[i] = &models.OrderUploadResult{Number: number, Result: models.OrderUploadInvalid}
+			continue
+		}
+		valid = append(valid, number)
+	}
+
+	if len(valid) > 0 {
+		uploaded, err := h.orderService.UploadOrders(c.Request.Context(), userID, valid)
+		if err != nil {
+			handleError(c, h.log, err)
+			return
+		}
+
+		byNumber := make(map[string]*models.OrderUploadResult, len(uploaded))
+		for _, r := range uploaded {
+			byNumber[r.Number] = r
+		}
+
+		// Хранилище возвращает один результат на номер. Принят только первый экземпляр
+		// повторяющегося номера, остальные к этому моменту уже загружены
+		seen := make(map[string]bool, len(valid))
+		for i, number := range numbers {
+			if results[i] != nil {
+				continue
+			}
+			result := byNumber[number]
+			if result != nil && seen[number] && result.Result == models.OrderUploadAccepted {
+				result = &models.OrderUploadResult{Number: number, Result: models.OrderUploadAlreadyUploaded}
+			}
+			seen[number] = true
+			results[i] = result
+		}
+	}
+
+	c.JSON(http.StatusMultiStatus, results)
+}
+
+// parseOrderBatch читает номера заказов из JSON массива или из списка, разделенного переводами строк
+func parseOrderBatch(c *gin.Context) ([]string, error) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return nil, bindError(err)
+	}
+
+	var numbers []string
+	if strings.HasPrefix(c.ContentType(), httpconst.ContentTypeJSON) {
+		if err := json.Unmarshal(body, &numbers); err != nil {
+			return nil, errs.NewAppError(errs.ErrBadRequest, "request body must be a JSON array of order numbers")
+		}
+		for i := range numbers {
+			numbers[i] = normalizeOrderNumber(numbers[i])
+		}
+	} else {
+		for _, line := range strings.Split(string(body), "\n") {
+			if line = normalizeOrderNumber(line); line != "" {
+				numbers = append(numbers, line)
+			}
+		}
+	}
+
+	if len(numbers) == 0 {
+		return nil, errs.NewAppError(errs.ErrBadRequest, "no order numbers provided")
+	}
+	if len(numbers) > maxBatchOrders {
+		return nil, errs.NewAppError(errs.ErrBadRequest, fmt.Sprintf("at most %d order numbers per batch", maxBatchOrders))
+	}
+
+	return numbers, nil
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitslim/gophermart/internal/httpconst"
+	"github.com/gitslim/gophermart/internal/logging/sugared"
+	"github.com/gitslim/gophermart/internal/luhn"
+	"github.com/gitslim/gophermart/internal/models"
+	"github.com/gitslim/gophermart/internal/service"
+	"github.com/gitslim/gophermart/internal/storage"
+	"github.com/gitslim/gophermart/internal/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBatchOrderService загружает пакеты заказов в хранилище
+type fakeBatchOrderService struct {
+	service.OrderService
+	orders storage.OrderStorage
+}
+
+func (s *fakeBatchOrderService) UploadOrders(ctx context.Context, userID int64, numbers []string) ([]*models.OrderUploadResult, error) {
+	return s.orders.CreateOrders(ctx, userID, numbers, time.Now())
+}
+
+// newBatchTestRouter возвращает маршрутизатор с загрузкой пакетов от имени пользователя,
+// заказ otherNumber принадлежит другому пользователю
+func newBatchTestRouter(t *testing.T, otherNumber string) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	log, err := sugared.NewLogger()
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	db := memory.NewDB()
+	users := memory.NewMemUserStorage(db)
+	orders := memory.NewMemOrderStorage(db)
+
+	alice := &models.User{Login: "alice", PasswordHash: "hash", Role: models.RoleUser}
+	require.NoError(t, users.CreateUser(ctx, alice))
+	bob := &models.User{Login: "bob", PasswordHash: "hash", Role: models.RoleUser}
+	require.NoError(t, users.CreateUser(ctx, bob))
+	_, err = orders.CreateOrders(ctx, bob.ID, []string{otherNumber}, time.Now())
+	require.NoError(t, err)
+
+	h := &Handler{orderService: &fakeBatchOrderService{orders: orders}, log: log}
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set(userIDKey, alice.ID)
+	})
+	r.POST("/orders/batch", h.UploadOrders)
+
+	return r
+}
+
+// uploadBatch отправляет пакет номеров JSON массивом
+func uploadBatch(t *testing.T, r *gin.Engine, numbers []string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	body, err := json.Marshal(numbers)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/orders/batch", strings.NewReader(string(body)))
+	req.Header.Set(httpconst.HeaderContentType, httpconst.ContentTypeJSON)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	return w
+}
+
+func TestUploadOrders(t *testing.T) {
+	const (
+		number      = "12345678903"
+		otherNumber = "79927398713"
+		invalid     = "12345678904"
+	)
+	r := newBatchTestRouter(t, otherNumber)
+
+	w := uploadBatch(t, r, []string{number, invalid, otherNumber, number, " " + number + " "})
+	require.Equal(t, http.StatusMultiStatus, w.Code)
+
+	var results []*models.OrderUploadResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &results))
+	assert.Equal(t, []*models.OrderUploadResult{
+		{Number: number, Result: models.OrderUploadAccepted},
+		{Number: invalid, Result: models.OrderUploadInvalid},
+		{Number: otherNumber, Result: models.OrderUploadConflict},
+		// Повторы номера в пакете принимаются только один раз
+		{Number: number, Result: models.OrderUploadAlreadyUploaded},
+		{Number: number, Result: models.OrderUploadAlreadyUploaded},
+	}, results)
+
+	// Повторная загрузка пакета не создает заказы заново
+	w = uploadBatch(t, r, []string{number})
+	require.Equal(t, http.StatusMultiStatus, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &results))
+	assert.Equal(t, []*models.OrderUploadResult{{Number: number, Result: models.OrderUploadAlreadyUploaded}}, results)
+}
+
+func TestUploadOrdersPlainText(t *testing.T) {
+	r := newBatchTestRouter(t, "79927398713")
+
+	req := httptest.NewRequest(http.MethodPost, "/orders/batch", strings.NewReader("12345678903\n\n4561261212345467\n"))
+	req.Header.Set(httpconst.HeaderContentType, "text/plain")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusMultiStatus, w.Code)
+
+	var results []*models.OrderUploadResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &results))
+	require.Len(t, results, 2)
+	assert.Equal(t, models.OrderUploadAccepted, results[1].Result)
+}
+
+func TestUploadOrdersLimit(t *testing.T) {
+	r := newBatchTestRouter(t, "79927398713")
+
+	// Номера с верной контрольной суммой, чтобы пакет дошел до хранилища
+	numbers := make([]string, 0, maxBatchOrders+1)
+	for n := 1000; len(numbers) < maxBatchOrders+1; n++ {
+		if number := strconv.Itoa(n); luhn.Valid(number) {
+			numbers = append(numbers, number)
+		}
+	}
+
+	w := uploadBatch(t, r, numbers[:maxBatchOrders])
+	require.Equal(t, http.StatusMultiStatus, w.Code)
+
+	var results []*models.OrderUploadResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &results))
+	require.Len(t, results, maxBatchOrders)
+
+	w = uploadBatch(t, r, numbers)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = uploadBatch(t, r, []string{})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
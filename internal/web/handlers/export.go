@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitslim/gophermart/internal/errs"
+	"github.com/gitslim/gophermart/internal/httpconst"
+	"github.com/gitslim/gophermart/internal/logging"
+	"github.com/gitslim/gophermart/internal/models"
+)
+
+const (
+	exportFormatCSV  = "csv"
+	exportFormatJSON = "json"
+
+	// exportFlushRows - количество строк, после которого буфер выгрузки отправляется клиенту
+	exportFlushRows = 500
+)
+
+var (
+	orderCSVHeader      = []string{"number", "status", "accrual", "uploaded_at"}
+	withdrawalCSVHeader = []string{"order", "sum", "processed_at"}
+)
+
+// exportFormat возвращает запрошенный формат выгрузки или пустую строку для обычного ответа
+func exportFormat(c *gin.Context) (string, error) {
+	switch format := strings.ToLower(c.Query("format")); format {
+	case "":
+		if strings.Contains(c.GetHeader(httpconst.HeaderAccept), httpconst.ContentTypeCSV) {
+			return exportFormatCSV, nil
+		}
+		return "", nil
+	case exportFormatCSV, exportFormatJSON:
+		return format, nil
+	default:
+		return "", errs.NewAppError(errs.ErrBadRequest, "format must be csv or json")
+	}
+}
+
+// exportOrders выгружает заказы пользователя в файл указанного формата
+func (h *Handler) exportOrders(c *gin.Context, userID int64, format string) {
+	filter, err := parseOrderFilter(c)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	w := newExportWriter(c, format, "orders", orderCSVHeader, func(o *models.Order) []string {
+		return []string{o.Number, o.Status, formatAmount(o.Accrual), o.UploadedAt.Format(time.RFC3339)}
+	})
+	err = h.orderService.ExportUserOrders(c.Request.Context(), userID, filter, w.Write)
+	h.finishExport(c, w, err)
+}
+
+// exportWithdrawals выгружает списания пользователя в файл указанного формата
+func (h *Handler) exportWithdrawals(c *gin.Context, userID int64, format string) {
+	filter, err := parseWithdrawalFilter(c)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	w := newExportWriter(c, format, "withdrawals", withdrawalCSVHeader, func(wd *models.Withdrawal) []string {
+		return []string{wd.Order, formatAmount(wd.Sum), wd.ProcessedAt.Format(time.RFC3339)}
+	})
+	err = h.balanceService.ExportWithdrawals(c.Request.Context(), userID, filter, w.Write)
+	h.finishExport(c, w, err)
+}
+
+// exporter описывает незавершенную выгрузку
+type exporter interface {
+	Started() bool
+	Close() error
+}
+
+// finishExport завершает выгрузку. После начала передачи ошибку уже нельзя вернуть клиенту,
+// поэтому соединение разрывается, чтобы клиент не принял оборванный файл за полный
+func (h *Handler) finishExport(c *gin.Context, w exporter, err error) {
+	log := logging.FromContext(c.Request.Context(), h.log)
+
+	if err != nil {
+		if !w.Started() {
+			handleError(c, h.log, err)
+			return
+		}
+		log.Errorf("Export %s interrupted: %v", c.Request.URL.Path, err)
+		panic(http.ErrAbortHandler)
+	}
+
+	if err := w.Close(); err != nil {
+		log.Errorf("Failed to finish export %s: %v", c.Request.URL.Path, err)
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// exportWriter потоково записывает строки выгрузки в ответ в формате CSV или JSON
+type exportWriter[T any] struct {
+	c       *gin.Context
+	format  string
+	name    string
+	header  []string
+	record  func(T) []string
+	csv     *csv.Writer
+	rows    int
+	started bool
+}
+
+func newExportWriter[T any](c *gin.Context, format, name string, header []string, record func(T) []string) *exportWriter[T] {
+	return &exportWriter[T]{c: c, format: format, name: name, header: header, record: record}
+}
+
+// Started сообщает, начата ли передача ответа
+func (w *exportWriter[T]) Started() bool {
+	return w.started
+}
+
+// begin отправляет заголовки ответа перед первой строкой
+func (w *exportWriter[T]) begin() error {
+	if w.started {
+		return nil
+	}
+	w.started = true
+
+	contentType := httpconst.ContentTypeCSV + "; charset=utf-8"
+	if w.format == exportFormatJSON {
+		contentType = httpconst.ContentTypeJSON + "; charset=utf-8"
+	}
+	filename := fmt.Sprintf("gophermart-%s-%s.%s", w.name, time.Now().Format("20060102-150405"), w.format)
+
+	w.c.Header(httpconst.HeaderContentType, contentType)
+	w.c.Header(httpconst.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.c.Status(http.StatusOK)
+
+	if w.format == exportFormatJSON {
+		_, err := w.c.Writer.WriteString("[")
+		return err
+	}
+
+	w.csv = csv.NewWriter(w.c.Writer)
+	return w.csv.Write(w.header)
+}
+
+// Write записывает строку выгрузки
+func (w *exportWriter[T]) Write(v T) error {
+	if err := w.begin(); err != nil {
+		return err
+	}
+	w.rows++
+
+	if w.format == exportFormatJSON {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if w.rows > 1 {
+			data = append([]byte(","), data...)
+		}
+		_, err = w.c.Writer.Write(data)
+		return err
+	}
+
+	if err := w.csv.Write(w.record(v)); err != nil {
+		return err
+	}
+	if w.rows%exportFlushRows == 0 {
+		w.csv.Flush()
+		return w.csv.Error()
+	}
+	return nil
+}
+
+// Close завершает выгрузку, пустая выгрузка содержит только заголовок
+func (w *exportWriter[T]) Close() error {
+	if err := w.begin(); err != nil {
+		return err
+	}
+
+	if w.format == exportFormatJSON {
+		_, err := w.c.Writer.WriteString("]")
+		return err
+	}
+
+	w.csv.Flush()
+	return w.csv.Error()
+}
+
+// formatAmount форматирует сумму баллов с точностью хранения
+func formatAmount(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitslim/gophermart/internal/conf"
+	"github.com/gitslim/gophermart/internal/httpconst"
+	"github.com/gitslim/gophermart/internal/logging/sugared"
+	"github.com/gitslim/gophermart/internal/models"
+	"github.com/gitslim/gophermart/internal/service"
+	"github.com/gitslim/gophermart/internal/web/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var exportTime = time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+// fakeExportOrderService выгружает rows заказов и возвращает err после них
+type fakeExportOrderService struct {
+	service.OrderService
+	rows int
+	err  error
+}
+
+func (s *fakeExportOrderService) ExportUserOrders(_ context.Context, _ int64, _ models.OrderFilter, fn func(*models.Order) error) error {
+	for i := 0; i < s.rows; i++ {
+		order := &models.Order{
+			Number:     strconv.Itoa(1000 + i),
+			Status:     models.OrderStatusProcessed,
+			Accrual:    1.5,
+			UploadedAt: exportTime,
+		}
+		if err := fn(order); err != nil {
+			return err
+		}
+	}
+	return s.err
+}
+
+// fakeExportBalanceService выгружает rows списаний и возвращает err после них
+type fakeExportBalanceService struct {
+	service.BalanceService
+	rows int
+	err  error
+}
+
+func (s *fakeExportBalanceService) ExportWithdrawals(_ context.Context, _ int64, _ models.WithdrawalFilter, fn func(*models.Withdrawal) error) error {
+	for i := 0; i < s.rows; i++ {
+		withdrawal := &models.Withdrawal{
+			Order:       strconv.Itoa(2000 + i),
+			Sum:         2,
+			ProcessedAt: exportTime,
+		}
+		if err := fn(withdrawal); err != nil {
+			return err
+		}
+	}
+	return s.err
+}
+
+func newExportTestRouter(t *testing.T, rows int, err error) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	log, logErr := sugared.NewLogger()
+	require.NoError(t, logErr)
+
+	h := &Handler{
+		orderService:   &fakeExportOrderService{rows: rows, err: err},
+		balanceService: &fakeExportBalanceService{rows: rows, err: err},
+		log:            log,
+	}
+	logger := middleware.NewLoggingMiddleware(log)
+	compress := middleware.NewCompressMiddleware(&conf.Config{CompressionMinSize: 1024}, log)
+
+	r := gin.New()
+	r.Use(logger.Recovery, compress.HandlerFunc, func(c *gin.Context) {
+		c.Set(userIDKey, int64(1))
+	})
+	r.GET("/orders", h.GetOrders)
+	r.GET("/withdrawals", h.GetWithdrawals)
+
+	return r
+}
+
+func TestExportCSV(t *testing.T) {
+	r := newExportTestRouter(t, 2, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/orders?format=csv", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.HasPrefix(w.Header().Get(httpconst.HeaderContentType), httpconst.ContentTypeCSV))
+	assert.Contains(t, w.Header().Get(httpconst.HeaderContentDisposition), "gophermart-orders-")
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		orderCSVHeader,
+		{"1000", models.OrderStatusProcessed, "1.50", "2024-03-01T12:00:00Z"},
+		{"1001", models.OrderStatusProcessed, "1.50", "2024-03-01T12:00:00Z"},
+	}, records)
+}
+
+func TestExportJSON(t *testing.T) {
+	r := newExportTestRouter(t, 2, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/withdrawals?format=json", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.HasPrefix(w.Header().Get(httpconst.HeaderContentType), httpconst.ContentTypeJSON))
+
+	var withdrawals []*models.Withdrawal
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &withdrawals))
+	require.Len(t, withdrawals, 2)
+	assert.Equal(t, "2001", withdrawals[1].Order)
+}
+
+func TestExportEmpty(t *testing.T) {
+	r := newExportTestRouter(t, 0, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/withdrawals?format=json", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "[]", w.Body.String())
+}
+
+func TestExportErrorBeforeStart(t *testing.T) {
+	r := newExportTestRouter(t, 0, errors.New("storage unavailable"))
+
+	req := httptest.NewRequest(http.MethodGet, "/orders?format=csv", nil)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	// До первой строки ошибка отправляется обычным ответом
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.True(t, strings.HasPrefix(w.Header().Get(httpconst.HeaderContentType), httpconst.ContentTypeProblemJSON))
+}
+
+func TestExportErrorMidStream(t *testing.T) {
+	// Строк больше порога сброса буфера, поэтому часть выгрузки уже отправлена клиенту
+	srv := httptest.NewServer(newExportTestRouter(t, exportFlushRows*2, errors.New("storage unavailable")))
+	defer srv.Close()
+
+	for _, path := range []string{"/orders?format=csv", "/withdrawals?format=json"} {
+		t.Run(path, func(t *testing.T) {
+			for _, encoding := range []string{"identity", httpconst.ContentEncodingGzip} {
+				req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+				require.NoError(t, err)
+				req.Header.Set(httpconst.HeaderAcceptEncoding, encoding)
+
+				// Оборванная выгрузка не должна выглядеть для клиента как полный файл
+				resp, err := srv.Client().Do(req)
+				if err == nil {
+					_, err = io.ReadAll(resp.Body)
+					resp.Body.Close()
+				}
+				assert.Error(t, err, encoding)
+			}
+		})
+	}
+}
@@ -1,21 +1,24 @@
 package handlers
 
 import (
-	"errors"
-	"io"
 	"net/http"
-	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gitslim/gophermart/internal/conf"
 	"github.com/gitslim/gophermart/internal/errs"
+	"github.com/gitslim/gophermart/internal/events"
 	"github.com/gitslim/gophermart/internal/logging"
+	"github.com/gitslim/gophermart/internal/luhn"
 	"github.com/gitslim/gophermart/internal/service"
 	"github.com/gitslim/gophermart/internal/web/dto"
 	"github.com/gitslim/gophermart/internal/web/middleware"
+	"github.com/gitslim/gophermart/internal/web/problem"
 )
 
 const (
-	userIDKey = "userID"
+	userIDKey    = "userID"
+	sessionIDKey = "sessionID"
 )
 
 // Handler содержит обработчики HTTP запросов
@@ -23,36 +26,34 @@ type Handler struct {
 	userService    service.UserService
 	orderService   service.OrderService
 	balanceService service.BalanceService
+	sessionService service.SessionService
+	privacyService service.PrivacyService
 	log            logging.Logger
 	auth           *middleware.AuthMiddleware
+	broker         *events.Broker
+
+	heartbeatInterval time.Duration
 }
 
 // NewHandler создает новый экземпляр Handler
-func NewHandler(log logging.Logger, userService service.UserService, orderService service.OrderService, balanceService service.BalanceService, auth *middleware.AuthMiddleware) *Handler {
+func NewHandler(log logging.Logger, userService service.UserService, orderService service.OrderService, balanceService service.BalanceService, sessionService service.SessionService, privacyService service.PrivacyService, auth *middleware.AuthMiddleware, broker *events.Broker, config *conf.Config) *Handler {
 	return &Handler{
 		userService:    userService,
 		orderService:   orderService,
 		balanceService: balanceService,
+		sessionService: sessionService,
+		privacyService: privacyService,
 		log:            log,
 		auth:           auth,
-	}
-}
+		broker:         broker,
 
-// handleError обрабатывает ошибки
-func handleError(c *gin.Context, err error) {
-	var e *errs.AppError
-	if errors.As(err, &e) {
-		c.JSON(e.Type.HTTPStatus, gin.H{"error": e.Error()})
-		return
+		heartbeatInterval: config.EventsHeartbeatInterval,
 	}
-	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 }
 
-func bindDTO(c *gin.Context, dto interface{}) error {
-	if err := c.ShouldBindJSON(&dto); err != nil {
-		return errs.NewAppError(errs.ErrBadRequest, "invalid request")
-	}
-	return nil
+// handleError отправляет ошибку клиенту в формате problem+json
+func handleError(c *gin.Context, log logging.Logger, err error) {
+	problem.Write(c, log, err)
 }
 
 // getUserID возвращает ID пользователя из контекста
@@ -72,39 +73,16 @@ func getUserID(c *gin.Context) (int64, error) {
 	return userID, nil
 }
 
+// getSessionID возвращает ID текущей сессии из контекста
+func getSessionID(c *gin.Context) int64 {
+	return c.GetInt64(sessionIDKey)
+}
+
 // validateOrderLuhn проверяет номер заказа по алгоритму Луна
 func validateOrderLuhn(number string) error {
-	err := errs.NewAppError(errs.ErrUnprocessableEntity, "invalid order number")
-	digits := make([]int, 0, len(number))
-	for _, r := range number {
-		if d, err := strconv.Atoi(string(r)); err == nil {
-			digits = append(digits, d)
-		} else {
-			return err
-		}
-	}
-
-	if len(digits) == 0 {
-		return err
+	if !luhn.Valid(number) {
+		return errs.NewAppError(errs.ErrInvalidOrderNumber, "invalid order number")
 	}
-
-	checksum := 0
-	for i := len(digits) - 2; i >= 0; i -= 2 {
-		d := digits[i] * 2
-		if d > 9 {
-			d -= 9
-		}
-		digits[i] = d
-	}
-
-	for _, d := range digits {
-		checksum += d
-	}
-
-	if checksum%10 != 0 {
-		return err
-	}
-
 	return nil
 }
 
@@ -113,25 +91,21 @@ func (h *Handler) Register(c *gin.Context) {
 	var req dto.UserRequest
 	err := bindDTO(c, &req)
 	if err != nil {
-		handleError(c, err)
+		handleError(c, h.log, err)
 		return
 	}
 
 	user, err := h.userService.Register(c.Request.Context(), req.Login, req.Password)
 	if err != nil {
-		handleError(c, err)
+		handleError(c, h.log, err)
 		return
 	}
 
-	token, err := h.auth.GenerateToken(user.ID)
-	if err != nil {
-		handleError(c, err)
+	if err := h.auth.StartSession(c, user); err != nil {
+		handleError(c, h.log, err)
 		return
 	}
 
-	h.auth.SetAuthCookie(c, token)
-	c.Set(userIDKey, user.ID)
-
 	c.Status(http.StatusOK)
 }
 
@@ -140,24 +114,69 @@ func (h *Handler) Login(c *gin.Context) {
 	var req dto.UserRequest
 	err := bindDTO(c, &req)
 	if err != nil {
-		handleError(c, err)
+		handleError(c, h.log, err)
 		return
 	}
 
 	user, err := h.userService.Login(c.Request.Context(), req.Login, req.Password)
 	if err != nil {
-		handleError(c, err)
+		handleError(c, h.log, err)
+		return
+	}
+
+	// При включенной двухфакторной аутентификации выдаем токен для второго шага вместо сессии
+	if user.TOTPEnabled {
+		preAuthToken, err := h.auth.GeneratePreAuthToken(user)
+		if err != nil {
+			handleError(c, h.log, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, dto.PreAuthResponse{
+			TwoFactorRequired: true,
+			PreAuthToken:      preAuthToken,
+		})
+		return
+	}
+
+	if err := h.auth.StartSession(c, user); err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// LoginSecondFactor обрабатывает второй шаг входа с одноразовым кодом или кодом восстановления
+func (h *Handler) LoginSecondFactor(c *gin.Context) {
+	var req dto.SecondFactorRequest
+	err := bindDTO(c, &req)
+	if err != nil {
+		handleError(c, h.log, err)
 		return
 	}
 
-	token, err := h.auth.GenerateToken(user.ID)
+	preAuth, err := h.auth.ParsePreAuthToken(c.Request.Context(), req.PreAuthToken)
 	if err != nil {
-		handleError(c, err)
+		handleError(c, h.log, err)
+		return
+	}
+
+	user, err := h.userService.VerifySecondFactor(c.Request.Context(), preAuth.UserID, req.Code)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	if err := h.auth.ConsumePreAuthToken(c.Request.Context(), preAuth); err != nil {
+		handleError(c, h.log, err)
 		return
 	}
 
-	h.auth.SetAuthCookie(c, token)
-	c.Set(userIDKey, user.ID)
+	if err := h.auth.StartSession(c, user); err != nil {
+		handleError(c, h.log, err)
+		return
+	}
 
 	c.Status(http.StatusOK)
 }
@@ -166,25 +185,24 @@ func (h *Handler) Login(c *gin.Context) {
 func (h *Handler) UploadOrder(c *gin.Context) {
 	userID, err := getUserID(c)
 	if err != nil {
-		handleError(c, err)
+		handleError(c, h.log, err)
 		return
 	}
 
-	body, err := io.ReadAll(c.Request.Body)
+	orderNumber, err := readOrderNumber(c)
 	if err != nil {
-		handleError(c, err)
+		handleError(c, h.log, err)
 		return
 	}
 
-	orderNumber := string(body)
 	if err := validateOrderLuhn(orderNumber); err != nil {
-		handleError(c, err)
+		handleError(c, h.log, err)
 		return
 	}
 
 	err = h.orderService.UploadOrder(c.Request.Context(), userID, orderNumber)
 	if err != nil {
-		handleError(c, err)
+		handleError(c, h.log, err)
 		return
 	}
 
@@ -195,16 +213,72 @@ func (h *Handler) UploadOrder(c *gin.Context) {
 func (h *Handler) GetOrders(c *gin.Context) {
 	userID, err := getUserID(c)
 	if err != nil {
-		handleError(c, err)
+		handleError(c, h.log, err)
+		return
+	}
+
+	format, err := exportFormat(c)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+	if format != "" {
+		h.exportOrders(c, userID, format)
+		return
+	}
+
+	if isPaginated(c) {
+		h.getOrdersPage(c, userID)
 		return
 	}
 
 	orders, err := h.orderService.GetUserOrders(c.Request.Context(), userID)
 	if err != nil {
-		handleError(c, err)
+		handleError(c, h.log, err)
+		return
+	}
+
+	if len(orders) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	c.JSON(http.StatusOK, orders)
+}
+
+// GetOrder возвращает заказ пользователя с историей его обработки
+func (h *Handler) GetOrder(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	order, err := h.orderService.GetUserOrder(c.Request.Context(), userID, c.Param("number"))
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// getOrdersPage возвращает страницу заказов пользователя с учетом фильтров
+func (h *Handler) getOrdersPage(c *gin.Context, userID int64) {
+	filter, err := parseOrderFilter(c)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	orders, next, err := h.orderService.GetUserOrdersPage(c.Request.Context(), userID, filter)
+	if err != nil {
+		handleError(c, h.log, err)
 		return
 	}
 
+	setNextLink(c, next)
+
 	if len(orders) == 0 {
 		c.Status(http.StatusNoContent)
 		return
@@ -217,19 +291,19 @@ func (h *Handler) GetOrders(c *gin.Context) {
 func (h *Handler) GetBalance(c *gin.Context) {
 	userID, err := getUserID(c)
 	if err != nil {
-		handleError(c, err)
+		handleError(c, h.log, err)
 		return
 	}
 
 	balance, err := h.balanceService.GetBalance(c.Request.Context(), userID)
 	if err != nil {
-		handleError(c, err)
+		handleError(c, h.log, err)
 		return
 	}
 
 	withdrawals, err := h.balanceService.GetWithdrawals(c.Request.Context(), userID)
 	if err != nil {
-		handleError(c, err)
+		handleError(c, h.log, err)
 		return
 	}
 
@@ -250,25 +324,26 @@ func (h *Handler) GetBalance(c *gin.Context) {
 func (h *Handler) Withdraw(c *gin.Context) {
 	userID, err := getUserID(c)
 	if err != nil {
-		handleError(c, err)
+		handleError(c, h.log, err)
 		return
 	}
 
 	var req dto.WithdrawRequest
 	err = bindDTO(c, &req)
 	if err != nil {
-		handleError(c, err)
+		handleError(c, h.log, err)
 		return
 	}
 
+	req.Order = normalizeOrderNumber(req.Order)
 	if err := validateOrderLuhn(req.Order); err != nil {
-		handleError(c, err)
+		handleError(c, h.log, err)
 		return
 	}
 
 	err = h.balanceService.Withdraw(c.Request.Context(), userID, req.Order, req.Sum)
 	if err != nil {
-		handleError(c, err)
+		handleError(c, h.log, err)
 		return
 	}
 
@@ -279,16 +354,159 @@ func (h *Handler) Withdraw(c *gin.Context) {
 func (h *Handler) GetWithdrawals(c *gin.Context) {
 	userID, err := getUserID(c)
 	if err != nil {
-		handleError(c, err)
+		handleError(c, h.log, err)
+		return
+	}
+
+	format, err := exportFormat(c)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+	if format != "" {
+		h.exportWithdrawals(c, userID, format)
+		return
+	}
+
+	if isPaginated(c) {
+		h.getWithdrawalsPage(c, userID)
 		return
 	}
 
 	withdrawals, err := h.balanceService.GetWithdrawals(c.Request.Context(), userID)
 	if err != nil {
-		handleError(c, err)
+		handleError(c, h.log, err)
+		return
+	}
+
+	if len(withdrawals) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	c.JSON(http.StatusOK, withdrawals)
+}
+
+// ChangePassword обрабатывает смену пароля пользователя
+func (h *Handler) ChangePassword(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	var req dto.ChangePasswordRequest
+	err = bindDTO(c, &req)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	user, err := h.userService.ChangePassword(c.Request.Context(), userID, req.OldPassword, req.NewPassword)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	// Смена версии токенов уже сделала недействительными токены остальных сессий,
+	// завершаем их явно, а текущей сессии выдаем новый токен
+	if err := h.sessionService.RevokeOtherSessions(c.Request.Context(), userID, getSessionID(c)); err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	if err := h.auth.RefreshSession(c, user); err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// EnrollTOTP начинает подключение двухфакторной аутентификации
+func (h *Handler) EnrollTOTP(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	secret, uri, err := h.userService.EnrollTOTP(c.Request.Context(), userID)
+	if err != nil {
+		handleError(c, h.log, err)
 		return
 	}
 
+	c.JSON(http.StatusOK, dto.TOTPEnrollResponse{
+		Secret: secret,
+		URI:    uri,
+	})
+}
+
+// ConfirmTOTP подтверждает подключение двухфакторной аутентификации кодом из приложения
+func (h *Handler) ConfirmTOTP(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	var req dto.TOTPCodeRequest
+	err = bindDTO(c, &req)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	codes, err := h.userService.ConfirmTOTP(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.TOTPConfirmResponse{RecoveryCodes: codes})
+}
+
+// DisableTOTP отключает двухфакторную аутентификацию
+func (h *Handler) DisableTOTP(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	var req dto.TOTPDisableRequest
+	err = bindDTO(c, &req)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	err = h.userService.DisableTOTP(c.Request.Context(), userID, req.Password, req.Code)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// getWithdrawalsPage возвращает страницу списаний пользователя с учетом фильтров
+func (h *Handler) getWithdrawalsPage(c *gin.Context, userID int64) {
+	filter, err := parseWithdrawalFilter(c)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	withdrawals, next, err := h.balanceService.GetWithdrawalsPage(c.Request.Context(), userID, filter)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	setNextLink(c, next)
+
 	if len(withdrawals) == 0 {
 		c.Status(http.StatusNoContent)
 		return
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitslim/gophermart/internal/health"
+	"github.com/gitslim/gophermart/internal/httpconst"
+)
+
+// HealthHandler содержит обработчики проб живости и готовности
+type HealthHandler struct {
+	health *health.Service
+}
+
+// NewHealthHandler создает новый экземпляр HealthHandler
+func NewHealthHandler(health *health.Service) *HealthHandler {
+	return &HealthHandler{health: health}
+}
+
+// Liveness сообщает, что процесс запущен и обрабатывает запросы, зависимости не проверяются
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.Header(httpconst.HeaderCacheControl, "no-store")
+	c.JSON(http.StatusOK, health.Report{Status: health.StatusUp})
+}
+
+// Readiness проверяет зависимости сервиса, при отказе критичной отвечает 503.
+// Проба доступна без аутентификации, поэтому отдает только статусы компонентов
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	report := h.health.Ready(c.Request.Context())
+	writeReport(c, report, report.Summary())
+}
+
+// ReadinessDetails проверяет зависимости сервиса и отдает полный отчет с ошибками и подробностями
+func (h *HealthHandler) ReadinessDetails(c *gin.Context) {
+	report := h.health.Ready(c.Request.Context())
+	writeReport(c, report, report)
+}
+
+// writeReport отправляет body со статусом по итогу проверки report
+func writeReport(c *gin.Context, report, body health.Report) {
+	status := http.StatusOK
+	if report.Status == health.StatusDown {
+		status = http.StatusServiceUnavailable
+	}
+
+	c.Header(httpconst.HeaderCacheControl, "no-store")
+	c.JSON(status, body)
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitslim/gophermart/internal/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeHealthChecker - недоступная зависимость с подробностями, которые нельзя раскрывать публично
+type fakeHealthChecker struct {
+	critical bool
+}
+
+func (c *fakeHealthChecker) Name() string {
+	return "accrual"
+}
+
+func (c *fakeHealthChecker) Critical() bool {
+	return c.critical
+}
+
+func (c *fakeHealthChecker) Check(_ context.Context) (map[string]interface{}, error) {
+	return map[string]interface{}{"address": "http://accrual.internal:8080"}, errors.New("dial tcp 10.0.0.5:8080: connection refused")
+}
+
+func TestReadiness(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		critical bool
+		details  bool
+		want     int
+	}{
+		{name: "public degraded", want: http.StatusOK},
+		{name: "public down", critical: true, want: http.StatusServiceUnavailable},
+		{name: "details degraded", details: true, want: http.StatusOK},
+		{name: "details down", critical: true, details: true, want: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewHealthHandler(health.NewService([]health.Checker{&fakeHealthChecker{critical: tt.critical}}))
+			handler := h.Readiness
+			if tt.details {
+				handler = h.ReadinessDetails
+			}
+
+			r := gin.New()
+			r.GET("/readyz", handler)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			require.Equal(t, tt.want, w.Code)
+
+			var report health.Report
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+			component := report.Components["accrual"]
+			assert.Equal(t, health.StatusDown, component.Status)
+
+			if tt.details {
+				assert.NotEmpty(t, component.Error)
+				assert.NotEmpty(t, component.Details)
+			} else {
+				assert.NotContains(t, w.Body.String(), "accrual.internal")
+				assert.NotContains(t, w.Body.String(), "10.0.0.5")
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitslim/gophermart/internal/errs"
+	"github.com/gitslim/gophermart/internal/logging"
+	"github.com/gitslim/gophermart/internal/service"
+	"github.com/gitslim/gophermart/internal/web/dto"
+)
+
+const (
+	merchantIDKey = "merchantID"
+)
+
+// MerchantHandler содержит обработчики API магазинов и управления их ключами
+type MerchantHandler struct {
+	merchantService service.MerchantService
+	log             logging.Logger
+}
+
+// NewMerchantHandler создает новый экземпляр MerchantHandler
+func NewMerchantHandler(log logging.Logger, merchantService service.MerchantService) *MerchantHandler {
+	return &MerchantHandler{
+		merchantService: merchantService,
+		log:             log,
+	}
+}
+
+// getMerchantID возвращает ID магазина из контекста
+func getMerchantID(c *gin.Context) (int64, error) {
+	merchantID := c.GetInt64(merchantIDKey)
+	if merchantID == 0 {
+		return 0, errs.NewAppError(errs.ErrUnauthorized, "merchant not found")
+	}
+	return merchantID, nil
+}
+
+// pathInt64 возвращает положительный целочисленный параметр пути
+func pathInt64(c *gin.Context, name string) (int64, error) {
+	v, err := strconv.ParseInt(c.Param(name), 10, 64)
+	if err != nil || v <= 0 {
+		return 0, errs.NewAppError(errs.ErrBadRequest, "invalid "+name)
+	}
+	return v, nil
+}
+
+// CreateMerchant регистрирует новый магазин
+func (h *MerchantHandler) CreateMerchant(c *gin.Context) {
+	var req dto.MerchantRequest
+	err := bindDTO(c, &req)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	merchant, err := h.merchantService.CreateMerchant(c.Request.Context(), req.Name)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, merchant)
+}
+
+// GetMerchants возвращает список магазинов
+func (h *MerchantHandler) GetMerchants(c *gin.Context) {
+	merchants, err := h.merchantService.GetMerchants(c.Request.Context())
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	if len(merchants) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	c.JSON(http.StatusOK, merchants)
+}
+
+// GetAPIKeys возвращает ключи доступа магазина без секретов
+func (h *MerchantHandler) GetAPIKeys(c *gin.Context) {
+	merchantID, err := pathInt64(c, "id")
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	keys, err := h.merchantService.GetAPIKeys(c.Request.Context(), merchantID)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	if len(keys) == 0 {
+		c.Status(http.StatusNoContent)
+		return
+	}
+
+	c.JSON(http.StatusOK, keys)
+}
+
+// IssueAPIKey выпускает новый ключ доступа магазина
+func (h *MerchantHandler) IssueAPIKey(c *gin.Context) {
+	merchantID, err := pathInt64(c, "id")
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	var req dto.APIKeyRequest
+	err = bindDTO(c, &req)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	key, rawKey, err := h.merchantService.IssueAPIKey(c.Request.Context(), merchantID, req.Scopes)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.IssuedAPIKeyResponse{APIKey: key, Key: rawKey})
+}
+
+// RotateAPIKey заменяет ключ доступа магазина новым
+func (h *MerchantHandler) RotateAPIKey(c *gin.Context) {
+	merchantID, err := pathInt64(c, "id")
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	keyID, err := pathInt64(c, "keyID")
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	key, rawKey, err := h.merchantService.RotateAPIKey(c.Request.Context(), merchantID, keyID)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.IssuedAPIKeyResponse{APIKey: key, Key: rawKey})
+}
+
+// RevokeAPIKey отзывает ключ доступа магазина
+func (h *MerchantHandler) RevokeAPIKey(c *gin.Context) {
+	merchantID, err := pathInt64(c, "id")
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	keyID, err := pathInt64(c, "keyID")
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	err = h.merchantService.RevokeAPIKey(c.Request.Context(), merchantID, keyID)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// UploadOrder загружает номер заказа от имени пользователя магазина
+func (h *MerchantHandler) UploadOrder(c *gin.Context) {
+	merchantID, err := getMerchantID(c)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	var req dto.MerchantOrderRequest
+	err = bindDTO(c, &req)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	req.Order = normalizeOrderNumber(req.Order)
+	if err := validateOrderLuhn(req.Order); err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	err = h.merchantService.UploadOrder(c.Request.Context(), merchantID, req.Login, req.Order)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	c.Status(http.StatusAccepted)
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitslim/gophermart/internal/errs"
+	"github.com/gitslim/gophermart/internal/httpconst"
+	"github.com/gitslim/gophermart/internal/models"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 100
+)
+
+// paginationParams перечисляет параметры, наличие которых включает постраничную выдачу
+var paginationParams = []string{"limit", "cursor", "status", "from", "to"}
+
+// isPaginated проверяет, запрошена ли постраничная выдача, без параметров сохраняется прежний ответ целиком
+func isPaginated(c *gin.Context) bool {
+	query := c.Request.URL.Query()
+	for _, p := range paginationParams {
+		if query.Has(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePage разбирает общие параметры страницы: limit, cursor, from и to
+func parsePage(c *gin.Context) (limit int, after *models.Cursor, from, to *time.Time, err error) {
+	limit = defaultPageLimit
+	if v := c.Query("limit"); v != "" {
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > maxPageLimit {
+			return 0, nil, nil, nil, errs.NewAppError(errs.ErrBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxPageLimit))
+		}
+	}
+
+	if v := c.Query("cursor"); v != "" {
+		after, err = decodeCursor(v)
+		if err != nil {
+			return 0, nil, nil, nil, err
+		}
+	}
+
+	from, err = parseTimeParam(c, "from")
+	if err != nil {
+		return 0, nil, nil, nil, err
+	}
+
+	to, err = parseTimeParam(c, "to")
+	if err != nil {
+		return 0, nil, nil, nil, err
+	}
+
+	if from != nil && to != nil && !from.Before(*to) {
+		return 0, nil, nil, nil, errs.NewAppError(errs.ErrBadRequest, "from must be before to")
+	}
+
+	return limit, after, from, to, nil
+}
+
+// parseOrderFilter разбирает параметры постраничной выдачи заказов
+func parseOrderFilter(c *gin.Context) (models.OrderFilter, error) {
+	limit, after, from, to, err := parsePage(c)
+	if err != nil {
+		return models.OrderFilter{}, err
+	}
+
+	var statuses []string
+	for _, v := range c.QueryArray("status") {
+		for _, status := range strings.Split(v, ",") {
+			status = strings.ToUpper(strings.TrimSpace(status))
+			switch status {
+			case models.OrderStatusNew, models.OrderStatusProcessing, models.OrderStatusInvalid, models.OrderStatusProcessed:
+				statuses = append(statuses, status)
+			default:
+				return models.OrderFilter{}, errs.NewAppError(errs.ErrBadRequest, "unknown order status: "+status)
+			}
+		}
+	}
+
+	return models.OrderFilter{
+		Statuses: statuses,
+		From:     from,
+		To:       to,
+		After:    after,
+		Limit:    limit,
+	}, nil
+}
+
+// parseWithdrawalFilter разбирает параметры постраничной выдачи списаний
+func parseWithdrawalFilter(c *gin.Context) (models.WithdrawalFilter, error) {
+	if c.Query("status") != "" {
+		return models.WithdrawalFilter{}, errs.NewAppError(errs.ErrBadRequest, "withdrawals cannot be filtered by status")
+	}
+
+	limit, after, from, to, err := parsePage(c)
+	if err != nil {
+		return models.WithdrawalFilter{}, err
+	}
+
+	return models.WithdrawalFilter{
+		From:  from,
+		To:    to,
+		After: after,
+		Limit: limit,
+	}, nil
+}
+
+// parseTimeParam разбирает дату в формате RFC 3339 или YYYY-MM-DD и приводит ее к UTC,
+// так как время в хранилище записано без часового пояса
+func parseTimeParam(c *gin.Context, name string) (*time.Time, error) {
+	v := c.Query(name)
+	if v == "" {
+		return nil, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, v); err == nil {
+			t = t.UTC()
+			return &t, nil
+		}
+	}
+
+	return nil, errs.NewAppError(errs.ErrBadRequest, name+" must be RFC 3339 timestamp or YYYY-MM-DD date")
+}
+
+// decodeCursor декодирует курсор, полученный от клиента
+func decodeCursor(v string) (*models.Cursor, error) {
+	cursor, err := models.DecodeCursor(v)
+	if err != nil {
+		return nil, errs.NewAppError(errs.ErrBadRequest, "invalid cursor")
+	}
+	return cursor, nil
+}
+
+// setNextLink добавляет заголовок Link со ссылкой на следующую страницу
+func setNextLink(c *gin.Context, next *models.Cursor) {
+	if next == nil {
+		return
+	}
+
+	u := *c.Request.URL
+	query := u.Query()
+	query.Set("cursor", next.Encode())
+	u.RawQuery = query.Encode()
+
+	c.Header(httpconst.HeaderLink, fmt.Sprintf(`<%s>; rel="next"`, u.RequestURI()))
+}
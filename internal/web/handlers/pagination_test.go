@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOrderFilterTimes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		want    [2]time.Time
+		wantErr bool
+	}{
+		{
+			name: "utc",
+			from: "2024-03-01T10:00:00Z",
+			to:   "2024-03-02",
+			want: [2]time.Time{
+				time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC),
+				time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name: "offset converted to utc",
+			from: "2024-03-01T10:00:00+03:00",
+			to:   "2024-03-01T10:00:00-05:00",
+			want: [2]time.Time{
+				time.Date(2024, 3, 1, 7, 0, 0, 0, time.UTC),
+				time.Date(2024, 3, 1, 15, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			name:    "from after to across offsets",
+			from:    "2024-03-01T10:00:00-01:00",
+			to:      "2024-03-01T10:00:00+01:00",
+			wantErr: true,
+		},
+		{
+			name:    "invalid",
+			from:    "01.03.2024",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := url.Values{}
+			query.Set("from", tt.from)
+			if tt.to != "" {
+				query.Set("to", tt.to)
+			}
+
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/user/orders?"+query.Encode(), nil)
+
+			filter, err := parseOrderFilter(c)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, filter.From)
+			require.NotNil(t, filter.To)
+
+			// Сравнение по строке проверяет и момент времени, и то, что зона приведена к UTC
+			assert.Equal(t, tt.want[0].String(), filter.From.String())
+			assert.Equal(t, tt.want[1].String(), filter.To.String())
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitslim/gophermart/internal/httpconst"
+	"github.com/gitslim/gophermart/internal/web/dto"
+)
+
+// ExportData возвращает архив персональных данных пользователя
+func (h *Handler) ExportData(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	export, err := h.privacyService.ExportData(c.Request.Context(), userID)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	filename := fmt.Sprintf("gophermart-export-%s.json", export.ExportedAt.Format("20060102-150405"))
+	c.Header(httpconst.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	c.JSON(http.StatusOK, export)
+}
+
+// DeleteAccount удаляет аккаунт пользователя с обезличиванием финансовых записей
+func (h *Handler) DeleteAccount(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	var req dto.DeleteAccountRequest
+	err = bindDTO(c, &req)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	err = h.privacyService.DeleteAccount(c.Request.Context(), userID, req.Password)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	h.auth.ClearAuthCookie(c)
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitslim/gophermart/internal/web/dto"
+)
+
+// GetSessions возвращает активные сессии пользователя
+func (h *Handler) GetSessions(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	sessions, err := h.sessionService.GetSessions(c.Request.Context(), userID)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	currentID := getSessionID(c)
+	response := make([]dto.SessionResponse, 0, len(sessions))
+	for _, s := range sessions {
+		response = append(response, dto.SessionResponse{
+			Session: s,
+			Current: s.ID == currentID,
+		})
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// RevokeSession завершает сессию пользователя на другом или текущем устройстве
+func (h *Handler) RevokeSession(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	sessionID, err := pathInt64(c, "id")
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	err = h.sessionService.RevokeSession(c.Request.Context(), userID, sessionID)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	if sessionID == getSessionID(c) {
+		h.auth.ClearAuthCookie(c)
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/gitslim/gophermart/internal/errs"
+	"github.com/gitslim/gophermart/internal/events"
+	"github.com/gitslim/gophermart/internal/httpconst"
+)
+
+// StreamEvents отправляет события заказов и баланса пользователя в формате Server-Sent Events
+func (h *Handler) StreamEvents(c *gin.Context) {
+	userID, err := getUserID(c)
+	if err != nil {
+		handleError(c, h.log, err)
+		return
+	}
+
+	// Переподключившийся клиент получает события, пропущенные после Last-Event-ID
+	var lastEventID uint64
+	if v := c.GetHeader(httpconst.HeaderLastEventID); v != "" {
+		lastEventID, err = strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			handleError(c, h.log, errs.NewAppError(errs.ErrBadRequest, "invalid Last-Event-ID"))
+			return
+		}
+	}
+
+	backlog, stream, cancel := h.broker.Subscribe(userID, lastEventID)
+	defer cancel()
+
+	c.Header(httpconst.HeaderContentType, httpconst.ContentTypeEventStream)
+	c.Header(httpconst.HeaderCacheControl, "no-cache")
+	c.Status(http.StatusOK)
+
+	for _, event := range backlog {
+		writeEvent(c, event)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, ok := <-stream:
+			// Канал закрыт при остановке сервера или отставании клиента, клиент переподключится
+			if !ok {
+				return
+			}
+			writeEvent(c, event)
+		case <-heartbeat.C:
+			_, _ = c.Writer.WriteString(": heartbeat\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
+
+// writeEvent записывает событие в поток
+func writeEvent(c *gin.Context, event events.Event) {
+	c.Render(-1, sse.Event{
+		Id:    strconv.FormatUint(event.ID, 10),
+		Event: event.Type,
+		Data:  event.Data,
+	})
+}
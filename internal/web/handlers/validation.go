@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gitslim/gophermart/internal/errs"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// Ошибки полей называют поля так же, как они называются в JSON
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(jsonFieldName)
+	}
+}
+
+// bindDTO разбирает JSON тело запроса и проверяет поля по тегам binding
+func bindDTO(c *gin.Context, dto interface{}) error {
+	if err := c.ShouldBindJSON(dto); err != nil {
+		return bindError(err)
+	}
+	return nil
+}
+
+// bindError преобразует ошибку разбора или проверки тела запроса в ошибку с описанием полей
+func bindError(err error) error {
+	var (
+		maxBytesErr    *http.MaxBytesError
+		syntaxErr      *json.SyntaxError
+		typeErr        *json.UnmarshalTypeError
+		validationErrs validator.ValidationErrors
+	)
+
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return errs.NewAppError(errs.ErrPayloadTooLarge, fmt.Sprintf("request body exceeds %d bytes", maxBytesErr.Limit))
+	case errors.Is(err, io.EOF):
+		return errs.NewAppError(errs.ErrValidation, "request body is required")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return errs.NewAppError(errs.ErrBadRequest, "malformed JSON: unexpected end of input")
+	case errors.As(err, &syntaxErr):
+		return errs.NewAppError(errs.ErrBadRequest, fmt.Sprintf("malformed JSON at offset %d", syntaxErr.Offset))
+	case errors.As(err, &typeErr):
+		if typeErr.Field == "" {
+			return errs.NewAppError(errs.ErrValidation, "request body must be "+jsonTypeName(typeErr.Type))
+		}
+		return errs.NewAppError(errs.ErrValidation, "invalid request").WithFields(errs.FieldError{
+			Field:   typeErr.Field,
+			Message: "must be " + jsonTypeName(typeErr.Type),
+		})
+	case errors.As(err, &validationErrs):
+		fields := make([]errs.FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			fields = append(fields, errs.FieldError{Field: fe.Field(), Message: fieldMessage(fe)})
+		}
+		return errs.NewAppError(errs.ErrValidation, "invalid request").WithFields(fields...)
+	default:
+		return errs.NewAppError(errs.ErrBadRequest, "invalid request")
+	}
+}
+
+// fieldMessage описывает нарушенное правило проверки поля
+func fieldMessage(fe validator.FieldError) string {
+	unit := ""
+	if fe.Kind() == reflect.String {
+		unit = " characters"
+	}
+
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "max":
+		return fmt.Sprintf("must be at most %s%s", fe.Param(), unit)
+	case "min":
+		return fmt.Sprintf("must be at least %s%s", fe.Param(), unit)
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "gte":
+		return "must be greater than or equal to " + fe.Param()
+	case "numeric":
+		return "must contain only digits"
+	default:
+		return "is invalid"
+	}
+}
+
+// jsonFieldName возвращает имя поля из тега json
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// jsonTypeName называет ожидаемый тип значения в терминах JSON
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
+}
+
+// normalizeOrderNumber отбрасывает пробельные символы вокруг номера заказа, например перевод строки в конце тела
+func normalizeOrderNumber(number string) string {
+	return strings.TrimSpace(number)
+}
+
+// readOrderNumber читает номер заказа из текстового тела запроса
+func readOrderNumber(c *gin.Context) (string, error) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		return "", bindError(err)
+	}
+
+	number := normalizeOrderNumber(string(body))
+	if number == "" {
+		return "", errs.NewAppError(errs.ErrValidation, "order number is required")
+	}
+
+	return number, nil
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitslim/gophermart/internal/errs"
+	"github.com/gitslim/gophermart/internal/httpconst"
+	"github.com/gitslim/gophermart/internal/logging"
+	"github.com/gitslim/gophermart/internal/service"
+	"github.com/gitslim/gophermart/internal/web/problem"
+)
+
+const (
+	merchantIDKey = "merchantID"
+	apiKeyIDKey   = "apiKeyID"
+	bearerPrefix  = "Bearer "
+)
+
+// APIKeyMiddleware предоставляет middleware для аутентификации магазинов по ключу доступа
+type APIKeyMiddleware struct {
+	merchantService service.MerchantService
+	log             logging.Logger
+}
+
+// NewAPIKeyMiddleware создает новый экземпляр APIKeyMiddleware
+func NewAPIKeyMiddleware(merchantService service.MerchantService, log logging.Logger) *APIKeyMiddleware {
+	return &APIKeyMiddleware{
+		merchantService: merchantService,
+		log:             log,
+	}
+}
+
+// APIKeyRequired проверяет ключ доступа из заголовка Authorization или X-API-Key и наличие у него права scope
+func (m *APIKeyMiddleware) APIKeyRequired(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rawKey := c.GetHeader(httpconst.HeaderAPIKey)
+		if auth := c.GetHeader(httpconst.HeaderAuthorization); rawKey == "" && strings.HasPrefix(auth, bearerPrefix) {
+			rawKey = strings.TrimPrefix(auth, bearerPrefix)
+		}
+		if rawKey == "" {
+			problem.Abort(c, m.log, newUnauthorizedError())
+			return
+		}
+
+		key, err := m.merchantService.Authenticate(c.Request.Context(), rawKey)
+		if err != nil {
+			var e *errs.AppError
+			if errors.As(err, &e) && e.Type == errs.ErrUnauthorized {
+				problem.Abort(c, m.log, newUnauthorizedError())
+			} else {
+				problem.Abort(c, m.log, fmt.Errorf("failed to authenticate api key: %w", err))
+			}
+			return
+		}
+
+		if !key.HasScope(scope) {
+			problem.Abort(c, m.log, errs.NewAppError(errs.ErrForbidden, "forbidden"))
+			return
+		}
+
+		c.Set(merchantIDKey, key.MerchantID)
+		c.Set(apiKeyIDKey, key.ID)
+		c.Next()
+	}
+}
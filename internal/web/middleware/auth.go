@@ -1,90 +1,320 @@
 package middleware
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gitslim/gophermart/internal/conf"
+	"github.com/gitslim/gophermart/internal/errs"
 	"github.com/gitslim/gophermart/internal/logging"
+	"github.com/gitslim/gophermart/internal/models"
+	"github.com/gitslim/gophermart/internal/service"
+	"github.com/gitslim/gophermart/internal/storage"
+	"github.com/gitslim/gophermart/internal/web/problem"
 	"github.com/golang-jwt/jwt/v5"
 )
 
 const (
-	authCookie = "auth_token"
-	userIDKey  = "userID"
+	userIDKey    = "userID"
+	roleKey      = "role"
+	sessionIDKey = "sessionID"
+
+	// preAuthPurpose помечает токен первого шага входа с двухфакторной аутентификацией
+	preAuthPurpose = "2fa"
+	preAuthTTL     = 5 * time.Minute
 )
 
 // AuthMiddleware предоставляет middleware для аутентификации
 type AuthMiddleware struct {
-	secretKey []byte
-	log       logging.Logger
+	secretKey      []byte
+	sessionTTL     time.Duration
+	cookie         cookieConfig
+	userStorage    storage.UserStorage
+	sessionService service.SessionService
+	log            logging.Logger
+}
+
+// cookieConfig содержит атрибуты куки аутентификации
+type cookieConfig struct {
+	name     string
+	domain   string
+	path     string
+	secure   bool
+	sameSite http.SameSite
 }
 
 // NewAuthMiddleware создает новый экземпляр AuthMiddleware
-func NewAuthMiddleware(config *conf.Config, log logging.Logger) *AuthMiddleware {
-	return &AuthMiddleware{
-		secretKey: []byte(config.SecretKey),
-		log:       log,
+func NewAuthMiddleware(config *conf.Config, userStorage storage.UserStorage, sessionService service.SessionService, log logging.Logger) (*AuthMiddleware, error) {
+	sameSite, err := config.CookieSameSiteMode()
+	if err != nil {
+		return nil, err
 	}
+
+	return &AuthMiddleware{
+		secretKey:  []byte(config.SecretKey),
+		sessionTTL: config.SessionTTL,
+		cookie: cookieConfig{
+			name:     config.CookieName,
+			domain:   config.CookieDomain,
+			path:     config.CookiePath,
+			secure:   config.CookieSecure,
+			sameSite: sameSite,
+		},
+		userStorage:    userStorage,
+		sessionService: sessionService,
+		log:            log,
+	}, nil
 }
 
 // AuthRequired проверяет JWT токен в куки
 func (m *AuthMiddleware) AuthRequired(c *gin.Context) {
-	cookie, err := c.Cookie(authCookie)
+	cookie, err := c.Cookie(m.cookie.name)
+	if err != nil {
+		problem.Abort(c, m.log, newUnauthorizedError())
+		return
+	}
+
+	user, sessionID, err := m.Authenticate(c.Request.Context(), cookie)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-		c.Abort()
+		problem.Abort(c, m.log, err)
 		return
 	}
 
-	token, err := jwt.Parse(cookie, func(token *jwt.Token) (interface{}, error) {
+	c.Set(userIDKey, user.ID)
+	c.Set(roleKey, user.Role)
+	c.Set(sessionIDKey, sessionID)
+	c.Next()
+}
+
+// Authenticate проверяет токен сессии и возвращает пользователя и ID сессии.
+// Используется также транспортами помимо HTTP.
+func (m *AuthMiddleware) Authenticate(ctx context.Context, tokenString string) (*models.User, int64, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 		return m.secretKey, nil
 	})
-
 	if err != nil || !token.Valid {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-		c.Abort()
-		return
+		return nil, 0, newUnauthorizedError()
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-		c.Abort()
-		return
+		return nil, 0, newUnauthorizedError()
+	}
+
+	// Токен первого шага входа не дает доступа к API
+	if _, ok := claims["purpose"]; ok {
+		return nil, 0, newUnauthorizedError()
 	}
 
 	userID, ok := claims["user_id"].(float64)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-		c.Abort()
-		return
+		return nil, 0, newUnauthorizedError()
 	}
 
-	c.Set(userIDKey, int64(userID))
-	c.Next()
+	// Токены, выданные до смены пароля, считаются отозванными
+	tokenVersion, _ := claims["token_version"].(float64)
+	role, _ := claims["role"].(string)
+	user, err := m.userStorage.GetUserByID(ctx, int64(userID))
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get user %d: %w", int64(userID), err)
+	}
+	// Смена роли также требует повторного входа
+	if user == nil || user.TokenVersion != int64(tokenVersion) || user.Role != role {
+		return nil, 0, newUnauthorizedError()
+	}
+
+	// Токен должен ссылаться на действующую сессию
+	sessionID, ok := claims["session_id"].(float64)
+	if !ok {
+		return nil, 0, newUnauthorizedError()
+	}
+	if _, err := m.sessionService.ValidateSession(ctx, user.ID, int64(sessionID)); err != nil {
+		var e *errs.AppError
+		if errors.As(err, &e) && e.Type == errs.ErrUnauthorized {
+			return nil, 0, newUnauthorizedError()
+		}
+		return nil, 0, fmt.Errorf("failed to validate session %d: %w", int64(sessionID), err)
+	}
+
+	return user, int64(sessionID), nil
+}
+
+// newUnauthorizedError создает ошибку отсутствующей или недействительной аутентификации
+func newUnauthorizedError() error {
+	return errs.NewAppError(errs.ErrUnauthorized, "unauthorized")
+}
+
+// RequireRole пропускает только пользователей с одной из указанных ролей, используется после AuthRequired
+func (m *AuthMiddleware) RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString(roleKey)
+		for _, r := range roles {
+			if role == r {
+				c.Next()
+				return
+			}
+		}
+
+		problem.Abort(c, m.log, errs.NewAppError(errs.ErrForbidden, "forbidden"))
+	}
+}
+
+// GenerateToken создает новый JWT токен для сессии пользователя
+func (m *AuthMiddleware) GenerateToken(user *models.User, session *models.Session) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user_id":       user.ID,
+		"session_id":    session.ID,
+		"token_version": user.TokenVersion,
+		"role":          user.Role,
+		"exp":           session.ExpiresAt.Unix(),
+	})
+
+	return token.SignedString(m.secretKey)
+}
+
+// StartSession создает сессию для устройства клиента и устанавливает ее токен в куки
+func (m *AuthMiddleware) StartSession(c *gin.Context, user *models.User) error {
+	session, token, err := m.IssueSession(c.Request.Context(), user, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		return err
+	}
+
+	m.SetAuthCookie(c, token)
+	c.Set(userIDKey, user.ID)
+	c.Set(sessionIDKey, session.ID)
+
+	return nil
+}
+
+// IssueSession создает сессию для устройства клиента и выпускает ее токен
+func (m *AuthMiddleware) IssueSession(ctx context.Context, user *models.User, userAgent, ip string) (*models.Session, string, error) {
+	session, err := m.sessionService.CreateSession(ctx, user.ID, userAgent, ip)
+	if err != nil {
+		return nil, "", err
+	}
+
+	token, err := m.GenerateToken(user, session)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return session, token, nil
+}
+
+// RefreshSession перевыпускает токен текущей сессии, например после смены версии токенов
+func (m *AuthMiddleware) RefreshSession(c *gin.Context, user *models.User) error {
+	session, err := m.sessionService.ValidateSession(c.Request.Context(), user.ID, c.GetInt64(sessionIDKey))
+	if err != nil {
+		return err
+	}
+
+	token, err := m.GenerateToken(user, session)
+	if err != nil {
+		return err
+	}
+
+	m.SetAuthCookie(c, token)
+
+	return nil
+}
+
+// PreAuthToken описывает проверенный токен первого шага входа
+type PreAuthToken struct {
+	UserID int64
+	// IssuedAt - время выпуска в микросекундах, по нему токен погашается при обмене на сессию
+	IssuedAt int64
 }
 
-// GenerateToken создает новый JWT токен
-func (m *AuthMiddleware) GenerateToken(userID int64) (string, error) {
+// GeneratePreAuthToken создает короткоживущий токен для второго шага входа
+func (m *AuthMiddleware) GeneratePreAuthToken(user *models.User) (string, error) {
+	now := time.Now()
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": userID,
-		"exp":     time.Now().Add(24 * time.Hour).Unix(),
+		"user_id":       user.ID,
+		"token_version": user.TokenVersion,
+		"purpose":       preAuthPurpose,
+		"issued_at":     now.UnixMicro(),
+		"exp":           now.Add(preAuthTTL).Unix(),
 	})
 
 	return token.SignedString(m.secretKey)
 }
 
+// ParsePreAuthToken проверяет токен первого шага входа. Токены, выданные до смены пароля
+// или отзыва сессий пользователя, недействительны
+func (m *AuthMiddleware) ParsePreAuthToken(ctx context.Context, tokenString string) (*PreAuthToken, error) {
+	invalid := newInvalidPreAuthTokenError()
+
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		return m.secretKey, nil
+	})
+	if err != nil || !token.Valid {
+		return nil, invalid
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != preAuthPurpose {
+		return nil, invalid
+	}
+
+	userID, ok := claims["user_id"].(float64)
+	if !ok {
+		return nil, invalid
+	}
+	issuedAt, ok := claims["issued_at"].(float64)
+	if !ok {
+		return nil, invalid
+	}
+
+	tokenVersion, _ := claims["token_version"].(float64)
+	user, err := m.userStorage.GetUserByID(ctx, int64(userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user %d: %w", int64(userID), err)
+	}
+	if user == nil || user.TokenVersion != int64(tokenVersion) {
+		return nil, invalid
+	}
+
+	return &PreAuthToken{UserID: user.ID, IssuedAt: int64(issuedAt)}, nil
+}
+
+// ConsumePreAuthToken погашает токен первого шага входа после проверки второго фактора.
+// Условное обновление в хранилище не дает обменять один токен на несколько сессий
+func (m *AuthMiddleware) ConsumePreAuthToken(ctx context.Context, token *PreAuthToken) error {
+	consumed, err := m.userStorage.ConsumePreAuthToken(ctx, token.UserID, token.IssuedAt)
+	if err != nil {
+		return fmt.Errorf("failed to consume pre-auth token of user %d: %w", token.UserID, err)
+	}
+	if !consumed {
+		return newInvalidPreAuthTokenError()
+	}
+	return nil
+}
+
+// newInvalidPreAuthTokenError создает ошибку недействительного токена первого шага входа
+func newInvalidPreAuthTokenError() error {
+	return errs.NewAppError(errs.ErrUnauthorized, "invalid pre-auth token")
+}
+
 // SetAuthCookie устанавливает JWT токен в куки
 func (m *AuthMiddleware) SetAuthCookie(c *gin.Context, token string) {
+	c.SetSameSite(m.cookie.sameSite)
 	c.SetCookie(
-		authCookie,
+		m.cookie.name,
 		token,
-		int(24*time.Hour.Seconds()), // максимальное время жизни - 24 часа
-		"/",                         // путь
-		"",                          // домен
-		false,                       // secure
-		true,                        // httpOnly
+		int(m.sessionTTL.Seconds()), // максимальное время жизни - время жизни сессии
+		m.cookie.path,
+		m.cookie.domain,
+		m.cookie.secure,
+		true, // httpOnly
 	)
 }
+
+// ClearAuthCookie удаляет куки с JWT токеном
+func (m *AuthMiddleware) ClearAuthCookie(c *gin.Context) {
+	c.SetSameSite(m.cookie.sameSite)
+	c.SetCookie(m.cookie.name, "", -1, m.cookie.path, m.cookie.domain, m.cookie.secure, true)
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/conf"
+	"github.com/gitslim/gophermart/internal/errs"
+	"github.com/gitslim/gophermart/internal/logging/sugared"
+	"github.com/gitslim/gophermart/internal/models"
+	"github.com/gitslim/gophermart/internal/storage/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requireUnauthorized проверяет, что токен отклонен как недействительный
+func requireUnauthorized(t *testing.T, err error) {
+	t.Helper()
+
+	var appErr *errs.AppError
+	require.True(t, errors.As(err, &appErr), "unexpected error %v", err)
+	require.Equal(t, errs.ErrUnauthorized, appErr.Type, appErr.Message)
+}
+
+func TestPreAuthToken(t *testing.T) {
+	ctx := context.Background()
+
+	log, err := sugared.NewLogger()
+	require.NoError(t, err)
+
+	users := memory.NewMemUserStorage(memory.NewDB())
+	auth, err := NewAuthMiddleware(&conf.Config{SecretKey: "secret"}, users, nil, log)
+	require.NoError(t, err)
+
+	user := &models.User{Login: "alice", PasswordHash: "hash", Role: models.RoleUser}
+	require.NoError(t, users.CreateUser(ctx, user))
+
+	issue := func() string {
+		t.Helper()
+		u, err := users.GetUserByID(ctx, user.ID)
+		require.NoError(t, err)
+		token, err := auth.GeneratePreAuthToken(u)
+		require.NoError(t, err)
+		// Время выпуска различает токены с точностью до микросекунды
+		time.Sleep(time.Millisecond)
+		return token
+	}
+
+	t.Run("single use", func(t *testing.T) {
+		preAuth, err := auth.ParsePreAuthToken(ctx, issue())
+		require.NoError(t, err)
+		assert.Equal(t, user.ID, preAuth.UserID)
+
+		require.NoError(t, auth.ConsumePreAuthToken(ctx, preAuth))
+		requireUnauthorized(t, auth.ConsumePreAuthToken(ctx, preAuth))
+	})
+
+	t.Run("earlier token rejected after newer exchanged", func(t *testing.T) {
+		earlier, err := auth.ParsePreAuthToken(ctx, issue())
+		require.NoError(t, err)
+		later, err := auth.ParsePreAuthToken(ctx, issue())
+		require.NoError(t, err)
+
+		require.NoError(t, auth.ConsumePreAuthToken(ctx, later))
+		requireUnauthorized(t, auth.ConsumePreAuthToken(ctx, earlier))
+	})
+
+	t.Run("revoked by password change", func(t *testing.T) {
+		token := issue()
+
+		_, err := users.ChangePassword(ctx, user.ID, "new hash")
+		require.NoError(t, err)
+
+		_, err = auth.ParsePreAuthToken(ctx, token)
+		requireUnauthorized(t, err)
+
+		// Токен, выданный после смены пароля, действителен
+		_, err = auth.ParsePreAuthToken(ctx, issue())
+		require.NoError(t, err)
+	})
+
+	t.Run("session token rejected", func(t *testing.T) {
+		u, err := users.GetUserByID(ctx, user.ID)
+		require.NoError(t, err)
+		token, err := auth.GenerateToken(u, &models.Session{ID: 1, ExpiresAt: time.Now().Add(time.Hour)})
+		require.NoError(t, err)
+
+		_, err = auth.ParsePreAuthToken(ctx, token)
+		requireUnauthorized(t, err)
+	})
+
+	t.Run("foreign signature rejected", func(t *testing.T) {
+		other, err := NewAuthMiddleware(&conf.Config{SecretKey: "other"}, users, nil, log)
+		require.NoError(t, err)
+		token, err := other.GeneratePreAuthToken(user)
+		require.NoError(t, err)
+
+		_, err = auth.ParsePreAuthToken(ctx, token)
+		requireUnauthorized(t, err)
+	})
+}
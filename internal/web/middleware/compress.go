@@ -0,0 +1,341 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/gitslim/gophermart/internal/conf"
+	"github.com/gitslim/gophermart/internal/errs"
+	"github.com/gitslim/gophermart/internal/httpconst"
+	"github.com/gitslim/gophermart/internal/logging"
+	"github.com/gitslim/gophermart/internal/web/problem"
+	"github.com/klauspost/compress/zstd"
+)
+
+// supportedEncodings перечисляет поддерживаемые кодировки ответа в порядке предпочтения сервера
+var supportedEncodings = []string{
+	httpconst.ContentEncodingBr,
+	httpconst.ContentEncodingZstd,
+	httpconst.ContentEncodingGzip,
+	httpconst.ContentEncodingDeflate,
+}
+
+// encoders создают сжимающий writer для кодировки ответа
+var encoders = map[string]func(io.Writer) (io.WriteCloser, error){
+	httpconst.ContentEncodingBr: func(w io.Writer) (io.WriteCloser, error) {
+		return brotli.NewWriterLevel(w, 4), nil
+	},
+	httpconst.ContentEncodingZstd: func(w io.Writer) (io.WriteCloser, error) {
+		return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.SpeedFastest), zstd.WithEncoderConcurrency(1))
+	},
+	httpconst.ContentEncodingGzip: func(w io.Writer) (io.WriteCloser, error) {
+		return gzip.NewWriterLevel(w, gzip.BestSpeed)
+	},
+	httpconst.ContentEncodingDeflate: func(w io.Writer) (io.WriteCloser, error) {
+		return zlib.NewWriterLevel(w, zlib.BestSpeed)
+	},
+}
+
+// decoders создают распаковывающий reader для кодировки тела запроса
+var decoders = map[string]func(io.Reader) (io.ReadCloser, error){
+	httpconst.ContentEncodingBr: func(r io.Reader) (io.ReadCloser, error) {
+		return io.NopCloser(brotli.NewReader(r)), nil
+	},
+	httpconst.ContentEncodingZstd: func(r io.Reader) (io.ReadCloser, error) {
+		d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	},
+	httpconst.ContentEncodingGzip: func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	httpconst.ContentEncodingDeflate: func(r io.Reader) (io.ReadCloser, error) {
+		return zlib.NewReader(r)
+	},
+}
+
+// compressibleContentTypes перечисляет типы ответов, которые имеет смысл сжимать
+var compressibleContentTypes = []string{
+	httpconst.ContentTypeJSON,
+	httpconst.ContentTypeProblemJSON,
+	httpconst.ContentTypeXML,
+	httpconst.ContentTypeJS,
+	httpconst.ContentTypeHTML,
+	httpconst.ContentTypePlain,
+	httpconst.ContentTypeCSV,
+}
+
+// CompressMiddleware распаковывает тела запросов и сжимает ответы согласно Accept-Encoding
+type CompressMiddleware struct {
+	minSize int
+	log     logging.Logger
+}
+
+// NewCompressMiddleware создает новый экземпляр CompressMiddleware
+func NewCompressMiddleware(config *conf.Config, log logging.Logger) *CompressMiddleware {
+	return &CompressMiddleware{
+		minSize: config.CompressionMinSize,
+		log:     log,
+	}
+}
+
+// HandlerFunc распаковывает тело запроса и подменяет writer ответа сжимающим
+func (m *CompressMiddleware) HandlerFunc(c *gin.Context) {
+	closers, err := decodeRequestBody(c)
+	defer func() {
+		for _, closer := range closers {
+			_ = closer.Close()
+		}
+	}()
+	if err != nil {
+		problem.Abort(c, m.log, err)
+		return
+	}
+
+	// Ответ зависит от Accept-Encoding независимо от того, будет ли он сжат
+	c.Writer.Header().Add(httpconst.HeaderVary, httpconst.HeaderAcceptEncoding)
+
+	encoding := negotiateEncoding(c.GetHeader(httpconst.HeaderAcceptEncoding))
+	if encoding == "" {
+		c.Next()
+		return
+	}
+
+	w := &compressResponseWriter{
+		ResponseWriter: c.Writer,
+		encoding:       encoding,
+		minSize:        m.minSize,
+	}
+	c.Writer = w
+
+	defer func() {
+		if r := recover(); r != nil {
+			// Если ответ еще не начат, возвращаем исходный writer, чтобы Recovery отправил ошибку без сжатия.
+			// Начатый сжатый поток не завершается, и клиент видит оборванный ответ
+			if !w.decided {
+				c.Writer = w.ResponseWriter
+			}
+			panic(r)
+		}
+
+		if err := w.finish(); err != nil {
+			logging.FromContext(c.Request.Context(), m.log).Errorf("Failed to finish %s response: %v", encoding, err)
+		}
+	}()
+
+	c.Next()
+}
+
+// decodeRequestBody подменяет тело запроса распаковывающим reader, кодировки снимаются в обратном порядке.
+// Возвращает созданные readers, которые нужно закрыть после обработки запроса
+func decodeRequestBody(c *gin.Context) ([]io.Closer, error) {
+	header := c.GetHeader(httpconst.HeaderContentEncoding)
+	if header == "" {
+		return nil, nil
+	}
+
+	var closers []io.Closer
+	codings := strings.Split(header, ",")
+	for i := len(codings) - 1; i >= 0; i-- {
+		coding := strings.ToLower(strings.TrimSpace(codings[i]))
+		if coding == httpconst.ContentEncodingIdentity {
+			continue
+		}
+
+		decoder, ok := decoders[coding]
+		if !ok {
+			return closers, errs.NewAppError(errs.ErrUnsupportedMediaType, fmt.Sprintf("unsupported content encoding %q", coding))
+		}
+
+		body, err := decoder(c.Request.Body)
+		if err != nil {
+			return closers, errs.NewAppError(errs.ErrBadRequest, fmt.Sprintf("invalid %s request body", coding))
+		}
+		c.Request.Body = body
+		closers = append(closers, body)
+	}
+
+	c.Request.Header.Del(httpconst.HeaderContentEncoding)
+	c.Request.Header.Del(httpconst.HeaderContentLength)
+	c.Request.ContentLength = -1
+
+	return closers, nil
+}
+
+// negotiateEncoding выбирает кодировку ответа с наибольшим q-value, пустая строка означает ответ без сжатия
+func negotiateEncoding(header string) string {
+	if header == "" {
+		return ""
+	}
+
+	accepted := parseAcceptEncoding(header)
+
+	best, bestQ := "", 0.0
+	for _, encoding := range supportedEncodings {
+		q, ok := accepted[encoding]
+		if !ok {
+			q, ok = accepted[httpconst.AcceptEncodingAll]
+		}
+		if ok && q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+
+	return best
+}
+
+// parseAcceptEncoding разбирает Accept-Encoding в отображение кодировки на q-value
+func parseAcceptEncoding(header string) map[string]float64 {
+	accepted := make(map[string]float64)
+
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			name, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok || !strings.EqualFold(name, "q") {
+				continue
+			}
+			v, err := strconv.ParseFloat(value, 64)
+			if err != nil || v < 0 || v > 1 {
+				v = 0
+			}
+			q = v
+		}
+
+		accepted[coding] = q
+	}
+
+	return accepted
+}
+
+// isCompressible сообщает, имеет ли смысл сжимать ответ с указанным Content-Type
+func isCompressible(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+
+	for _, v := range compressibleContentTypes {
+		if mediaType == v {
+			return true
+		}
+	}
+	return false
+}
+
+// compressResponseWriter накапливает начало ответа, пока не станет ясно, нужно ли его сжимать.
+// Решение принимается по типу ответа и его размеру
+type compressResponseWriter struct {
+	gin.ResponseWriter
+
+	encoding string
+	minSize  int
+
+	buf     []byte
+	decided bool
+	encoder io.WriteCloser
+}
+
+func (w *compressResponseWriter) Write(data []byte) (int, error) {
+	if w.decided {
+		return w.write(data)
+	}
+
+	w.buf = append(w.buf, data...)
+	if len(w.buf) >= w.minSize {
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(data), nil
+}
+
+func (w *compressResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// WriteHeaderNow отправляет заголовки, ответ, начатый без тела, не сжимается
+func (w *compressResponseWriter) WriteHeaderNow() {
+	if !w.decided {
+		w.minSize = len(w.buf) + 1
+		_ = w.decide()
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Flush отправляет накопленные данные клиенту, используется потоковыми ответами
+func (w *compressResponseWriter) Flush() {
+	if !w.decided {
+		_ = w.decide()
+	}
+	if f, ok := w.encoder.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide выбирает между сжатием и передачей как есть и отправляет накопленные данные
+func (w *compressResponseWriter) decide() error {
+	w.decided = true
+
+	header := w.Header()
+	status := w.Status()
+	if len(w.buf) >= w.minSize &&
+		status != http.StatusNoContent && status != http.StatusNotModified && status >= http.StatusOK &&
+		header.Get(httpconst.HeaderContentEncoding) == "" &&
+		isCompressible(header.Get(httpconst.HeaderContentType)) {
+		encoder, err := encoders[w.encoding](w.ResponseWriter)
+		if err != nil {
+			return err
+		}
+		w.encoder = encoder
+
+		header.Set(httpconst.HeaderContentEncoding, w.encoding)
+		header.Del(httpconst.HeaderContentLength)
+	}
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := w.write(buf)
+	return err
+}
+
+func (w *compressResponseWriter) write(data []byte) (int, error) {
+	if w.encoder != nil {
+		return w.encoder.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// finish отправляет остаток ответа и завершает сжатый поток
+func (w *compressResponseWriter) finish() error {
+	if !w.decided {
+		// Ответ меньше порога отправляется без сжатия
+		w.minSize = len(w.buf) + 1
+		if err := w.decide(); err != nil {
+			return err
+		}
+	}
+
+	if w.encoder != nil {
+		return w.encoder.Close()
+	}
+	return nil
+}
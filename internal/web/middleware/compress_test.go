@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitslim/gophermart/internal/conf"
+	"github.com/gitslim/gophermart/internal/httpconst"
+	"github.com/gitslim/gophermart/internal/logging/sugared"
+	"github.com/gitslim/gophermart/internal/web/problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCompressTestRouter(t *testing.T, handler gin.HandlerFunc) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	log, err := sugared.NewLogger()
+	require.NoError(t, err)
+
+	logger := NewLoggingMiddleware(log)
+	compress := NewCompressMiddleware(&conf.Config{CompressionMinSize: 16}, log)
+
+	r := gin.New()
+	r.Use(logger.Recovery, compress.HandlerFunc)
+	r.POST("/", handler)
+
+	return r
+}
+
+func TestCompressMiddlewarePanic(t *testing.T) {
+	r := newCompressTestRouter(t, func(c *gin.Context) {
+		panic("boom")
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set(httpconst.HeaderAcceptEncoding, "gzip")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	// Ошибка, записанная Recovery, доходит до клиента целиком и без сжатия
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, w.Header().Get(httpconst.HeaderContentEncoding))
+
+	var p problem.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &p))
+	assert.Equal(t, http.StatusInternalServerError, p.Status)
+}
+
+func TestCompressMiddlewareNegotiation(t *testing.T) {
+	body := strings.Repeat(`{"number":"12345678903"}`, 10)
+	r := newCompressTestRouter(t, func(c *gin.Context) {
+		data, err := io.ReadAll(c.Request.Body)
+		require.NoError(t, err)
+		c.Data(http.StatusOK, httpconst.ContentTypeJSON, data)
+	})
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		want           string
+	}{
+		{name: "no header", acceptEncoding: "", want: ""},
+		{name: "server preference", acceptEncoding: "gzip, br", want: httpconst.ContentEncodingBr},
+		{name: "highest q-value", acceptEncoding: "br;q=0.5, gzip;q=0.9", want: httpconst.ContentEncodingGzip},
+		{name: "rejected with q=0", acceptEncoding: "gzip;q=0", want: ""},
+		{name: "wildcard", acceptEncoding: "*;q=0.5, br;q=0, zstd;q=0", want: httpconst.ContentEncodingGzip},
+		{name: "unsupported", acceptEncoding: "compress", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Тело запроса сжато gzip и распаковывается перед обработчиком
+			var compressed bytes.Buffer
+			zw := gzip.NewWriter(&compressed)
+			_, err := zw.Write([]byte(body))
+			require.NoError(t, err)
+			require.NoError(t, zw.Close())
+
+			req := httptest.NewRequest(http.MethodPost, "/", &compressed)
+			req.Header.Set(httpconst.HeaderContentEncoding, httpconst.ContentEncodingGzip)
+			if tt.acceptEncoding != "" {
+				req.Header.Set(httpconst.HeaderAcceptEncoding, tt.acceptEncoding)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.want, w.Header().Get(httpconst.HeaderContentEncoding))
+			assert.Contains(t, w.Header().Values(httpconst.HeaderVary), httpconst.HeaderAcceptEncoding)
+
+			if tt.want == "" {
+				assert.Equal(t, body, w.Body.String())
+			}
+			if tt.want == httpconst.ContentEncodingGzip {
+				zr, err := gzip.NewReader(w.Body)
+				require.NoError(t, err)
+				data, err := io.ReadAll(zr)
+				require.NoError(t, err)
+				assert.Equal(t, body, string(data))
+			}
+		})
+	}
+}
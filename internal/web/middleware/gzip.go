@@ -1,77 +0,0 @@
-package middleware
-
-import (
-	"compress/gzip"
-	"fmt"
-	"io"
-	"net/http"
-	"strings"
-
-	"github.com/gin-gonic/gin"
-	"github.com/gitslim/gophermart/internal/httpconst"
-)
-
-type GzipMiddleware struct {
-}
-
-func NewGzipMiddleware() *GzipMiddleware {
-	return &GzipMiddleware{}
-}
-
-type gzipResponseWriter struct {
-	gin.ResponseWriter
-	Writer io.Writer
-}
-
-func (w *gzipResponseWriter) Write(data []byte) (int, error) {
-	return w.Writer.Write(data)
-}
-
-func isCompressionAcceptable(c *gin.Context) bool {
-	return strings.Contains(c.GetHeader(httpconst.HeaderAcceptEncoding), httpconst.AcceptEncodingGzip)
-}
-
-func isContentTypeCompressable(c *gin.Context) bool {
-	supportedContentTypes := []string{httpconst.ContentTypeJSON, httpconst.ContentTypeHTML}
-	ct := c.GetHeader(httpconst.HeaderContentType)
-	for _, v := range supportedContentTypes {
-		if strings.Contains(v, ct) {
-			return true
-		}
-	}
-	return false
-}
-
-func isRequestCompressed(c *gin.Context) bool {
-	return c.GetHeader(httpconst.HeaderContentEncoding) == httpconst.ContentEncodingGzip
-}
-
-func (m *GzipMiddleware) HandlerFunc(c *gin.Context) {
-	if isRequestCompressed(c) {
-		gzReader, err := gzip.NewReader(c.Request.Body)
-		if err != nil {
-			fmt.Printf("Gzreader error: %v\n", err)
-			c.AbortWithStatus(http.StatusBadRequest)
-			return
-		}
-		defer gzReader.Close()
-
-		c.Request.Body = io.NopCloser(gzReader)
-	}
-
-	if isCompressionAcceptable(c) && isContentTypeCompressable(c) {
-		// gzWriter := gzip.NewWriter(c.Writer)
-		gzWriter, err := gzip.NewWriterLevel(c.Writer, gzip.BestSpeed)
-		if err != nil {
-			c.AbortWithStatus(http.StatusInternalServerError)
-			return
-		}
-		defer gzWriter.Close()
-
-		c.Header(httpconst.HeaderContentEncoding, httpconst.ContentEncodingGzip)
-
-		c.Writer = &gzipResponseWriter{Writer: gzWriter, ResponseWriter: c.Writer}
-
-	}
-	c.Next()
-}
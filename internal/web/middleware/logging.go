@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitslim/gophermart/internal/logging"
+	"github.com/gitslim/gophermart/internal/web/problem"
+)
+
+// LoggingMiddleware присваивает запросам идентификатор и пишет журнал доступа
+type LoggingMiddleware struct {
+	log logging.Logger
+}
+
+// NewLoggingMiddleware создает новый экземпляр LoggingMiddleware
+func NewLoggingMiddleware(log logging.Logger) *LoggingMiddleware {
+	return &LoggingMiddleware{log: log}
+}
+
+// RequestID принимает идентификатор запроса из X-Request-ID или генерирует новый, возвращает его в ответе
+// и кладет в контекст запроса логгер с этим идентификатором
+func (m *LoggingMiddleware) RequestID(c *gin.Context) {
+	requestID := problem.RequestID(c)
+
+	log := m.log.With("request_id", requestID)
+	c.Request = c.Request.WithContext(logging.WithContext(c.Request.Context(), log))
+
+	c.Next()
+}
+
+// AccessLog пишет структурированную запись о каждом обработанном запросе,
+// в том числе о запросах, прерванных разрывом соединения
+func (m *LoggingMiddleware) AccessLog(c *gin.Context) {
+	defer m.logRequest(c, time.Now())
+
+	c.Next()
+}
+
+// logRequest пишет запись журнала доступа для завершенного запроса
+func (m *LoggingMiddleware) logRequest(c *gin.Context, start time.Time) {
+	bytes := c.Writer.Size()
+	if bytes < 0 {
+		bytes = 0
+	}
+
+	fields := []interface{}{
+		"method", c.Request.Method,
+		"path", c.Request.URL.Path,
+		"route", c.FullPath(),
+		"status", c.Writer.Status(),
+		"latency", time.Since(start),
+		"bytes", bytes,
+		"client_ip", c.ClientIP(),
+		"user_agent", c.Request.UserAgent(),
+	}
+	if userID := c.GetInt64(userIDKey); userID != 0 {
+		fields = append(fields, "user_id", userID)
+	}
+
+	log := logging.FromContext(c.Request.Context(), m.log)
+	if c.Writer.Status() >= http.StatusInternalServerError {
+		log.Error("HTTP request", fields...)
+		return
+	}
+	log.Info("HTTP request", fields...)
+}
+
+// Recovery перехватывает панику обработчика и отвечает внутренней ошибкой.
+// http.ErrAbortHandler пропускается к серверу, который разрывает соединение без ответа
+func (m *LoggingMiddleware) Recovery(c *gin.Context) {
+	defer func() {
+		if r := recover(); r != nil {
+			if r == http.ErrAbortHandler {
+				panic(r)
+			}
+			problem.Abort(c, m.log, fmt.Errorf("panic recovered: %v\n%s", r, debug.Stack()))
+		}
+	}()
+
+	c.Next()
+}
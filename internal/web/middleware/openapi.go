@@ -0,0 +1,152 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/gin-gonic/gin"
+	"github.com/gitslim/gophermart/internal/conf"
+	"github.com/gitslim/gophermart/internal/errs"
+	"github.com/gitslim/gophermart/internal/httpconst"
+	"github.com/gitslim/gophermart/internal/logging"
+	"github.com/gitslim/gophermart/internal/web/openapi"
+	"github.com/gitslim/gophermart/internal/web/problem"
+)
+
+// maxBodySizeExtension - расширение операции в спецификации с максимальным размером тела запроса в байтах
+const maxBodySizeExtension = "x-max-body-size"
+
+// invalidContentTypeReason - начало причины ошибки openapi3filter при неподходящем Content-Type
+const invalidContentTypeReason = "header Content-Type has unexpected value"
+
+// OpenAPIMiddleware проверяет входящие запросы на соответствие спецификации OpenAPI
+type OpenAPIMiddleware struct {
+	spec        *openapi.Spec
+	maxBodySize int64
+	log         logging.Logger
+}
+
+// NewOpenAPIMiddleware создает новый экземпляр OpenAPIMiddleware
+func NewOpenAPIMiddleware(config *conf.Config, spec *openapi.Spec, log logging.Logger) *OpenAPIMiddleware {
+	return &OpenAPIMiddleware{
+		spec:        spec,
+		maxBodySize: config.MaxBodySize,
+		log:         log,
+	}
+}
+
+// ValidateRequest ограничивает размер тела и проверяет параметры, Content-Type и тело запроса,
+// маршруты вне спецификации пропускаются
+func (m *OpenAPIMiddleware) ValidateRequest(c *gin.Context) {
+	route, pathParams, err := m.spec.FindRoute(c.Request)
+	if err != nil {
+		c.Next()
+		return
+	}
+
+	// Ограничение ставится до чтения тела, в том числе распакованного CompressMiddleware
+	if limit := m.bodyLimit(route.Operation); limit > 0 && c.Request.Body != nil && c.Request.Body != http.NoBody {
+		if c.Request.ContentLength > limit {
+			problem.Abort(c, m.log, payloadTooLargeError(limit))
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+	}
+
+	input := &openapi3filter.RequestValidationInput{
+		Request:    c.Request,
+		PathParams: pathParams,
+		Route:      route,
+		Options: &openapi3filter.Options{
+			// Аутентификацию выполняют AuthMiddleware и APIKeyMiddleware
+			AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		},
+	}
+
+	if err := openapi3filter.ValidateRequest(c.Request.Context(), input); err != nil {
+		m.log.Debugf("Request %s %s does not match openapi spec: %v", c.Request.Method, c.Request.URL.Path, err)
+		problem.Abort(c, m.log, validationError(err))
+		return
+	}
+
+	c.Next()
+}
+
+// bodyLimit возвращает максимальный размер тела запроса операции
+func (m *OpenAPIMiddleware) bodyLimit(operation *openapi3.Operation) int64 {
+	if v, ok := operation.Extensions[maxBodySizeExtension].(float64); ok && v > 0 {
+		return int64(v)
+	}
+	return m.maxBodySize
+}
+
+// payloadTooLargeError формирует ошибку превышения размера тела запроса
+func payloadTooLargeError(limit int64) *errs.AppError {
+	return errs.NewAppError(errs.ErrPayloadTooLarge, fmt.Sprintf("request body exceeds %d bytes", limit))
+}
+
+// validationError формирует ошибку проверки с указанием поля, без дампа схемы
+func validationError(err error) *errs.AppError {
+	var reqErr *openapi3filter.RequestError
+	if !errors.As(err, &reqErr) {
+		return errs.NewAppError(errs.ErrValidation, "invalid request")
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(reqErr.Err, &maxBytesErr) {
+		return payloadTooLargeError(maxBytesErr.Limit)
+	}
+
+	if reqErr.RequestBody != nil && strings.HasPrefix(reqErr.Reason, invalidContentTypeReason) {
+		return errs.NewAppError(errs.ErrUnsupportedMediaType, unsupportedContentTypeMessage(reqErr))
+	}
+
+	subject := "request"
+	field := ""
+	switch {
+	case reqErr.Parameter != nil:
+		subject = "parameter " + reqErr.Parameter.Name
+		field = reqErr.Parameter.Name
+	case reqErr.RequestBody != nil:
+		subject = "request body"
+	}
+
+	var schemaErr *openapi3.SchemaError
+	if errors.As(reqErr.Err, &schemaErr) {
+		if pointer := strings.Join(schemaErr.JSONPointer(), "."); pointer != "" {
+			field = pointer
+		}
+		appErr := errs.NewAppError(errs.ErrValidation, "invalid "+subject)
+		if field != "" {
+			return appErr.WithFields(errs.FieldError{Field: field, Message: schemaErr.Reason})
+		}
+		appErr.Message += ": " + schemaErr.Reason
+		return appErr
+	}
+
+	message := "invalid " + subject
+	if reqErr.Reason != "" {
+		message += ": " + reqErr.Reason
+	} else if reqErr.Err != nil {
+		message += ": " + reqErr.Err.Error()
+	}
+	return errs.NewAppError(errs.ErrValidation, message)
+}
+
+// unsupportedContentTypeMessage перечисляет типы тела, допустимые для операции
+func unsupportedContentTypeMessage(reqErr *openapi3filter.RequestError) string {
+	contentType := reqErr.Input.Request.Header.Get(httpconst.HeaderContentType)
+
+	expected := make([]string, 0, len(reqErr.RequestBody.Content))
+	for mediaType := range reqErr.RequestBody.Content {
+		expected = append(expected, mediaType)
+	}
+	sort.Strings(expected)
+
+	return fmt.Sprintf("unsupported content type %q, expected %s", contentType, strings.Join(expected, " or "))
+}
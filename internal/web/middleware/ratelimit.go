@@ -0,0 +1,93 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitslim/gophermart/internal/conf"
+	"github.com/gitslim/gophermart/internal/errs"
+	"github.com/gitslim/gophermart/internal/httpconst"
+	"github.com/gitslim/gophermart/internal/logging"
+	"github.com/gitslim/gophermart/internal/ratelimit"
+	"github.com/gitslim/gophermart/internal/web/problem"
+)
+
+// RateLimitMiddleware ограничивает частоту запросов: публичные маршруты по IP клиента,
+// защищенные - по ID пользователя, маршруты магазинов - по ключу доступа
+type RateLimitMiddleware struct {
+	store    ratelimit.Store
+	public   ratelimit.Limit
+	api      ratelimit.Limit
+	orders   ratelimit.Limit
+	merchant ratelimit.Limit
+	log      logging.Logger
+}
+
+// NewRateLimitMiddleware создает новый экземпляр RateLimitMiddleware
+func NewRateLimitMiddleware(config *conf.Config, store ratelimit.Store, log logging.Logger) *RateLimitMiddleware {
+	return &RateLimitMiddleware{
+		store:    store,
+		public:   config.RateLimitPublic,
+		api:      config.RateLimitAPI,
+		orders:   config.RateLimitOrders,
+		merchant: config.RateLimitMerchant,
+		log:      log,
+	}
+}
+
+// Public ограничивает публичные маршруты по IP клиента
+func (m *RateLimitMiddleware) Public(c *gin.Context) {
+	m.limit(c, ratelimit.ClassPublic, m.public, c.ClientIP())
+}
+
+// API ограничивает защищенные маршруты по ID пользователя, используется после AuthRequired
+func (m *RateLimitMiddleware) API(c *gin.Context) {
+	m.limit(c, ratelimit.ClassAPI, m.api, strconv.FormatInt(c.GetInt64(userIDKey), 10))
+}
+
+// Orders ограничивает загрузку заказов по ID пользователя, используется после AuthRequired
+func (m *RateLimitMiddleware) Orders(c *gin.Context) {
+	m.limit(c, ratelimit.ClassOrders, m.orders, strconv.FormatInt(c.GetInt64(userIDKey), 10))
+}
+
+// Merchant ограничивает маршруты магазинов по ID ключа доступа, используется после APIKeyRequired
+func (m *RateLimitMiddleware) Merchant(c *gin.Context) {
+	m.limit(c, ratelimit.ClassMerchant, m.merchant, strconv.FormatInt(c.GetInt64(apiKeyIDKey), 10))
+}
+
+// limit расходует запрос из квоты класса для ключа клиента и выставляет заголовки RateLimit-*
+func (m *RateLimitMiddleware) limit(c *gin.Context, class string, limit ratelimit.Limit, key string) {
+	if !limit.Enabled() {
+		c.Next()
+		return
+	}
+
+	result, err := m.store.Take(c.Request.Context(), ratelimit.Key(class, key), limit)
+	if err != nil {
+		// Недоступность хранилища квот не должна останавливать сервис
+		logging.FromContext(c.Request.Context(), m.log).Errorf("Failed to check rate limit %s for %s: %v", class, key, err)
+		c.Next()
+		return
+	}
+
+	c.Header(httpconst.HeaderRateLimitPolicy, fmt.Sprintf("%d;w=%d", limit.Requests, ceilSeconds(limit.Period)))
+	c.Header(httpconst.HeaderRateLimitLimit, strconv.Itoa(limit.Requests))
+	c.Header(httpconst.HeaderRateLimitRemaining, strconv.Itoa(result.Remaining))
+	c.Header(httpconst.HeaderRateLimitReset, strconv.Itoa(ceilSeconds(result.Reset)))
+
+	if !result.Allowed {
+		c.Header(httpconst.HeaderRetryAfter, strconv.Itoa(max(ceilSeconds(result.RetryAfter), 1)))
+		problem.Abort(c, m.log, errs.NewAppError(errs.ErrTooManyRequests, "rate limit exceeded"))
+		return
+	}
+
+	c.Next()
+}
+
+// ceilSeconds округляет длительность вверх до целых секунд
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
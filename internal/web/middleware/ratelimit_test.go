@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitslim/gophermart/internal/conf"
+	"github.com/gitslim/gophermart/internal/httpconst"
+	"github.com/gitslim/gophermart/internal/logging/sugared"
+	"github.com/gitslim/gophermart/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newRateLimitTestRouter(t *testing.T, limit ratelimit.Limit) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	log, err := sugared.NewLogger()
+	require.NoError(t, err)
+
+	m := NewRateLimitMiddleware(&conf.Config{RateLimitPublic: limit, RateLimitMerchant: limit}, ratelimit.NewMemoryStore(), log)
+
+	r := gin.New()
+	r.GET("/public", m.Public, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	r.GET("/merchant/:key", func(c *gin.Context) {
+		c.Set(apiKeyIDKey, int64(len(c.Param("key"))))
+	}, m.Merchant, func(c *gin.Context) {
+		c.Status(http.StatusAccepted)
+	})
+
+	return r
+}
+
+func TestRateLimitTooManyRequests(t *testing.T) {
+	r := newRateLimitTestRouter(t, ratelimit.Limit{Requests: 2, Period: time.Minute})
+
+	do := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/public", nil))
+		return w
+	}
+
+	w := do()
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2;w=60", w.Header().Get(httpconst.HeaderRateLimitPolicy))
+	assert.Equal(t, "2", w.Header().Get(httpconst.HeaderRateLimitLimit))
+	assert.Equal(t, "1", w.Header().Get(httpconst.HeaderRateLimitRemaining))
+
+	require.Equal(t, http.StatusOK, do().Code)
+
+	// Токен восстанавливается за Period/Requests, Retry-After округляется вверх до секунд
+	w = do()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get(httpconst.HeaderRateLimitRemaining))
+	assert.Equal(t, "30", w.Header().Get(httpconst.HeaderRetryAfter))
+	assert.Equal(t, httpconst.ContentTypeProblemJSON, w.Header().Get(httpconst.HeaderContentType))
+}
+
+func TestRateLimitMerchantByAPIKey(t *testing.T) {
+	r := newRateLimitTestRouter(t, ratelimit.Limit{Requests: 1, Period: time.Minute})
+
+	do := func(path string) int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusAccepted, do("/merchant/a"))
+	assert.Equal(t, http.StatusTooManyRequests, do("/merchant/a"))
+
+	// Квота другого ключа не зависит от первого, даже с того же IP
+	assert.Equal(t, http.StatusAccepted, do("/merchant/bb"))
+}
+
+func TestRateLimitDisabled(t *testing.T) {
+	r := newRateLimitTestRouter(t, ratelimit.Limit{})
+
+	for i := 0; i < 5; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/public", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get(httpconst.HeaderRateLimitLimit))
+	}
+}
@@ -0,0 +1,107 @@
+package problem
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitslim/gophermart/internal/errs"
+	"github.com/gitslim/gophermart/internal/httpconst"
+	"github.com/gitslim/gophermart/internal/logging"
+)
+
+// RequestIDKey - ключ контекста gin с идентификатором запроса
+const RequestIDKey = "requestID"
+
+// maxRequestIDLength - максимальная длина идентификатора запроса, принимаемого от клиента
+const maxRequestIDLength = 128
+
+// internalMessage заменяет текст внутренних ошибок в ответе клиенту
+const internalMessage = "internal server error"
+
+// Problem представляет ответ с ошибкой в формате RFC 7807
+type Problem struct {
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Status    int               `json:"status"`
+	Detail    string            `json:"detail,omitempty"`
+	Instance  string            `json:"instance,omitempty"`
+	Code      string            `json:"code"`
+	RequestID string            `json:"request_id,omitempty"`
+	Errors    []errs.FieldError `json:"errors,omitempty"`
+}
+
+// Write отправляет ошибку клиенту, внутренние ошибки логируются и скрываются
+func Write(c *gin.Context, log logging.Logger, err error) {
+	requestID := RequestID(c)
+
+	var e *errs.AppError
+	if !errors.As(err, &e) || e.Type == errs.ErrInternal {
+		logging.FromContext(c.Request.Context(), log).Errorf("Request %s %s failed: %v", c.Request.Method, c.Request.URL.Path, err)
+		e = errs.NewAppError(errs.ErrInternal, internalMessage)
+	}
+
+	// Успешные статусы, например повторная загрузка заказа, отправляются без тела
+	status := e.Type.HTTPStatus
+	if status < http.StatusBadRequest {
+		c.Status(status)
+		return
+	}
+
+	c.Header(httpconst.HeaderContentType, httpconst.ContentTypeProblemJSON)
+	c.JSON(status, Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    e.Message,
+		Instance:  c.Request.URL.Path,
+		Code:      e.Type.Code,
+		RequestID: requestID,
+		Errors:    e.Fields,
+	})
+}
+
+// Abort отправляет ошибку клиенту и прерывает обработку запроса
+func Abort(c *gin.Context, log logging.Logger, err error) {
+	Write(c, log, err)
+	c.Abort()
+}
+
+// RequestID возвращает идентификатор запроса, при отсутствии берет его из заголовка или генерирует
+func RequestID(c *gin.Context) string {
+	if id := c.GetString(RequestIDKey); id != "" {
+		return id
+	}
+
+	id := c.GetHeader(httpconst.HeaderRequestID)
+	if !isValidRequestID(id) {
+		id = newRequestID()
+	}
+
+	c.Set(RequestIDKey, id)
+	c.Header(httpconst.HeaderRequestID, id)
+
+	return id
+}
+
+// isValidRequestID отсекает пустые, слишком длинные и небезопасные для логов идентификаторы клиента
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID генерирует случайный идентификатор запроса
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
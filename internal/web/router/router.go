@@ -1,34 +1,77 @@
 package router
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gitslim/gophermart/internal/conf"
+	"github.com/gitslim/gophermart/internal/models"
 	"github.com/gitslim/gophermart/internal/web/handlers"
 	"github.com/gitslim/gophermart/internal/web/middleware"
+	"github.com/gitslim/gophermart/internal/web/openapi"
 )
 
 // NewRouter настраивает маршрутизацию
-func NewRouter(handler *handlers.Handler, gzip *middleware.GzipMiddleware, auth *middleware.AuthMiddleware) *gin.Engine {
-	r := gin.Default()
-	r.Use(gzip.HandlerFunc)
+func NewRouter(config *conf.Config, handler *handlers.Handler, adminHandler *handlers.AdminHandler, merchantHandler *handlers.MerchantHandler, healthHandler *handlers.HealthHandler, logger *middleware.LoggingMiddleware, compress *middleware.CompressMiddleware, rateLimit *middleware.RateLimitMiddleware, auth *middleware.AuthMiddleware, apiKey *middleware.APIKeyMiddleware, spec *openapi.Spec, validator *middleware.OpenAPIMiddleware) (*gin.Engine, error) {
+	r := gin.New()
+
+	// Без явно заданных прокси X-Forwarded-For игнорируется, иначе клиент мог бы подменить свой IP
+	if err := r.SetTrustedProxies(config.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+
+	r.Use(logger.RequestID, logger.AccessLog, logger.Recovery, compress.HandlerFunc)
 
 	// Пинг для проверки здоровья
 	r.GET("/ping", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"message": "pong"})
 	})
 
-	// Публичные маршруты
-	r.POST("/api/user/register", handler.Register)
-	r.POST("/api/user/login", handler.Login)
+	// Пробы живости и готовности
+	r.GET("/healthz", healthHandler.Liveness)
+	r.GET("/readyz", healthHandler.Readiness)
+
+	// Спецификация API
+	r.GET("/api/openapi.json", spec.ServeJSON)
+	r.GET("/api/docs", spec.ServeUI)
+
+	// Публичные маршруты. Запросы проверяются по спецификации после аутентификации,
+	// чтобы неаутентифицированный клиент получал 401, а не 400
+	public := r.Group("/api/user")
+	public.Use(rateLimit.Public, validator.ValidateRequest)
+	{
+		public.POST("/register", handler.Register)
+		public.POST("/login", handler.Login)
+		public.POST("/login/2fa", handler.LoginSecondFactor)
+	}
 
 	// Защищенные маршруты
 	authorized := r.Group("/api")
-	authorized.Use(auth.AuthRequired)
+	authorized.Use(auth.AuthRequired, rateLimit.API, validator.ValidateRequest)
 	{
+		// Пароль
+		authorized.POST("/user/password", handler.ChangePassword)
+
+		// Персональные данные
+		authorized.GET("/user/export", handler.ExportData)
+		authorized.DELETE("/user", handler.DeleteAccount)
+
+		// Сессии
+		authorized.GET("/user/sessions", handler.GetSessions)
+		authorized.DELETE("/user/sessions/:id", handler.RevokeSession)
+
+		// Двухфакторная аутентификация
+		authorized.POST("/user/2fa/enroll", handler.EnrollTOTP)
+		authorized.POST("/user/2fa/confirm", handler.ConfirmTOTP)
+		authorized.POST("/user/2fa/disable", handler.DisableTOTP)
+
 		// Заказы
-		authorized.POST("/user/orders", handler.UploadOrder)
+		authorized.POST("/user/orders", rateLimit.Orders, handler.UploadOrder)
+		authorized.POST("/user/orders/batch", rateLimit.Orders, handler.UploadOrders)
+		authorized.GET("/user/orders/stream", handler.StreamEvents)
 		authorized.GET("/user/orders", handler.GetOrders)
+		authorized.GET("/user/orders/:number", handler.GetOrder)
 
 		// Баланс
 		authorized.GET("/user/balance", handler.GetBalance)
@@ -36,5 +79,42 @@ func NewRouter(handler *handlers.Handler, gzip *middleware.GzipMiddleware, auth
 		authorized.GET("/user/withdrawals", handler.GetWithdrawals)
 	}
 
-	return r
+	// Административные маршруты
+	admin := r.Group("/api/admin")
+	admin.Use(auth.AuthRequired, auth.RequireRole(models.RoleSupport, models.RoleAdmin), rateLimit.API, validator.ValidateRequest)
+	{
+		// Пользователи
+		admin.GET("/users", adminHandler.SearchUsers)
+		admin.GET("/users/:id", adminHandler.GetUser)
+		admin.GET("/users/:id/orders", adminHandler.GetUserOrders)
+		admin.PUT("/users/:id/role", auth.RequireRole(models.RoleAdmin), adminHandler.SetUserRole)
+
+		// Баланс
+		admin.GET("/users/:id/balance/adjustments", adminHandler.GetBalanceAdjustments)
+		admin.POST("/users/:id/balance/adjustments", adminHandler.AdjustBalance)
+
+		// Заказы
+		admin.GET("/orders/:number", adminHandler.GetOrder)
+		admin.POST("/orders/:number/reprocess", adminHandler.ReprocessOrder)
+
+		// Магазины и их ключи доступа
+		merchants := admin.Group("/merchants", auth.RequireRole(models.RoleAdmin))
+		merchants.GET("", merchantHandler.GetMerchants)
+		merchants.POST("", merchantHandler.CreateMerchant)
+		merchants.GET("/:id/keys", merchantHandler.GetAPIKeys)
+		merchants.POST("/:id/keys", merchantHandler.IssueAPIKey)
+		merchants.POST("/:id/keys/:keyID/rotate", merchantHandler.RotateAPIKey)
+		merchants.DELETE("/:id/keys/:keyID", merchantHandler.RevokeAPIKey)
+
+		// Подробное состояние зависимостей
+		admin.GET("/health", auth.RequireRole(models.RoleAdmin), healthHandler.ReadinessDetails)
+	}
+
+	// Маршруты магазинов, аутентификация по ключу доступа
+	merchant := r.Group("/api/merchant")
+	{
+		merchant.POST("/orders", apiKey.APIKeyRequired(models.ScopeOrdersWrite), rateLimit.Merchant, validator.ValidateRequest, merchantHandler.UploadOrder)
+	}
+
+	return r, nil
 }
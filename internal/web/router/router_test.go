@@ -0,0 +1,62 @@
+package router
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gitslim/gophermart/internal/conf"
+	"github.com/gitslim/gophermart/internal/web/handlers"
+	"github.com/gitslim/gophermart/internal/web/middleware"
+	"github.com/gitslim/gophermart/internal/web/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// undocumentedRoutes - служебные маршруты, которые не описываются в спецификации
+var undocumentedRoutes = map[string]bool{
+	"GET /api/openapi.json": true,
+	"GET /api/docs":         true,
+}
+
+var ginParam = regexp.MustCompile(`:([^/]+)`)
+
+func TestRoutesMatchOpenAPISpec(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	spec, err := openapi.NewSpec()
+	require.NoError(t, err)
+
+	r, err := NewRouter(
+		&conf.Config{},
+		&handlers.Handler{},
+		&handlers.AdminHandler{},
+		&handlers.MerchantHandler{},
+		&handlers.HealthHandler{},
+		middleware.NewLoggingMiddleware(nil),
+		middleware.NewCompressMiddleware(&conf.Config{}, nil),
+		middleware.NewRateLimitMiddleware(&conf.Config{}, nil, nil),
+		&middleware.AuthMiddleware{},
+		&middleware.APIKeyMiddleware{},
+		spec,
+		middleware.NewOpenAPIMiddleware(&conf.Config{}, spec, nil),
+	)
+	require.NoError(t, err)
+
+	var registered []string
+	for _, route := range r.Routes() {
+		key := route.Method + " " + ginParam.ReplaceAllString(route.Path, "{$1}")
+		if !undocumentedRoutes[key] {
+			registered = append(registered, key)
+		}
+	}
+
+	var documented []string
+	for path, item := range spec.Document().Paths.Map() {
+		for method := range item.Operations() {
+			documented = append(documented, method+" "+path)
+		}
+	}
+
+	assert.ElementsMatch(t, documented, registered, "routes registered in NewRouter and operations in openapi.yaml have drifted apart")
+}
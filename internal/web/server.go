@@ -2,34 +2,83 @@ package web
 
 import (
 	"context"
+	"net"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gitslim/gophermart/internal/certs"
 	"github.com/gitslim/gophermart/internal/conf"
 	"github.com/gitslim/gophermart/internal/logging"
 	"go.uber.org/fx"
 )
 
-// RegisterServerHooks регистрирует хуки для запуска и остановки HTTP сервера
-func RegisterServerHooks(lc fx.Lifecycle, cfg *conf.Config, log logging.Logger, router *gin.Engine) {
+// RegisterServerHooks регистрирует хуки для запуска и остановки HTTP сервера.
+// При настроенном TLS сервер обслуживает HTTPS с HTTP/2 и, если задано, перенаправляет HTTP на HTTPS
+func RegisterServerHooks(lc fx.Lifecycle, cfg *conf.Config, log logging.Logger, router *gin.Engine, reloader *certs.Reloader) {
 	srv := &http.Server{
 		Addr:    cfg.RunAddress,
 		Handler: router,
 	}
 
+	if reloader == nil {
+		registerServer(lc, log, "HTTP", srv, srv.ListenAndServe)
+		return
+	}
+
+	srv.TLSConfig = reloader.TLSConfig()
+	srv.TLSConfig.NextProtos = []string{"h2", "http/1.1"}
+	registerServer(lc, log, "HTTPS", srv, func() error {
+		return srv.ListenAndServeTLS("", "")
+	})
+
+	if cfg.TLSRedirectAddress != "" {
+		redirect := &http.Server{
+			Addr:    cfg.TLSRedirectAddress,
+			Handler: redirectToHTTPS(cfg.RunAddress),
+		}
+		registerServer(lc, log, "HTTP redirect", redirect, redirect.ListenAndServe)
+	}
+}
+
+// registerServer регистрирует хуки запуска и остановки сервера
+func registerServer(lc fx.Lifecycle, log logging.Logger, name string, srv *http.Server, serve func() error) {
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
 			go func() {
-				log.Infof("Starting HTTP server on %v", srv.Addr)
-				if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-					log.Fatalf("HTTP server failed: %s", err)
+				log.Infof("Starting %s server on %v", name, srv.Addr)
+				if err := serve(); err != nil && err != http.ErrServerClosed {
+					log.Fatalf("%s server failed: %s", name, err)
 				}
 			}()
 			return nil
 		},
 		OnStop: func(ctx context.Context) error {
-			log.Info("Stopping HTTP server")
+			log.Infof("Stopping %s server", name)
 			return srv.Shutdown(ctx)
 		},
 	})
 }
+
+// redirectToHTTPS перенаправляет запросы на тот же путь по HTTPS на порт основного сервера
+func redirectToHTTPS(httpsAddress string) http.Handler {
+	_, port, _ := net.SplitHostPort(httpsAddress)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		} else {
+			host = strings.Trim(host, "[]")
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		} else if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+
+		target := "https://" + host + r.URL.RequestURI()
+		// 308 сохраняет метод и тело запроса в отличие от 301
+		http.Redirect(w, r, target, http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,38 @@
+package web
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedirectToHTTPS(t *testing.T) {
+	tests := []struct {
+		name         string
+		httpsAddress string
+		host         string
+		want         string
+	}{
+		{name: "custom port", httpsAddress: ":8443", host: "example.com:8080", want: "https://example.com:8443/api/user/orders?page=2"},
+		{name: "default port", httpsAddress: ":443", host: "example.com:8080", want: "https://example.com/api/user/orders?page=2"},
+		{name: "host without port", httpsAddress: "0.0.0.0:8443", host: "example.com", want: "https://example.com:8443/api/user/orders?page=2"},
+		{name: "ipv6 custom port", httpsAddress: ":8443", host: "[::1]:8080", want: "https://[::1]:8443/api/user/orders?page=2"},
+		{name: "ipv6 default port", httpsAddress: ":443", host: "[::1]", want: "https://[::1]/api/user/orders?page=2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/user/orders?page=2", strings.NewReader("12345678903"))
+			req.Host = tt.host
+			w := httptest.NewRecorder()
+			redirectToHTTPS(tt.httpsAddress).ServeHTTP(w, req)
+
+			// 308 сохраняет метод и тело запроса
+			assert.Equal(t, http.StatusPermanentRedirect, w.Code)
+			assert.Equal(t, tt.want, w.Header().Get("Location"))
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/health"
+)
+
+// healthChecker сообщает время последней успешной обработки заказов
+type healthChecker struct {
+	worker *OrderProcessingWorker
+}
+
+// NewHealthChecker создает проверку воркера обработки заказов
+func NewHealthChecker(worker *OrderProcessingWorker) health.Checker {
+	return &healthChecker{worker: worker}
+}
+
+func (c *healthChecker) Name() string {
+	return "order_worker"
+}
+
+func (c *healthChecker) Critical() bool {
+	return false
+}
+
+func (c *healthChecker) Check(_ context.Context) (map[string]interface{}, error) {
+	details := map[string]interface{}{}
+
+	// До первой успешной обработки отсчитываем время от запуска воркера
+	since := c.worker.startedAt.Load()
+	if ns := c.worker.lastSuccess.Load(); ns != 0 {
+		since = ns
+		details["last_success"] = time.Unix(0, ns).UTC().Format(time.RFC3339)
+	}
+
+	if since == 0 {
+		return details, errors.New("worker is not running")
+	}
+	if time.Since(time.Unix(0, since)) > staleAfter {
+		return details, errors.New("no successful processing cycle recently")
+	}
+
+	return details, nil
+}
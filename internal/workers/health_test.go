@@ -0,0 +1,33 @@
+package workers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthChecker(t *testing.T) {
+	w := &OrderProcessingWorker{}
+	checker := NewHealthChecker(w)
+	assert.False(t, checker.Critical())
+
+	_, err := checker.Check(context.Background())
+	assert.EqualError(t, err, "worker is not running")
+
+	// До первой успешной обработки время отсчитывается от запуска
+	w.startedAt.Store(time.Now().UnixNano())
+	details, err := checker.Check(context.Background())
+	assert.NoError(t, err)
+	assert.NotContains(t, details, "last_success")
+
+	w.startedAt.Store(time.Now().Add(-2 * staleAfter).UnixNano())
+	_, err = checker.Check(context.Background())
+	assert.Error(t, err)
+
+	w.lastSuccess.Store(time.Now().UnixNano())
+	details, err = checker.Check(context.Background())
+	assert.NoError(t, err)
+	assert.Contains(t, details, "last_success")
+}
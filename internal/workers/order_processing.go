@@ -2,8 +2,10 @@ package workers
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
+	"github.com/gitslim/gophermart/internal/conf"
 	"github.com/gitslim/gophermart/internal/logging"
 	"github.com/gitslim/gophermart/internal/models"
 	"github.com/gitslim/gophermart/internal/retry"
@@ -12,41 +14,118 @@ import (
 	"go.uber.org/fx"
 )
 
+const (
+	// processInterval - период запуска обработки заказов
+	processInterval = 3 * time.Second
+	// staleAfter - время без успешной обработки, после которого воркер считается неисправным
+	staleAfter = 10 * processInterval
+)
+
 // OrderProcessingWorker представляет фоновый обработчик заказов
 type OrderProcessingWorker struct {
-	orderService service.OrderService
-	orderStorage storage.OrderStorage
-	log          logging.Logger
+	orderService    service.OrderService
+	orderStorage    storage.OrderStorage
+	interval        time.Duration
+	shutdownTimeout time.Duration
+	log             logging.Logger
+
+	// Время запуска и последней успешной обработки в наносекундах Unix
+	startedAt   atomic.Int64
+	lastSuccess atomic.Int64
+
+	// stopping закрывается при остановке: новые пакеты и заказы не берутся в обработку
+	stopping chan struct{}
+	// done закрывается после выхода из цикла обработки
+	done chan struct{}
+	// cancel прерывает обработку текущего заказа, если он не завершился до срока остановки.
+	// Изменения заказа сохраняются одной транзакцией, поэтому прерывание не оставляет их частично примененными
+	cancel context.CancelFunc
+
+	// Итоги остановки, читаются только после закрытия done
+	current     string
+	drained     int
+	skipped     int
+	interrupted bool
 }
 
 // NewOrderProcessingWorker создает новый экземпляр фонового обработчика заказов
-func NewOrderProcessingWorker(orderService service.OrderService, sorderStorage storage.OrderStorage, log logging.Logger) *OrderProcessingWorker {
+func NewOrderProcessingWorker(config *conf.Config, orderService service.OrderService, sorderStorage storage.OrderStorage, log logging.Logger) *OrderProcessingWorker {
 	return &OrderProcessingWorker{
-		orderService: orderService,
-		orderStorage: sorderStorage,
-		log:          log,
+		orderService:    orderService,
+		orderStorage:    sorderStorage,
+		interval:        processInterval,
+		shutdownTimeout: config.WorkerShutdownTimeout,
+		log:             log,
+	}
+}
+
+// Start запускает фоновую обработку заказов с собственным контекстом, не зависящим от контекста запуска приложения
+func (w *OrderProcessingWorker) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	w.stopping = make(chan struct{})
+	w.done = make(chan struct{})
+	w.startedAt.Store(time.Now().UnixNano())
+
+	go w.run(ctx)
+}
+
+// Stop прекращает прием новых заказов и ждет завершения текущего не дольше shutdownTimeout и срока ctx
+func (w *OrderProcessingWorker) Stop(ctx context.Context) error {
+	close(w.stopping)
+
+	ctx, cancel := context.WithTimeout(ctx, w.shutdownTimeout)
+	defer cancel()
+
+	select {
+	case <-w.done:
+	case <-ctx.Done():
+		w.interrupted = true
+		w.cancel()
+		<-w.done
+	}
+	w.cancel()
+
+	if w.interrupted {
+		w.log.Warnf("Order processing worker stopped by deadline while processing order %q: %d order(s) drained, %d pending order(s) left for the next start",
+			w.current, w.drained, w.skipped)
+		return nil
 	}
+
+	w.log.Infof("Order processing worker stopped: %d order(s) drained, %d pending order(s) left for the next start", w.drained, w.skipped)
+	return nil
 }
 
-// Start запускает фоновую обработку заказов
-func (w *OrderProcessingWorker) Start(ctx context.Context) error {
-	ticker := time.NewTicker(3 * time.Second)
+// run выполняет цикл обработки заказов до остановки воркера
+func (w *OrderProcessingWorker) run(ctx context.Context) {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.interval)
 	defer ticker.Stop()
 
 	for {
 		select {
-		case <-ctx.Done():
-			return ctx.Err()
+		case <-w.stopping:
+			return
 		case <-ticker.C:
 			if err := w.processOrders(ctx); err != nil {
 				w.log.Errorf("Failed to process orders: %v", err)
+				continue
 			}
+			w.lastSuccess.Store(time.Now().UnixNano())
 		}
 	}
 }
 
 // processOrders обрабатывает все необработанные заказы
 func (w *OrderProcessingWorker) processOrders(ctx context.Context) error {
+	// select в run выбирает случайно из готовых веток, поэтому тик может прийти уже после начала остановки
+	select {
+	case <-w.stopping:
+		return nil
+	default:
+	}
+
 	// Получаем все заказы в статусе NEW или PROCESSING
 	orders, err := w.orderStorage.GetOrdersByStatuses(ctx, []string{
 		models.OrderStatusNew,
@@ -57,13 +136,40 @@ func (w *OrderProcessingWorker) processOrders(ctx context.Context) error {
 	}
 
 	// Обрабатываем каждый заказ
-	for _, order := range orders {
-		if err := retry.Retry(func() error {
-			return w.orderService.ProcessOrder(ctx, order.Number)
-		}, 3); err != nil {
-			w.log.Errorf("Failed to process order %s: %v", order.Number, err)
+	for i, order := range orders {
+		// При остановке оставшиеся заказы пакета будут обработаны после следующего запуска
+		select {
+		case <-w.stopping:
+			w.skipped = len(orders) - i
+			return nil
+		default:
+		}
+
+		// Логгер с номером заказа связывает записи сервисов и хранилищ с обработкой заказа
+		log := w.log.With("order", order.Number)
+		orderCtx := logging.WithContext(ctx, log)
+
+		w.current = order.Number
+		err := retry.Retry(func() error {
+			return w.orderService.ProcessOrder(orderCtx, order.Number)
+		}, 3)
+		if err != nil && ctx.Err() != nil {
+			// Обработка прервана по сроку остановки, номер заказа остается в итогах
+			return nil
+		}
+		w.current = ""
+
+		if err != nil {
+			log.Errorf("Failed to process order %s: %v", order.Number, err)
 			continue
 		}
+
+		// Заказы, завершенные после начала остановки, считаются дообработанными
+		select {
+		case <-w.stopping:
+			w.drained++
+		default:
+		}
 	}
 
 	return nil
@@ -73,8 +179,11 @@ func (w *OrderProcessingWorker) processOrders(ctx context.Context) error {
 func RegisterOrderProcessingWorkerHooks(lc fx.Lifecycle, worker *OrderProcessingWorker) {
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
-			go worker.Start(ctx)
+			worker.Start()
 			return nil
 		},
+		OnStop: func(ctx context.Context) error {
+			return worker.Stop(ctx)
+		},
 	})
 }
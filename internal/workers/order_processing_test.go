@@ -0,0 +1,90 @@
+package workers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gitslim/gophermart/internal/logging/sugared"
+	"github.com/gitslim/gophermart/internal/models"
+	"github.com/gitslim/gophermart/internal/service"
+	"github.com/gitslim/gophermart/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeOrderService сообщает о начале обработки заказа и ждет release или отмены контекста
+type fakeOrderService struct {
+	service.OrderService
+	started chan string
+	release chan struct{}
+}
+
+func (s *fakeOrderService) ProcessOrder(ctx context.Context, orderNumber string) error {
+	s.started <- orderNumber
+	select {
+	case <-s.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// fakeOrderStorage возвращает заданные заказы для обработки
+type fakeOrderStorage struct {
+	storage.OrderStorage
+	orders []*models.Order
+}
+
+func (s *fakeOrderStorage) GetOrdersByStatuses(_ context.Context, _ []string) ([]*models.Order, error) {
+	return s.orders, nil
+}
+
+func newTestWorker(t *testing.T, shutdownTimeout time.Duration) (*OrderProcessingWorker, *fakeOrderService) {
+	t.Helper()
+
+	log, err := sugared.NewLogger()
+	require.NoError(t, err)
+
+	svc := &fakeOrderService{started: make(chan string, 1), release: make(chan struct{})}
+	orders := &fakeOrderStorage{orders: []*models.Order{{Number: "100"}, {Number: "200"}}}
+
+	return &OrderProcessingWorker{
+		orderService:    svc,
+		orderStorage:    orders,
+		interval:        10 * time.Millisecond,
+		shutdownTimeout: shutdownTimeout,
+		log:             log,
+	}, svc
+}
+
+func TestOrderProcessingWorkerStopDrainsCurrentOrder(t *testing.T) {
+	w, svc := newTestWorker(t, time.Second)
+	w.Start()
+	require.Equal(t, "100", <-svc.started)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		close(svc.release)
+	}()
+	require.NoError(t, w.Stop(context.Background()))
+
+	assert.False(t, w.interrupted)
+	assert.Equal(t, 1, w.drained)
+	assert.Equal(t, 1, w.skipped)
+}
+
+func TestOrderProcessingWorkerStopByDeadline(t *testing.T) {
+	w, svc := newTestWorker(t, 50*time.Millisecond)
+	w.Start()
+	require.Equal(t, "100", <-svc.started)
+
+	start := time.Now()
+	require.NoError(t, w.Stop(context.Background()))
+
+	// Заказ, не завершенный к сроку, прерывается отменой контекста и остается для следующего запуска
+	assert.Less(t, time.Since(start), time.Second)
+	assert.True(t, w.interrupted)
+	assert.Equal(t, "100", w.current)
+	assert.Zero(t, w.drained)
+}
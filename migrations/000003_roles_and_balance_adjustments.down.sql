@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP TABLE IF EXISTS balance_adjustments;
+
+ALTER TABLE users DROP CONSTRAINT IF EXISTS valid_role;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TABLE IF EXISTS merchant_api_keys;
+DROP TABLE IF EXISTS merchants;
+
+COMMIT;
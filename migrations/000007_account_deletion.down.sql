@@ -0,0 +1,25 @@
+BEGIN;
+
+ALTER TABLE recovery_codes DROP CONSTRAINT IF EXISTS recovery_codes_user_id_fkey;
+ALTER TABLE recovery_codes ADD CONSTRAINT recovery_codes_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id);
+
+ALTER TABLE sessions DROP CONSTRAINT IF EXISTS sessions_user_id_fkey;
+ALTER TABLE sessions ADD CONSTRAINT sessions_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id);
+
+ALTER TABLE balance_adjustments DROP CONSTRAINT IF EXISTS balance_adjustments_user_id_fkey;
+ALTER TABLE balance_adjustments ADD CONSTRAINT balance_adjustments_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id);
+
+ALTER TABLE withdrawals DROP CONSTRAINT IF EXISTS withdrawals_user_id_fkey;
+ALTER TABLE withdrawals ADD CONSTRAINT withdrawals_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id);
+
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_user_id_fkey;
+ALTER TABLE orders ADD CONSTRAINT orders_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users(id);
+
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE users DROP COLUMN IF EXISTS last_pre_auth_at;
+ALTER TABLE users ALTER COLUMN totp_secret TYPE VARCHAR(64);
+
+COMMIT;
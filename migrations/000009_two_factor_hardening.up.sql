@@ -0,0 +1,7 @@
+BEGIN;
+
+-- Секрет TOTP хранится зашифрованным и не помещается в прежние 64 символа
+ALTER TABLE users ALTER COLUMN totp_secret TYPE VARCHAR(128);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS last_pre_auth_at BIGINT NOT NULL DEFAULT 0;
+
+COMMIT;
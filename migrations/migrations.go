@@ -0,0 +1,26 @@
+// Package migrations встраивает миграции схемы базы данных в бинарный файл,
+// чтобы они не зависели от рабочего каталога при запуске
+package migrations
+
+import (
+	"embed"
+
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+//go:embed *.sql
+var postgresFS embed.FS
+
+//go:embed sqlite/*.sql
+var sqliteFS embed.FS
+
+// Postgres возвращает источник миграций PostgreSQL
+func Postgres() (source.Driver, error) {
+	return iofs.New(postgresFS, ".")
+}
+
+// SQLite возвращает источник миграций SQLite
+func SQLite() (source.Driver, error) {
+	return iofs.New(sqliteFS, "sqlite")
+}
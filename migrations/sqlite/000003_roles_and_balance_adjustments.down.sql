@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS balance_adjustments;
+
+ALTER TABLE users DROP COLUMN role;
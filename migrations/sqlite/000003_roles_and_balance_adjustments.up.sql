@@ -0,0 +1,15 @@
+-- Роли пользователей, первый администратор назначается вручную:
+-- UPDATE users SET role = 'admin' WHERE login = '...';
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'user'
+    CONSTRAINT valid_role CHECK (role IN ('user', 'support', 'admin'));
+
+CREATE TABLE IF NOT EXISTS balance_adjustments (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id BIGINT NOT NULL REFERENCES users(id),
+    admin_id BIGINT NOT NULL REFERENCES users(id),
+    amount DECIMAL(10,2) NOT NULL,
+    reason TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_balance_adjustments_user_id ON balance_adjustments(user_id);
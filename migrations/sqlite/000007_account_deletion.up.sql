@@ -0,0 +1,4 @@
+-- Финансовые записи хранятся для бухгалтерии и не удаляются вместе с пользователем.
+-- Внешние ключи SQLite без ON DELETE уже запрещают удаление, а учетные данные
+-- удаляются каскадно с 000005 и 000006, поэтому миграция только добавляет колонку
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMP;
@@ -0,0 +1,14 @@
+-- Каждая проверка заказа в системе начислений: смена статуса и зачисленная сумма
+CREATE TABLE IF NOT EXISTS order_status_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    order_id BIGINT NOT NULL REFERENCES orders(id) ON DELETE RESTRICT,
+    previous_status VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    accrual DECIMAL(10,2) NOT NULL DEFAULT 0,
+    credited DECIMAL(10,2) NOT NULL DEFAULT 0,
+    checked_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT valid_previous_status CHECK (previous_status IN ('NEW', 'PROCESSING', 'INVALID', 'PROCESSED')),
+    CONSTRAINT valid_status CHECK (status IN ('NEW', 'PROCESSING', 'INVALID', 'PROCESSED'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_status_history_order_id ON order_status_history(order_id, checked_at);
@@ -0,0 +1,3 @@
+-- SQLite не ограничивает длину VARCHAR, поэтому зашифрованный секрет TOTP помещается
+-- в прежнюю колонку, и миграция только добавляет колонку для одноразовых токенов входа
+ALTER TABLE users ADD COLUMN last_pre_auth_at BIGINT NOT NULL DEFAULT 0;